@@ -0,0 +1,69 @@
+// Command spvdis disassembles a SPIR-V binary into the standard textual form,
+// with ids named from the module's debug info, or with -json prints the
+// interface the spirv package reflects from it:
+//
+//	spvdis shader.frag.spv
+//	spvdis -json shader.vert.spv
+//
+// With no file, or "-", it reads standard input.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/christerso/vulkan-go/spirv"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "spvdis:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	jsonOut := flag.Bool("json", false, "print the reflected interface as JSON")
+	rawIDs := flag.Bool("raw-id", false, "print ids as %N instead of names")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: spvdis [-json] [-raw-id] [file.spv]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var code []byte
+	var err error
+	if name := flag.Arg(0); name == "" || name == "-" {
+		code, err = io.ReadAll(os.Stdin)
+	} else {
+		code, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return err
+	}
+	m, err := spirv.Parse(code)
+	if err != nil {
+		return err
+	}
+
+	if *jsonOut {
+		r, err := m.Reflect()
+		if err != nil {
+			return err
+		}
+		out, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Printf("%s\n", out)
+		return err
+	}
+	return m.Disassemble(os.Stdout, spirv.DisassembleOptions{RawIDs: *rawIDs})
+}
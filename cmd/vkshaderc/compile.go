@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// source is one shader source file and how to compile it.
+type source struct {
+	path  string // as given on the command line
+	hlsl  bool
+	stage string // glslc stage name: vert, frag, comp, ...
+	spv   string // path of the compiled .spv next to the source
+}
+
+// stages are the shader stage file extensions glslc recognizes.
+var stages = map[string]bool{
+	"vert": true, "frag": true, "comp": true, "geom": true, "tesc": true, "tese": true,
+	"mesh": true, "task": true, "rgen": true, "rint": true, "rahit": true, "rchit": true,
+	"rmiss": true, "rcall": true,
+}
+
+// parseSource derives the language and stage of path from its name:
+// name.stage for GLSL, name.stage.glsl, or name.stage.hlsl.
+func parseSource(path string) (source, error) {
+	s := source{path: path}
+	base := path
+	switch ext := filepath.Ext(path); ext {
+	case ".hlsl":
+		s.hlsl = true
+		base = strings.TrimSuffix(path, ext)
+	case ".glsl":
+		base = strings.TrimSuffix(path, ext)
+	}
+	s.stage = strings.TrimPrefix(filepath.Ext(base), ".")
+	if !stages[s.stage] {
+		return s, fmt.Errorf("%s: cannot tell the shader stage; name it like name.frag or name.frag.hlsl", path)
+	}
+	s.spv = base + ".spv"
+	return s, nil
+}
+
+// options are the compile settings shared by all sources. They are part of
+// every content hash.
+type options struct {
+	entry     string
+	targetEnv string // vulkan1.0 ... vulkan1.3
+	optimize  bool
+	includes  []string
+	defines   []string
+}
+
+var includeRe = regexp.MustCompile(`^\s*#\s*include\s*([<"])([^">]+)[">]`)
+
+// contentHash hashes the options and the text of src and every file it
+// includes, transitively, so that editing a header invalidates the shaders
+// that use it. Includes that cannot be resolved are skipped; the compiler
+// reports them if they matter.
+func contentHash(src source, opts options) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "vkshaderc 1\nhlsl=%v stage=%s entry=%s env=%s O=%v\n", src.hlsl, src.stage, opts.entry, opts.targetEnv, opts.optimize)
+	for _, d := range opts.includes {
+		fmt.Fprintf(h, "I=%s\n", d)
+	}
+	for _, d := range opts.defines {
+		fmt.Fprintf(h, "D=%s\n", d)
+	}
+	seen := map[string]bool{}
+	var visit func(name, path string) error
+	visit = func(name, path string) error {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		if seen[abs] {
+			return nil
+		}
+		seen[abs] = true
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "file %q %d\n", name, len(text))
+		h.Write(text)
+		sc := bufio.NewScanner(bytes.NewReader(text))
+		for sc.Scan() {
+			m := includeRe.FindStringSubmatch(sc.Text())
+			if m == nil {
+				continue
+			}
+			if inc := resolveInclude(filepath.Dir(path), m[1] == `"`, m[2], opts.includes); inc != "" {
+				if err := visit(m[2], inc); err != nil {
+					return err
+				}
+			}
+		}
+		return sc.Err()
+	}
+	if err := visit(filepath.Base(src.path), src.path); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// resolveInclude finds an included file the way the compilers do: a quoted
+// name relative to the including file first, then the -I directories.
+func resolveInclude(dir string, quoted bool, name string, includes []string) string {
+	var dirs []string
+	if quoted {
+		dirs = append(dirs, dir)
+	}
+	for _, d := range append(dirs, includes...) {
+		p := filepath.Join(d, name)
+		if fi, err := os.Stat(p); err == nil && !fi.IsDir() {
+			return p
+		}
+	}
+	return ""
+}
+
+// compilerNames lists the compilers to look for, in order of preference.
+func compilerNames(hlsl bool) []string {
+	if hlsl {
+		return []string{"dxc", "glslc", "glslangValidator"}
+	}
+	return []string{"glslc", "glslangValidator"}
+}
+
+// findCompiler returns the compiler to use for src: the one named by want, or
+// the first installed one. It returns "" when none is installed.
+func findCompiler(want string, src source) (string, error) {
+	if want != "" {
+		if want == "dxc" && !src.hlsl {
+			return "", fmt.Errorf("%s: dxc compiles HLSL only", src.path)
+		}
+		if _, err := exec.LookPath(want); err != nil {
+			return "", nil
+		}
+		return want, nil
+	}
+	for _, c := range compilerNames(src.hlsl) {
+		if _, err := exec.LookPath(c); err == nil {
+			return c, nil
+		}
+	}
+	return "", nil
+}
+
+// dxcProfiles maps stages to dxc target profile prefixes.
+var dxcProfiles = map[string]string{
+	"vert": "vs_6_0", "frag": "ps_6_0", "comp": "cs_6_0", "geom": "gs_6_0",
+	"tesc": "hs_6_0", "tese": "ds_6_0", "mesh": "ms_6_5", "task": "as_6_5",
+}
+
+// compilerArgs returns the command line that compiles src to out.
+func compilerArgs(compiler string, src source, opts options, out string) ([]string, error) {
+	var args []string
+	switch compiler {
+	case "glslc":
+		args = append(args, "--target-env="+opts.targetEnv, "-fshader-stage="+src.stage)
+		if src.hlsl {
+			args = append(args, "-x", "hlsl", "-fentry-point="+opts.entry)
+		}
+		if opts.optimize {
+			args = append(args, "-O")
+		}
+		for _, d := range opts.includes {
+			args = append(args, "-I", d)
+		}
+		for _, d := range opts.defines {
+			args = append(args, "-D"+d)
+		}
+	case "glslangValidator":
+		args = append(args, "-V", "--target-env", opts.targetEnv, "-S", src.stage)
+		if src.hlsl {
+			args = append(args, "-D", "-e", opts.entry)
+		}
+		for _, d := range opts.includes {
+			args = append(args, "-I"+d)
+		}
+		for _, d := range opts.defines {
+			args = append(args, "-D"+d)
+		}
+	case "dxc":
+		profile, ok := dxcProfiles[src.stage]
+		if !ok {
+			profile = "lib_6_3"
+		}
+		args = append(args, "-spirv", "-fspv-target-env="+opts.targetEnv, "-T", profile, "-E", opts.entry)
+		if !opts.optimize {
+			args = append(args, "-O0")
+		}
+		for _, d := range opts.includes {
+			args = append(args, "-I", d)
+		}
+		for _, d := range opts.defines {
+			args = append(args, "-D", d)
+		}
+		return append(args, "-Fo", out, src.path), nil
+	default:
+		return nil, fmt.Errorf("unknown compiler %q (want glslc, glslangValidator, or dxc)", compiler)
+	}
+	return append(args, "-o", out, src.path), nil
+}
+
+// compile runs compiler on src and returns the SPIR-V.
+func compile(compiler string, src source, opts options) ([]byte, error) {
+	tmp, err := os.CreateTemp("", "vkshaderc-*.spv")
+	if err != nil {
+		return nil, err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	args, err := compilerArgs(compiler, src, opts, tmp.Name())
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(compiler, args...)
+	var stderr bytes.Buffer
+	cmd.Stdout = &stderr
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s %s: %v\n%s", compiler, src.path, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return os.ReadFile(tmp.Name())
+}
+
+// cache stores compiled SPIR-V by content hash and compiler.
+type cache struct {
+	dir string // "" disables the cache
+}
+
+func (c cache) path(hash, compiler string) string {
+	return filepath.Join(c.dir, hash+"-"+compiler+".spv")
+}
+
+func (c cache) get(hash, compiler string) ([]byte, bool) {
+	if c.dir == "" {
+		return nil, false
+	}
+	code, err := os.ReadFile(c.path(hash, compiler))
+	return code, err == nil
+}
+
+// put stores code, writing through a temporary file so concurrent runs never
+// see a partial entry. Failures only cost a recompile later, so they are
+// ignored.
+func (c cache) put(hash, compiler string, code []byte) {
+	if c.dir == "" || os.MkdirAll(c.dir, 0o755) != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(code)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(hash, compiler))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// fileHash returns the hex SHA-256 of b.
+func fileHash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
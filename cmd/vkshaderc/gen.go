@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/christerso/vulkan-go/spirv"
+	"github.com/christerso/vulkan-go/vk"
+)
+
+// hashDirective prefixes the lines of a generated file that record, per
+// source, the content hash the .spv was compiled from and the .spv's own hash.
+const hashDirective = "//vkshaderc:hash "
+
+// record is one hash line of a generated file.
+type record struct {
+	source, spv string
+}
+
+// readGenerated returns the hash lines of a previously generated file, keyed
+// by source path, and its package name. A missing file has no records and
+// package main.
+func readGenerated(path string) (map[string]record, string, error) {
+	recs := map[string]record{}
+	text, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return recs, "main", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), path, text, parser.PackageClauseOnly)
+	if err != nil {
+		return nil, "", err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(text))
+	for sc.Scan() {
+		line, ok := strings.CutPrefix(sc.Text(), hashDirective)
+		if !ok {
+			continue
+		}
+		if fields := strings.Fields(line); len(fields) == 3 {
+			recs[fields[0]] = record{source: fields[1], spv: fields[2]}
+		}
+	}
+	return recs, f.Name.Name, sc.Err()
+}
+
+// shader is one compiled source, ready to generate code for.
+type shader struct {
+	src    source
+	embed  string // .spv path relative to the generated file
+	ident  string // Go identifier of the spirv.Shader
+	hash   string // content hash of the source and its includes
+	code   []byte
+	reflec *spirv.Reflection
+}
+
+// identifier turns a file name like sky.frag.hlsl into skyFrag, or SkyFrag
+// when exported.
+func identifier(path string, exported bool) string {
+	base := filepath.Base(path)
+	base = strings.TrimSuffix(strings.TrimSuffix(base, ".hlsl"), ".glsl")
+	words := strings.FieldsFunc(base, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for i, w := range words {
+		r := []rune(w)
+		if i > 0 || exported {
+			r[0] = unicode.ToUpper(r[0])
+		} else {
+			r[0] = unicode.ToLower(r[0])
+		}
+		b.WriteString(string(r))
+	}
+	id := b.String()
+	if id == "" || unicode.IsDigit([]rune(id)[0]) {
+		id = "shader" + id
+		if exported {
+			id = "S" + id[1:]
+		}
+	}
+	return id
+}
+
+// generate returns the formatted Go file embedding shaders.
+func generate(pkg string, shaders []shader) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by vkshaderc; DO NOT EDIT.\n\n")
+	for _, s := range shaders {
+		fmt.Fprintf(&b, "%s%s %s %s\n", hashDirective, filepath.ToSlash(s.src.path), s.hash, fileHash(s.code))
+	}
+	fmt.Fprintf(&b, "\npackage %s\n\n", pkg)
+	fmt.Fprintf(&b, "import (\n\t_ \"embed\"\n\n\t\"github.com/christerso/vulkan-go/spirv\"\n")
+	if needsVK(shaders) {
+		fmt.Fprintf(&b, "\t\"github.com/christerso/vulkan-go/vk\"\n")
+	}
+	fmt.Fprintf(&b, ")\n")
+
+	for _, s := range shaders {
+		r := s.reflec
+		fmt.Fprintf(&b, "\n//go:embed %s\nvar %sSPV []byte\n\n", s.embed, s.ident)
+		fmt.Fprintf(&b, "// %s is %s compiled to SPIR-V, with its reflected interface.\n", s.ident, filepath.ToSlash(s.src.path))
+		fmt.Fprintf(&b, "var %s = spirv.Shader{\n\tCode: %sSPV,\n", s.ident, s.ident)
+		if len(r.EntryPoints) > 0 {
+			e := r.EntryPoints[0]
+			fmt.Fprintf(&b, "\tEntry: %q,\n\tStage: %s,\n", e.Name, stageName(e.Stage))
+			if e.WorkgroupSize != [3]uint32{} {
+				fmt.Fprintf(&b, "\tWorkgroupSize: [3]uint32{%d, %d, %d},\n", e.WorkgroupSize[0], e.WorkgroupSize[1], e.WorkgroupSize[2])
+			}
+		}
+		layout, err := spirv.MergeLayouts(r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", s.src.path, err)
+		}
+		if len(layout.Sets) > 0 || layout.PushSize > 0 {
+			fmt.Fprintf(&b, "\tLayout: spirv.Layout{\n")
+			if len(layout.Sets) > 0 {
+				fmt.Fprintf(&b, "\t\tSets: [][]vk.DescriptorBinding{\n")
+				for set, bindings := range layout.Sets {
+					fmt.Fprintf(&b, "\t\t\t{ // set %d\n", set)
+					for _, d := range bindings {
+						fmt.Fprintf(&b, "\t\t\t\t{Binding: %d, Type: %s, Count: %d, Stages: %s},\n",
+							d.Binding, descriptorName(d.Type), d.Count, stageName(d.Stages))
+					}
+					fmt.Fprintf(&b, "\t\t\t},\n")
+				}
+				fmt.Fprintf(&b, "\t\t},\n")
+			}
+			if layout.PushSize > 0 {
+				fmt.Fprintf(&b, "\t\tPushStages: %s,\n\t\tPushSize: %d,\n", stageName(layout.PushStages), layout.PushSize)
+			}
+			fmt.Fprintf(&b, "\t},\n")
+		}
+		if len(r.VertexInputs) > 0 {
+			binding, attrs := r.VertexInput(0, vk.VertexInputRateVertex)
+			fmt.Fprintf(&b, "\tVertexBinding: vk.VertexInputBinding{Binding: 0, Stride: %d, InputRate: vk.VertexInputRateVertex},\n", binding.Stride)
+			fmt.Fprintf(&b, "\tVertexAttributes: []vk.VertexInputAttribute{\n")
+			for _, a := range attrs {
+				fmt.Fprintf(&b, "\t\t{Location: %d, Binding: 0, Format: %s, Offset: %d},\n", a.Location, formatName(a.Format), a.Offset)
+			}
+			fmt.Fprintf(&b, "\t},\n")
+		}
+		fmt.Fprintf(&b, "}\n")
+	}
+	return format.Source(b.Bytes())
+}
+
+// needsVK reports whether the generated code refers to package vk.
+func needsVK(shaders []shader) bool {
+	for _, s := range shaders {
+		if len(s.reflec.EntryPoints) > 0 && stageNames[s.reflec.EntryPoints[0].Stage] != "" {
+			return true
+		}
+		if len(s.reflec.Bindings) > 0 || s.reflec.PushConstants != nil || len(s.reflec.VertexInputs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+var stageNames = map[uint32]string{
+	vk.ShaderStageVertex:         "vk.ShaderStageVertex",
+	vk.ShaderStageTessControl:    "vk.ShaderStageTessControl",
+	vk.ShaderStageTessEvaluation: "vk.ShaderStageTessEvaluation",
+	vk.ShaderStageGeometry:       "vk.ShaderStageGeometry",
+	vk.ShaderStageFragment:       "vk.ShaderStageFragment",
+	vk.ShaderStageCompute:        "vk.ShaderStageCompute",
+}
+
+// stageName spells a stage mask with vk constants where it can.
+func stageName(mask uint32) string {
+	if mask == 0 {
+		return "0"
+	}
+	var parts []string
+	for bit := uint32(1); bit != 0; bit <<= 1 {
+		if mask&bit == 0 {
+			continue
+		}
+		if n, ok := stageNames[bit]; ok {
+			parts = append(parts, n)
+			mask &^= bit
+		}
+	}
+	if mask != 0 {
+		parts = append(parts, fmt.Sprintf("%#x", mask))
+	}
+	return strings.Join(parts, " | ")
+}
+
+var descriptorNames = map[vk.DescriptorType]string{
+	vk.DescriptorSampler:               "vk.DescriptorSampler",
+	vk.DescriptorCombinedImageSampler:  "vk.DescriptorCombinedImageSampler",
+	vk.DescriptorSampledImage:          "vk.DescriptorSampledImage",
+	vk.DescriptorStorageImage:          "vk.DescriptorStorageImage",
+	vk.DescriptorUniformTexelBuffer:    "vk.DescriptorUniformTexelBuffer",
+	vk.DescriptorStorageTexelBuffer:    "vk.DescriptorStorageTexelBuffer",
+	vk.DescriptorUniformBuffer:         "vk.DescriptorUniformBuffer",
+	vk.DescriptorStorageBuffer:         "vk.DescriptorStorageBuffer",
+	vk.DescriptorInputAttachment:       "vk.DescriptorInputAttachment",
+	vk.DescriptorAccelerationStructure: "vk.DescriptorAccelerationStructure",
+}
+
+func descriptorName(t vk.DescriptorType) string {
+	if n, ok := descriptorNames[t]; ok {
+		return n
+	}
+	return fmt.Sprintf("vk.DescriptorType(%d)", t)
+}
+
+// formatNames covers the formats spirv reflects vertex inputs as.
+var formatNames = map[vk.Format]string{
+	vk.FormatR32Sfloat:          "vk.FormatR32Sfloat",
+	vk.FormatR32G32Sfloat:       "vk.FormatR32G32Sfloat",
+	vk.FormatR32G32B32Sfloat:    "vk.FormatR32G32B32Sfloat",
+	vk.FormatR32G32B32A32Sfloat: "vk.FormatR32G32B32A32Sfloat",
+	vk.FormatR16Sfloat:          "vk.FormatR16Sfloat",
+	vk.FormatR16G16Sfloat:       "vk.FormatR16G16Sfloat",
+	vk.FormatR16G16B16Sfloat:    "vk.FormatR16G16B16Sfloat",
+	vk.FormatR16G16B16A16Sfloat: "vk.FormatR16G16B16A16Sfloat",
+	vk.FormatR64Sfloat:          "vk.FormatR64Sfloat",
+	vk.FormatR64G64Sfloat:       "vk.FormatR64G64Sfloat",
+	vk.FormatR64G64B64Sfloat:    "vk.FormatR64G64B64Sfloat",
+	vk.FormatR64G64B64A64Sfloat: "vk.FormatR64G64B64A64Sfloat",
+	vk.FormatR32Sint:            "vk.FormatR32Sint",
+	vk.FormatR32G32Sint:         "vk.FormatR32G32Sint",
+	vk.FormatR32G32B32Sint:      "vk.FormatR32G32B32Sint",
+	vk.FormatR32G32B32A32Sint:   "vk.FormatR32G32B32A32Sint",
+	vk.FormatR32Uint:            "vk.FormatR32Uint",
+	vk.FormatR32G32Uint:         "vk.FormatR32G32Uint",
+	vk.FormatR32G32B32Uint:      "vk.FormatR32G32B32Uint",
+	vk.FormatR32G32B32A32Uint:   "vk.FormatR32G32B32A32Uint",
+}
+
+func formatName(f vk.Format) string {
+	if n, ok := formatNames[f]; ok {
+		return n
+	}
+	return fmt.Sprintf("vk.Format(%d)", f)
+}
@@ -0,0 +1,179 @@
+// Command vkshaderc compiles GLSL and HLSL shaders to SPIR-V for go:generate.
+// It writes each name.stage source's SPIR-V to name.stage.spv next to it and
+// emits a Go file that embeds the .spv files as spirv.Shader values carrying
+// the reflected descriptor layout, push constants, vertex inputs, and
+// workgroup size:
+//
+//	//go:generate go run github.com/christerso/vulkan-go/cmd/vkshaderc -o shaders_gen.go shaders/sky.vert shaders/sky.frag
+//
+// It runs glslc, glslangValidator, or (for .hlsl) dxc, whichever is installed
+// first, or the one -compiler names. Output is cached by a hash of the
+// source, every file it #includes, and the compile options, and the generated
+// file records that hash for each .spv. A .spv whose sources have not changed
+// is therefore reused without any compiler installed, and one whose sources
+// have changed is an error when no compiler is available.
+//
+// With -check nothing is written: vkshaderc fails if any committed .spv or
+// the generated file is stale, for use in CI.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/christerso/vulkan-go/spirv"
+)
+
+// listFlag collects a repeatable flag.
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, ",") }
+func (l *listFlag) Set(s string) error { *l = append(*l, s); return nil }
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "vkshaderc:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var opts options
+	out := flag.String("o", "shaders_gen.go", "generated Go file")
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "package of the generated file (default $GOPACKAGE, else that of -o, else main)")
+	compiler := flag.String("compiler", "", "glslc, glslangValidator, or dxc (default: first installed)")
+	check := flag.Bool("check", false, "write nothing; fail if a .spv or the generated file is stale")
+	export := flag.Bool("export", false, "export the generated identifiers")
+	cacheDir := flag.String("cache", defaultCacheDir(), "compile cache directory; empty disables it")
+	flag.StringVar(&opts.entry, "entry", "main", "entry point name")
+	flag.StringVar(&opts.targetEnv, "target-env", "vulkan1.3", "target environment")
+	flag.BoolVar(&opts.optimize, "O", false, "optimize for performance")
+	flag.Var((*listFlag)(&opts.includes), "I", "add an include directory (repeatable)")
+	flag.Var((*listFlag)(&opts.defines), "D", "define a macro, NAME or NAME=VALUE (repeatable)")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: vkshaderc [flags] shader.vert shader.frag ...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	recs, oldPkg, err := readGenerated(*out)
+	if err != nil {
+		return err
+	}
+	if *pkg == "" {
+		*pkg = oldPkg
+	}
+	c := cache{dir: *cacheDir}
+	outDir := filepath.Dir(*out)
+	var shaders []shader
+	var stale []string
+	idents := map[string]string{}
+	for _, path := range flag.Args() {
+		src, err := parseSource(path)
+		if err != nil {
+			return err
+		}
+		s := shader{src: src, ident: identifier(path, *export)}
+		if prev, dup := idents[s.ident]; dup {
+			return fmt.Errorf("%s and %s both generate %s", prev, path, s.ident)
+		}
+		idents[s.ident] = path
+		rel, err := filepath.Rel(outDir, src.spv)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return fmt.Errorf("%s: must be in the directory of %s or below it, for go:embed", src.spv, *out)
+		}
+		s.embed = filepath.ToSlash(rel)
+		if s.hash, err = contentHash(src, opts); err != nil {
+			return err
+		}
+
+		committed, err := os.ReadFile(src.spv)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		rec, recorded := recs[filepath.ToSlash(path)]
+		current := committed != nil && recorded && rec.source == s.hash && rec.spv == fileHash(committed)
+		switch {
+		case current:
+			s.code = committed
+		case *check:
+			stale = append(stale, src.spv)
+			continue
+		default:
+			if s.code, err = build(*compiler, src, opts, s.hash, c); err != nil {
+				return err
+			}
+		}
+		if s.reflec, err = spirv.Reflect(s.code); err != nil {
+			return fmt.Errorf("%s: %v", src.spv, err)
+		}
+		if !*check && !bytes.Equal(s.code, committed) {
+			if err := os.WriteFile(src.spv, s.code, 0o644); err != nil {
+				return err
+			}
+		}
+		shaders = append(shaders, s)
+	}
+	if len(stale) > 0 {
+		return fmt.Errorf("stale SPIR-V, run go generate: %s", strings.Join(stale, ", "))
+	}
+
+	gen, err := generate(*pkg, shaders)
+	if err != nil {
+		return err
+	}
+	if *check {
+		old, err := os.ReadFile(*out)
+		if err != nil || !bytes.Equal(old, gen) {
+			return fmt.Errorf("%s is stale, run go generate", *out)
+		}
+		return nil
+	}
+	return os.WriteFile(*out, gen, 0o644)
+}
+
+// build returns the SPIR-V for src from the cache, or compiles and caches it.
+func build(want string, src source, opts options, hash string, c cache) ([]byte, error) {
+	compiler, err := findCompiler(want, src)
+	if err != nil {
+		return nil, err
+	}
+	if compiler == "" {
+		names := strings.Join(compilerNames(src.hlsl), ", ")
+		if want != "" {
+			names = want
+		}
+		// Any cached build of these exact sources will do.
+		for _, name := range compilerNames(src.hlsl) {
+			if code, ok := c.get(hash, name); ok {
+				return code, nil
+			}
+		}
+		return nil, fmt.Errorf("%s: %s is missing or out of date and no shader compiler is installed (%s)", src.path, src.spv, names)
+	}
+	if code, ok := c.get(hash, compiler); ok {
+		return code, nil
+	}
+	code, err := compile(compiler, src, opts)
+	if err != nil {
+		return nil, err
+	}
+	c.put(hash, compiler, code)
+	return code, nil
+}
+
+// defaultCacheDir returns the per-user cache directory for compiled shaders.
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "vkshaderc")
+}
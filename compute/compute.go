@@ -0,0 +1,88 @@
+// Package compute runs SPIR-V compute shaders over Go slices. A Kernel wraps
+// one shader; Run uploads its input slices into storage buffers, dispatches the
+// shader, and reads its output buffers back into Go slices:
+//
+//	dev, _ := compute.NewDevice(device, pd, queue, family)
+//	k, _ := compute.NewKernel(dev, spirv)
+//	err := k.Run(ctx, compute.Linear(n, 64), compute.In(a), compute.In(b), compute.Out(sum))
+//
+// Arguments bind, in order, to storage buffers 0..n-1 of descriptor set 0. The
+// package builds only on the vk package: buffers come from CreateBuffer and
+// CreateDeviceLocalBuffer and descriptors from the vk descriptor helpers.
+package compute
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+
+	"github.com/christerso/vulkan-go/vk"
+)
+
+// Device is the device, physical device, and compute-capable queue that
+// kernels run on. Queue submissions from concurrent Runs are serialized
+// through it, since a VkQueue must be externally synchronized.
+type Device struct {
+	Device   vk.Device
+	Physical vk.PhysicalDevice
+	Queue    vk.Queue
+	Family   uint32
+
+	mu sync.Mutex // guards Queue
+}
+
+// NewDevice wraps an existing logical device. queue must belong to family and
+// support compute.
+func NewDevice(device vk.Device, pd vk.PhysicalDevice, queue vk.Queue, family uint32) (*Device, error) {
+	fams := pd.QueueFamilies()
+	if int(family) >= len(fams) || fams[family].QueueFlags&vk.QueueComputeBit == 0 {
+		return nil, fmt.Errorf("compute: queue family %d does not support compute", family)
+	}
+	return &Device{Device: device, Physical: pd, Queue: queue, Family: family}, nil
+}
+
+// Grid is the number of workgroups to dispatch along each axis. Zero Y or Z
+// counts as 1.
+type Grid struct{ X, Y, Z uint32 }
+
+// Linear returns the one-dimensional grid that covers n invocations with
+// workgroups of local invocations, matching a shader's local_size_x.
+func Linear(n, local uint32) Grid { return Grid{X: vk.GroupCount(n, local), Y: 1, Z: 1} }
+
+func (g Grid) dims() (uint32, uint32, uint32) {
+	y, z := g.Y, g.Z
+	if y == 0 {
+		y = 1
+	}
+	if z == 0 {
+		z = 1
+	}
+	return g.X, y, z
+}
+
+// Arg is one kernel argument: a Go slice viewed as bytes and bound to a storage
+// buffer. Build it with In, Out, or InOut.
+type Arg struct {
+	data []byte
+	in   bool // upload before the dispatch
+	out  bool // read back after the dispatch
+}
+
+// In passes s to the kernel as a read-only input. The element type must be
+// plain data (no pointers) laid out as the shader's std430 buffer expects.
+func In[T any](s []T) Arg { return Arg{data: bytesOf(s), in: true} }
+
+// Out binds a buffer of len(s) elements and copies its contents into s after
+// the kernel has run.
+func Out[T any](s []T) Arg { return Arg{data: bytesOf(s), out: true} }
+
+// InOut uploads s, runs the kernel, and copies the buffer back into s.
+func InOut[T any](s []T) Arg { return Arg{data: bytesOf(s), in: true, out: true} }
+
+// bytesOf views the backing array of s as bytes.
+func bytesOf[T any](s []T) []byte {
+	if len(s) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(&s[0])), len(s)*int(unsafe.Sizeof(s[0])))
+}
@@ -0,0 +1,240 @@
+package compute
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"unsafe"
+
+	"github.com/christerso/vulkan-go/vk"
+)
+
+// Kernel is a compute shader ready to run over Go slices. Pipelines are built
+// lazily, one per argument count, and cached. Run may be called from several
+// goroutines at once.
+type Kernel struct {
+	dev    *Device
+	module vk.ShaderModule
+
+	mu        sync.Mutex
+	pipelines map[int]*kernelPipeline
+}
+
+// kernelPipeline is the layout and pipeline for one argument count.
+type kernelPipeline struct {
+	setLayout vk.DescriptorSetLayout
+	layout    vk.PipelineLayout
+	pipeline  vk.Pipeline
+}
+
+// NewKernel creates a kernel from SPIR-V whose entry point "main" reads and
+// writes storage buffers bound at set 0, bindings 0..n-1.
+func NewKernel(dev *Device, spirv []byte) (*Kernel, error) {
+	if len(spirv) == 0 || len(spirv)%4 != 0 {
+		return nil, fmt.Errorf("compute: SPIR-V length %d is not a positive multiple of 4", len(spirv))
+	}
+	module, err := dev.Device.CreateShaderModule(spirv)
+	if err != nil {
+		return nil, err
+	}
+	return &Kernel{dev: dev, module: module, pipelines: map[int]*kernelPipeline{}}, nil
+}
+
+// Destroy frees the kernel's pipelines and shader module. No Run may be in
+// progress.
+func (k *Kernel) Destroy() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	d := k.dev.Device
+	for _, p := range k.pipelines {
+		d.DestroyPipeline(p.pipeline)
+		d.DestroyPipelineLayout(p.layout)
+		d.DestroyDescriptorSetLayout(p.setLayout)
+	}
+	k.pipelines = nil
+	d.DestroyShaderModule(k.module)
+	k.module = 0
+}
+
+// pipelineFor returns the cached pipeline for n storage-buffer arguments,
+// building it on first use.
+func (k *Kernel) pipelineFor(n int) (*kernelPipeline, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if p := k.pipelines[n]; p != nil {
+		return p, nil
+	}
+	d := k.dev.Device
+	bindings := make([]vk.DescriptorBinding, n)
+	for i := range bindings {
+		bindings[i] = vk.DescriptorBinding{Binding: uint32(i), Type: vk.DescriptorStorageBuffer, Count: 1, Stages: vk.ShaderStageCompute}
+	}
+	setLayout, err := d.CreateDescriptorSetLayout(bindings)
+	if err != nil {
+		return nil, err
+	}
+	layout, err := d.CreatePipelineLayout([]vk.DescriptorSetLayout{setLayout}, 0, 0)
+	if err != nil {
+		d.DestroyDescriptorSetLayout(setLayout)
+		return nil, err
+	}
+	pipeline, err := d.CreateComputePipeline(vk.ComputePipelineConfig{Layout: layout, Shader: k.module})
+	if err != nil {
+		d.DestroyPipelineLayout(layout)
+		d.DestroyDescriptorSetLayout(setLayout)
+		return nil, err
+	}
+	p := &kernelPipeline{setLayout: setLayout, layout: layout, pipeline: pipeline}
+	k.pipelines[n] = p
+	return p, nil
+}
+
+// Run dispatches grid workgroups of the kernel with args bound to storage
+// buffers 0..len(args)-1 and blocks until the results are back in the Out and
+// InOut slices. If ctx is cancelled first, Run returns ctx.Err() and the
+// outputs are left untouched; the GPU resources of the run are released in the
+// background once the submission completes.
+func (k *Kernel) Run(ctx context.Context, grid Grid, args ...Arg) error {
+	if len(args) == 0 {
+		return fmt.Errorf("compute: Run needs at least one argument")
+	}
+	for i, a := range args {
+		if len(a.data) == 0 {
+			return fmt.Errorf("compute: argument %d is empty", i)
+		}
+	}
+	p, err := k.pipelineFor(len(args))
+	if err != nil {
+		return err
+	}
+	d := k.dev
+	dev := d.Device
+
+	// Everything the run creates is released in reverse order, either on
+	// return or, after a cancellation, once the GPU is done with it.
+	var cleanup []func()
+	release := func() {
+		for i := len(cleanup) - 1; i >= 0; i-- {
+			cleanup[i]()
+		}
+	}
+	owned := true
+	defer func() {
+		if owned {
+			release()
+		}
+	}()
+
+	pool, err := dev.CreateCommandPool(d.Family)
+	if err != nil {
+		return err
+	}
+	cleanup = append(cleanup, func() { dev.DestroyCommandPool(pool) })
+
+	bufs := make([]vk.AllocBuffer, len(args))
+	readback := make([]vk.AllocBuffer, len(args))
+	for i, a := range args {
+		size := vk.DeviceSize(len(a.data))
+		usage := vk.BufferUsageStorageBuffer | vk.BufferUsageTransferSrc
+		var b vk.AllocBuffer
+		if a.in {
+			d.mu.Lock()
+			b, err = dev.CreateDeviceLocalBuffer(d.Physical, d.Queue, pool, a.data, usage)
+			d.mu.Unlock()
+		} else {
+			b, err = dev.CreateBuffer(d.Physical, vk.BufferConfig{Size: size, Usage: usage, Properties: vk.MemoryDeviceLocal})
+		}
+		if err != nil {
+			return err
+		}
+		bufs[i] = b
+		cleanup = append(cleanup, func() { dev.DestroyBuffer(b) })
+		if !a.out {
+			continue
+		}
+		rb, err := dev.CreateBuffer(d.Physical, vk.BufferConfig{
+			Size:       size,
+			Usage:      vk.BufferUsageTransferDst,
+			Properties: vk.MemoryHostVisible,
+			Preferred:  vk.MemoryHostCached,
+			Map:        true,
+		})
+		if err != nil {
+			return err
+		}
+		readback[i] = rb
+		cleanup = append(cleanup, func() { dev.DestroyBuffer(rb) })
+	}
+
+	descPool, err := dev.CreateDescriptorPool(1, map[vk.DescriptorType]uint32{vk.DescriptorStorageBuffer: uint32(len(args))})
+	if err != nil {
+		return err
+	}
+	cleanup = append(cleanup, func() { dev.DestroyDescriptorPool(descPool) })
+	set, err := dev.AllocateDescriptorSet(descPool, p.setLayout)
+	if err != nil {
+		return err
+	}
+	var writes vk.DescriptorSetBuilder
+	for i, b := range bufs {
+		writes.Buffer(set, uint32(i), vk.DescriptorStorageBuffer, b.Buffer, 0, vk.WholeSize)
+	}
+	writes.Update(dev)
+
+	cmds, err := dev.AllocateCommandBuffers(pool, 1)
+	if err != nil {
+		return err
+	}
+	cmd := cmds[0]
+	if err := cmd.Begin(vk.CommandBufferOneTimeSubmit); err != nil {
+		return err
+	}
+	cmd.MemoryBarrier(vk.StageTransfer, vk.StageComputeShader, vk.AccessTransferWrite, vk.AccessShaderRead|vk.AccessShaderWrite)
+	cmd.BindPipelineAt(vk.BindPointCompute, p.pipeline)
+	cmd.BindDescriptorSetAt(vk.BindPointCompute, p.layout, 0, set)
+	cmd.Dispatch(grid.dims())
+	cmd.MemoryBarrier(vk.StageComputeShader, vk.StageTransfer, vk.AccessShaderWrite, vk.AccessTransferRead)
+	for i, a := range args {
+		if a.out {
+			cmd.CopyBuffer(bufs[i].Buffer, readback[i].Buffer, vk.DeviceSize(len(a.data)))
+		}
+	}
+	cmd.MemoryBarrier(vk.StageTransfer, vk.StageHost, vk.AccessTransferWrite, vk.AccessHostRead)
+	if err := cmd.End(); err != nil {
+		return err
+	}
+
+	fence, err := dev.CreateFence(false)
+	if err != nil {
+		return err
+	}
+	cleanup = append(cleanup, func() { dev.DestroyFence(fence) })
+	d.mu.Lock()
+	err = d.Queue.Submit(vk.SubmitConfig{Command: cmd, Fence: fence})
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := dev.WaitFenceContext(ctx, fence); err != nil {
+		if ctx.Err() != nil {
+			owned = false
+			go func() {
+				_ = dev.WaitFence(fence, math.MaxUint64)
+				release()
+			}()
+		}
+		return err
+	}
+
+	for i, a := range args {
+		if !a.out {
+			continue
+		}
+		if err := dev.InvalidateBuffer(readback[i], 0, vk.WholeSize); err != nil {
+			return err
+		}
+		copy(a.data, unsafe.Slice((*byte)(readback[i].Mapped), len(a.data)))
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package compute
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/christerso/vulkan-go/spirv/builder"
+	"github.com/christerso/vulkan-go/vk"
+)
+
+// testDevice returns a compute device, preferring a CPU implementation such
+// as lavapipe, or skips the test when there is no Vulkan loader or device.
+func testDevice(t *testing.T) *Device {
+	t.Helper()
+	if err := vk.Load(); err != nil {
+		t.Skip(err)
+	}
+	instance, err := vk.CreateInstance(vk.InstanceConfig{ApplicationName: "compute test"})
+	if err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(instance.Destroy)
+	pds, err := instance.EnumeratePhysicalDevices()
+	if err != nil || len(pds) == 0 {
+		t.Skip("no Vulkan physical device")
+	}
+	pd := pds[0]
+	for _, p := range pds {
+		if p.Info().Type == vk.DeviceTypeCPU {
+			pd = p
+			break
+		}
+	}
+	family, err := pd.ComputeFamily()
+	if err != nil {
+		t.Skip(err)
+	}
+	device, _, err := pd.CreateDevice(vk.DeviceConfig{GraphicsFamily: family})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(device.Destroy)
+	dev, err := NewDevice(device, pd, device.Queue(family, 0), family)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dev
+}
+
+// saxpy builds out[i] = a*x[i] + y[i] for i < n, with n in binding 3.
+func saxpy(t *testing.T, a float32) []byte {
+	t.Helper()
+	b := builder.New()
+	f32 := b.Float32()
+	x := b.StorageBuffer(0, 0, b.RuntimeArray(f32), true)
+	y := b.StorageBuffer(0, 1, b.RuntimeArray(f32), true)
+	out := b.StorageBuffer(0, 2, b.RuntimeArray(f32), false)
+	n := b.StorageBuffer(0, 3, b.RuntimeArray(b.Uint32()), true)
+	fn := b.Compute("main", 64, 1, 1)
+	i := fn.Extract(fn.GlobalInvocationID(), 0)
+	fn.If(fn.Less(i, fn.Load(fn.Index(n, b.ConstUint32(0)))), func() {
+		ax := fn.Mul(b.ConstFloat32(a), fn.Load(fn.Index(x, i)))
+		fn.Store(fn.Index(out, i), fn.Add(ax, fn.Load(fn.Index(y, i))))
+	})
+	code, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestKernelSaxpy(t *testing.T) {
+	const a = 2.5
+	code := saxpy(t, a)
+	dev := testDevice(t)
+	k, err := NewKernel(dev, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Destroy()
+
+	// Sizes that fill and fall short of the 64-wide workgroups.
+	for _, n := range []uint32{1, 64, 1000} {
+		x := make([]float32, n)
+		y := make([]float32, n)
+		for i := range x {
+			x[i] = float32(i) * 0.5
+			y[i] = float32(n) - float32(i)
+		}
+		want := make([]float32, n)
+		for i := range want {
+			want[i] = a*x[i] + y[i]
+		}
+		got := make([]float32, n)
+		if err := k.Run(context.Background(), Linear(n, 64), In(x), In(y), Out(got), In([]uint32{n})); err != nil {
+			t.Fatalf("n=%d: %v", n, err)
+		}
+		for i := range want {
+			// The driver may fuse the multiply and add.
+			if math.Abs(float64(got[i]-want[i])) > 1e-6*math.Abs(float64(want[i])) {
+				t.Fatalf("n=%d: out[%d] = %v, want %v", n, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+// TestKernelInOut runs a kernel that updates its buffer in place, reusing
+// the cached pipeline across runs.
+func TestKernelInOut(t *testing.T) {
+	b := builder.New()
+	u32 := b.Uint32()
+	buf := b.StorageBuffer(0, 0, b.RuntimeArray(u32), false)
+	fn := b.Compute("main", 64, 1, 1)
+	i := fn.Extract(fn.GlobalInvocationID(), 0)
+	p := fn.Index(buf, i)
+	fn.Store(p, fn.Add(fn.Mul(fn.Load(p), fn.Load(p)), i))
+	code, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev := testDevice(t)
+	k, err := NewKernel(dev, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Destroy()
+
+	const n = 256
+	got := make([]uint32, n)
+	want := make([]uint32, n)
+	for i := range got {
+		got[i] = uint32(i)
+		want[i] = uint32(i)
+	}
+	for range 2 {
+		if err := k.Run(context.Background(), Linear(n, 64), InOut(got)); err != nil {
+			t.Fatal(err)
+		}
+		for i := range want {
+			want[i] = want[i]*want[i] + uint32(i)
+		}
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("buf[%d] = %d, want %d", i, got[i], want[i])
+		}
+	}
+}
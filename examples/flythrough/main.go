@@ -10,7 +10,7 @@
 package main
 
 import (
-	_ "embed"
+	"errors"
 	"flag"
 	"fmt"
 	"math"
@@ -23,23 +23,7 @@ import (
 	"github.com/christerso/vulkan-go/vk"
 )
 
-//go:embed shaders/terrain.vert.spv
-var terrainVert []byte
-
-//go:embed shaders/terrain.frag.spv
-var terrainFrag []byte
-
-//go:embed shaders/sky.vert.spv
-var skyVert []byte
-
-//go:embed shaders/sky.frag.spv
-var skyFrag []byte
-
-//go:embed shaders/tree.vert.spv
-var treeVert []byte
-
-//go:embed shaders/tree.frag.spv
-var treeFrag []byte
+//go:generate go run ../../cmd/vkshaderc -o shaders_gen.go shaders/terrain.vert shaders/terrain.frag shaders/sky.vert shaders/sky.frag shaders/tree.vert shaders/tree.frag
 
 const framesInFlight = 2
 
@@ -139,15 +123,12 @@ func run(gridN, treeCount, winW, winH, maxFrames int, gcLoad, validate bool) err
 	}
 	defer device.DestroyRenderPass(renderPass)
 
+	// Uploads record into this pool; frames use the ring's.
 	pool, err := device.CreateCommandPool(gfx)
 	if err != nil {
 		return err
 	}
 	defer device.DestroyCommandPool(pool)
-	cmds, err := device.AllocateCommandBuffers(pool, framesInFlight)
-	if err != nil {
-		return err
-	}
 
 	// Geometry.
 	terrain := GenerateTerrain(gridN, 400, 70)
@@ -211,9 +192,9 @@ func run(gridN, treeCount, winW, winH, maxFrames int, gcLoad, validate bool) err
 		shaders = append(shaders, m)
 		return m
 	}
-	terrainVS, terrainFS := mk(terrainVert), mk(terrainFrag)
-	skyVS, skyFS := mk(skyVert), mk(skyFrag)
-	treeVS, treeFS := mk(treeVert), mk(treeFrag)
+	terrainVS, terrainFS := mk(terrainVert.Code), mk(terrainFrag.Code)
+	skyVS, skyFS := mk(skyVert.Code), mk(skyFrag.Code)
+	treeVS, treeFS := mk(treeVert.Code), mk(treeFrag.Code)
 	if err != nil {
 		return err
 	}
@@ -276,6 +257,7 @@ func run(gridN, treeCount, winW, winH, maxFrames int, gcLoad, validate bool) err
 	ubufs := make([]vk.AllocBuffer, framesInFlight)
 	dsets := make([]vk.DescriptorSet, framesInFlight)
 	uboSize := vk.DeviceSize(unsafe.Sizeof(uniform{}))
+	var writes vk.DescriptorSetBuilder
 	for i := 0; i < framesInFlight; i++ {
 		ubufs[i], err = device.CreateBuffer(pd, vk.BufferConfig{Size: uboSize, Usage: vk.BufferUsageUniformBuffer, Properties: vk.MemoryHostVisible | vk.MemoryHostCoherent, Map: true})
 		if err != nil {
@@ -286,27 +268,32 @@ func run(gridN, treeCount, winW, winH, maxFrames int, gcLoad, validate bool) err
 		if err != nil {
 			return err
 		}
-		device.UpdateBufferDescriptor(dsets[i], 0, vk.DescriptorUniformBuffer, ubufs[i].Buffer, 0, uboSize)
-	}
-
-	imageAvailable := make([]vk.Semaphore, framesInFlight)
-	inFlight := make([]vk.Fence, framesInFlight)
-	for i := 0; i < framesInFlight; i++ {
-		if imageAvailable[i], err = device.CreateSemaphore(); err != nil {
-			return err
-		}
-		defer device.DestroySemaphore(imageAvailable[i])
-		if inFlight[i], err = device.CreateFence(true); err != nil {
-			return err
-		}
-		defer device.DestroyFence(inFlight[i])
+		writes.Buffer(dsets[i], 0, vk.DescriptorUniformBuffer, ubufs[i].Buffer, 0, uboSize)
 	}
+	writes.Update(device)
 
 	sc, err := newSwapchain(device, pd, surf, renderPass, format, colorSpace, present, depthFormat, window)
 	if err != nil {
 		return err
 	}
-	defer sc.destroy(device)
+	defer func() {
+		if sc != nil {
+			sc.destroy(device)
+		}
+	}()
+	ring, err := vk.NewFrameRing(device, vk.FrameRingConfig{Queue: queue, QueueFamily: gfx, Swapchain: sc.handle, Frames: framesInFlight})
+	if err != nil {
+		return err
+	}
+	defer ring.Destroy()
+	recreate := func() error {
+		device.WaitIdle()
+		sc.destroy(device)
+		if sc, err = newSwapchain(device, pd, surf, renderPass, format, colorSpace, present, depthFormat, window); err != nil {
+			return err
+		}
+		return ring.SetSwapchain(sc.handle)
+	}
 
 	if gcLoad {
 		startGCLoad()
@@ -316,35 +303,27 @@ func run(gridN, treeCount, winW, winH, maxFrames int, gcLoad, validate bool) err
 	startMetrics := readGCStats()
 	start := time.Now()
 	last := start
-	frame := 0
 	count := 0
 
 	for window.Poll() {
-		fi := frame % framesInFlight
-		_ = device.WaitFence(inFlight[fi], math.MaxUint64)
-
-		imgIndex, res := device.AcquireNextImage(sc.handle, imageAvailable[fi], math.MaxUint64)
-		if res == vk.ErrorOutOfDateKHR {
-			device.WaitIdle()
-			sc.destroy(device)
-			if sc, err = newSwapchain(device, pd, surf, renderPass, format, colorSpace, present, depthFormat, window); err != nil {
+		f, err := ring.BeginFrame()
+		if errors.Is(err, vk.ErrOutOfDate) {
+			if err := recreate(); err != nil {
 				return err
 			}
 			continue
-		} else if !res.Ok() && res != vk.SuboptimalKHR {
-			return fmt.Errorf("acquire: %s", res)
+		} else if err != nil {
+			return err
 		}
-		_ = device.ResetFence(inFlight[fi])
+		fi := f.Slot
 
 		t := float32(time.Since(start).Seconds())
 		u := buildUniform(t, terrain.WorldSize, terrain.HeightScale, float32(sc.extent.Width)/float32(sc.extent.Height))
 		vk.CopyToMapped(ubufs[fi].Mapped, unsafe.Slice((*byte)(unsafe.Pointer(&u)), int(uboSize)))
 
-		cmd := cmds[fi]
-		_ = cmd.Reset()
-		_ = cmd.Begin(0)
+		cmd := f.Cmd
 		area := vk.Rect2D{Extent: sc.extent}
-		cmd.BeginRenderPass(renderPass, sc.framebuffers[imgIndex], area, []vk.ClearValue{
+		cmd.BeginRenderPass(renderPass, sc.framebuffers[f.Image], area, []vk.ClearValue{
 			vk.ClearColor(0.52, 0.70, 0.92, 1.0), vk.ClearDepthStencil(1.0, 0),
 		})
 		cmd.SetViewport(vk.Viewport{Width: float32(sc.extent.Width), Height: float32(sc.extent.Height), MaxDepth: 1})
@@ -366,24 +345,18 @@ func run(gridN, treeCount, winW, winH, maxFrames int, gcLoad, validate bool) err
 		cmd.DrawIndexed(treeIndexCount, treeInstCount, 0, 0, 0)
 
 		cmd.EndRenderPass()
-		_ = cmd.End()
 
-		if err := queue.Submit(vk.SubmitConfig{Wait: imageAvailable[fi], WaitStage: vk.StageColorAttachmentOutput, Command: cmd, Signal: sc.renderFinished[imgIndex], Fence: inFlight[fi]}); err != nil {
-			return err
-		}
-		pres := queue.Present(sc.handle, imgIndex, sc.renderFinished[imgIndex])
-		if pres == vk.ErrorOutOfDateKHR || pres == vk.SuboptimalKHR {
-			device.WaitIdle()
-			sc.destroy(device)
-			if sc, err = newSwapchain(device, pd, surf, renderPass, format, colorSpace, present, depthFormat, window); err != nil {
+		if err := ring.EndFrame(f); errors.Is(err, vk.ErrOutOfDate) {
+			if err := recreate(); err != nil {
 				return err
 			}
+		} else if err != nil {
+			return err
 		}
 
 		now := time.Now()
 		frameTimes = append(frameTimes, now.Sub(last).Seconds()*1000)
 		last = now
-		frame++
 		count++
 		if maxFrames > 0 && count >= maxFrames {
 			break
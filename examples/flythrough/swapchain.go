@@ -7,14 +7,13 @@ import (
 
 // swapchain bundles the swapchain and its per-image resources.
 type swapchain struct {
-	handle         vk.SwapchainKHR
-	extent         vk.Extent2D
-	images         []vk.Image
-	views          []vk.ImageView
-	depth          vk.AllocImage
-	depthView      vk.ImageView
-	framebuffers   []vk.Framebuffer
-	renderFinished []vk.Semaphore
+	handle       vk.SwapchainKHR
+	extent       vk.Extent2D
+	images       []vk.Image
+	views        []vk.ImageView
+	depth        vk.AllocImage
+	depthView    vk.ImageView
+	framebuffers []vk.Framebuffer
 }
 
 func newSwapchain(device vk.Device, pd vk.PhysicalDevice, surf vk.SurfaceKHR, rp vk.RenderPass,
@@ -81,20 +80,11 @@ func newSwapchain(device vk.Device, pd vk.PhysicalDevice, surf vk.SurfaceKHR, rp
 			return nil, err
 		}
 		sc.framebuffers = append(sc.framebuffers, fb)
-		sem, err := device.CreateSemaphore()
-		if err != nil {
-			sc.destroy(device)
-			return nil, err
-		}
-		sc.renderFinished = append(sc.renderFinished, sem)
 	}
 	return sc, nil
 }
 
 func (sc *swapchain) destroy(device vk.Device) {
-	for _, sem := range sc.renderFinished {
-		device.DestroySemaphore(sem)
-	}
 	for _, fb := range sc.framebuffers {
 		device.DestroyFramebuffer(fb)
 	}
@@ -0,0 +1,263 @@
+package rendergraph
+
+import (
+	"fmt"
+
+	"github.com/christerso/vulkan-go/vk"
+)
+
+// Barrier is one barrier a Plan records, on the whole of a buffer or on
+// Range of an image.
+type Barrier struct {
+	Resource             Resource
+	SrcStage, SrcAccess  uint64
+	DstStage, DstAccess  uint64
+	OldLayout, NewLayout vk.ImageLayout // images only
+	Range                vk.ImageRange  // images only
+}
+
+// PlannedPass is a pass of a Plan with the barriers recorded before it.
+type PlannedPass struct {
+	Name     string
+	Barriers []Barrier
+	// Reads and Writes list the resources the pass declared.
+	Reads, Writes []Resource
+
+	pass *Pass
+}
+
+// ResourceInfo describes a resource of a Plan.
+type ResourceInfo struct {
+	Name               string
+	Buffer             bool
+	Imported, Exported bool
+	// First and Last are the indices in Plan.Passes of the first and last
+	// pass using the resource, both -1 when no pass left does.
+	First, Last int
+	// Slot is the memory slot of a transient image: transient images with
+	// the same slot share memory. It is -1 for other resources.
+	Slot int
+}
+
+// Plan is a compiled Graph: the passes to run in order, with their barriers,
+// and the resources and how their memory is shared.
+type Plan struct {
+	Passes    []PlannedPass
+	Culled    []string // passes removed because nothing uses their results
+	Resources []ResourceInfo
+	Slots     int // memory slots shared by transient images
+	// Final holds the barriers after the last pass that leave exported
+	// resources in their final state.
+	Final []Barrier
+
+	g *Graph
+}
+
+// Compile checks the graph, culls passes, assigns transient memory, and
+// derives barriers. It does not touch the device.
+func (g *Graph) Compile() (*Plan, error) {
+	for _, p := range g.passes {
+		for _, u := range p.uses {
+			if int(u.res) < 0 || int(u.res) >= len(g.res) {
+				return nil, fmt.Errorf("rendergraph: pass %q uses unknown resource %d", p.name, u.res)
+			}
+			r := g.res[u.res]
+			if !r.buffer && u.state.Layout == layoutConflict {
+				return nil, fmt.Errorf("rendergraph: pass %q uses image %q in two layouts", p.name, r.name)
+			}
+		}
+	}
+	for _, r := range g.res {
+		if r.exported && !r.imported {
+			return nil, fmt.Errorf("rendergraph: transient resource %q exported", r.name)
+		}
+	}
+	live := g.cull()
+	plan := &Plan{g: g, Resources: make([]ResourceInfo, len(g.res))}
+	for i, r := range g.res {
+		plan.Resources[i] = ResourceInfo{Name: r.name, Buffer: r.buffer, Imported: r.imported, Exported: r.exported, First: -1, Last: -1, Slot: -1}
+	}
+	for _, p := range g.passes {
+		if !live[p.index] {
+			plan.Culled = append(plan.Culled, p.name)
+			continue
+		}
+		pp := PlannedPass{Name: p.name, pass: p}
+		for _, u := range p.uses {
+			if u.read {
+				pp.Reads = append(pp.Reads, u.res)
+			}
+			if u.write {
+				pp.Writes = append(pp.Writes, u.res)
+			}
+			info := &plan.Resources[u.res]
+			if info.First < 0 {
+				if !g.res[u.res].imported && !u.write {
+					return nil, fmt.Errorf("rendergraph: pass %q reads transient %q before any pass writes it", p.name, info.Name)
+				}
+				info.First = len(plan.Passes)
+			}
+			info.Last = len(plan.Passes)
+		}
+		plan.Passes = append(plan.Passes, pp)
+	}
+	plan.alias()
+	plan.barriers()
+	return plan, nil
+}
+
+// cull returns, per pass index, whether the pass is kept: it has a side
+// effect, makes the final contents of an imported resource, or makes
+// something a kept pass reads.
+func (g *Graph) cull() []bool {
+	// producers[i] lists the passes whose writes pass i reads.
+	producers := make([][]int, len(g.passes))
+	lastWriter := make([]int, len(g.res))
+	for i := range lastWriter {
+		lastWriter[i] = -1
+	}
+	for _, p := range g.passes {
+		for _, u := range p.uses {
+			if u.read && lastWriter[u.res] >= 0 {
+				producers[p.index] = append(producers[p.index], lastWriter[u.res])
+			}
+		}
+		for _, u := range p.uses {
+			if u.write {
+				lastWriter[u.res] = p.index
+			}
+		}
+	}
+	live := make([]bool, len(g.passes))
+	var stack []int
+	mark := func(i int) {
+		if !live[i] {
+			live[i] = true
+			stack = append(stack, i)
+		}
+	}
+	for _, p := range g.passes {
+		if p.sideEffect {
+			mark(p.index)
+		}
+	}
+	for r, w := range lastWriter {
+		if w >= 0 && g.res[r].imported {
+			mark(w)
+		}
+	}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, j := range producers[i] {
+			mark(j)
+		}
+	}
+	return live
+}
+
+// alias assigns memory slots to the transient images in use, greedily in
+// declaration order: an image takes the first slot whose last image is done
+// before it starts. An image declared before one it could follow in time
+// may therefore miss a slot that a first-use order would have found.
+func (p *Plan) alias() {
+	var slotEnd []int // last pass of the latest image in each slot
+	for i, r := range p.g.res {
+		info := &p.Resources[i]
+		if r.imported || r.buffer || info.First < 0 {
+			continue
+		}
+		info.Slot = -1
+		for s, end := range slotEnd {
+			if end < info.First {
+				info.Slot = s
+				break
+			}
+		}
+		if info.Slot < 0 {
+			info.Slot = len(slotEnd)
+			slotEnd = append(slotEnd, 0)
+		}
+		slotEnd[info.Slot] = info.Last
+	}
+	p.Slots = len(slotEnd)
+}
+
+// placeholder handles stand for resources in the tracker.
+func imageHandle(r Resource) vk.Image   { return vk.Image(r + 1) }
+func bufferHandle(r Resource) vk.Buffer { return vk.Buffer(r + 1) }
+
+// barriers runs the passes' uses through a vk.StateTracker to find the
+// barriers before each pass and after the last.
+func (p *Plan) barriers() {
+	var t vk.StateTracker
+	// An aliased image first waits for every access of the images that used
+	// its memory before it.
+	slotStages := make([]vk.ResourceState, p.Slots)
+	started := make([]bool, len(p.g.res))
+	for i, r := range p.g.res {
+		switch {
+		case r.buffer && r.imported:
+			t.TrackBuffer(bufferHandle(Resource(i)), r.initial)
+		case r.buffer:
+			t.TrackBuffer(bufferHandle(Resource(i)), vk.StateUndefined)
+		case r.imported:
+			t.TrackImage(imageHandle(Resource(i)), r.image.Levels, 1, r.image.Aspect, r.initial)
+		}
+	}
+	for pi := range p.Passes {
+		pp := &p.Passes[pi]
+		for _, u := range pp.pass.uses {
+			r := p.g.res[u.res]
+			if r.buffer {
+				t.UseBuffer(bufferHandle(u.res), u.state)
+				continue
+			}
+			if info := p.Resources[u.res]; info.Slot >= 0 && !started[u.res] {
+				started[u.res] = true
+				prev := slotStages[info.Slot]
+				t.TrackImage(imageHandle(u.res), r.image.Levels, 1, r.image.Aspect, vk.ResourceState{Stage: prev.Stage, Access: prev.Access})
+			}
+			if info := p.Resources[u.res]; info.Slot >= 0 {
+				s := &slotStages[info.Slot]
+				s.Stage |= u.state.Stage
+				s.Access |= u.state.Access
+			}
+			t.UseImage(imageHandle(u.res), vk.ImageRange{}, u.state)
+		}
+		pp.Barriers = collect(t.Barriers())
+	}
+	for i, r := range p.g.res {
+		if !r.exported {
+			continue
+		}
+		if r.buffer {
+			t.UseBuffer(bufferHandle(Resource(i)), r.final)
+		} else {
+			t.UseImage(imageHandle(Resource(i)), vk.ImageRange{}, r.final)
+		}
+	}
+	p.Final = collect(t.Barriers())
+}
+
+// collect converts tracker barriers on placeholder handles to Barriers.
+func collect(images []vk.ImageBarrier2, buffers []vk.BufferBarrier2) []Barrier {
+	var out []Barrier
+	for _, b := range images {
+		out = append(out, Barrier{
+			Resource: Resource(b.Image - 1),
+			SrcStage: b.SrcStage, SrcAccess: b.SrcAccess,
+			DstStage: b.DstStage, DstAccess: b.DstAccess,
+			OldLayout: b.OldLayout, NewLayout: b.NewLayout,
+			Range: b.Range,
+		})
+	}
+	for _, b := range buffers {
+		out = append(out, Barrier{
+			Resource: Resource(b.Buffer - 1),
+			SrcStage: b.SrcStage, SrcAccess: b.SrcAccess,
+			DstStage: b.DstStage, DstAccess: b.DstAccess,
+		})
+	}
+	return out
+}
@@ -0,0 +1,398 @@
+package rendergraph
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/christerso/vulkan-go/vk"
+)
+
+var (
+	colorDesc = ImageDesc{Format: vk.FormatR16G16B16A16Sfloat, Width: 64, Height: 64,
+		Usage: vk.ImageUsageColorAttachment | vk.ImageUsageSampled}
+	whole = vk.ImageRange{Aspect: vk.AspectColor, Levels: 1, Layers: 1}
+)
+
+// importBack imports a swapchain-like image that the frame presents.
+func importBack(g *Graph) Resource {
+	back := g.ImportImage("back", 1, 1, colorDesc, vk.StateUndefined)
+	g.Export(back, vk.StatePresent)
+	return back
+}
+
+func passNames(p *Plan) []string {
+	var names []string
+	for _, pp := range p.Passes {
+		names = append(names, pp.Name)
+	}
+	return names
+}
+
+func TestCull(t *testing.T) {
+	tests := []struct {
+		name         string
+		build        func(g *Graph)
+		kept, culled []string
+	}{
+		{
+			name: "unread transient",
+			build: func(g *Graph) {
+				back := importBack(g)
+				tmp := g.CreateImage("tmp", colorDesc)
+				g.AddPass("unused", nil).Write(tmp, vk.StateColorAttachment)
+				g.AddPass("draw", nil).Write(back, vk.StateColorAttachment)
+			},
+			kept:   []string{"draw"},
+			culled: []string{"unused"},
+		},
+		{
+			name: "chain to an imported resource",
+			build: func(g *Graph) {
+				back := importBack(g)
+				hdr := g.CreateImage("hdr", colorDesc)
+				bloom := g.CreateImage("bloom", colorDesc)
+				g.AddPass("scene", nil).Write(hdr, vk.StateColorAttachment)
+				g.AddPass("bloom", nil).Read(hdr, vk.StateSampledFragment).Write(bloom, vk.StateColorAttachment)
+				g.AddPass("tonemap", nil).Read(hdr, vk.StateSampledFragment).Write(back, vk.StateColorAttachment)
+			},
+			kept:   []string{"scene", "tonemap"},
+			culled: []string{"bloom"},
+		},
+		{
+			name: "side effect",
+			build: func(g *Graph) {
+				buf := g.CreateBuffer("readback", BufferDesc{Size: 256})
+				img := g.CreateImage("img", colorDesc)
+				g.AddPass("fill", nil).Write(buf, vk.StateTransferDst)
+				g.AddPass("copy", nil).Read(buf, vk.StateTransferSrc).SideEffect()
+				g.AddPass("dead", nil).Write(img, vk.StateColorAttachment)
+			},
+			kept:   []string{"fill", "copy"},
+			culled: []string{"dead"},
+		},
+		{
+			// Only the last write reaches the end of the frame.
+			name: "overwritten import",
+			build: func(g *Graph) {
+				back := importBack(g)
+				g.AddPass("first", nil).Write(back, vk.StateColorAttachment)
+				g.AddPass("second", nil).Write(back, vk.StateTransferDst)
+			},
+			kept:   []string{"second"},
+			culled: []string{"first"},
+		},
+		{
+			name: "read of an earlier write",
+			build: func(g *Graph) {
+				back := importBack(g)
+				g.AddPass("clear", nil).Write(back, vk.StateColorAttachment)
+				g.AddPass("blend", nil).Read(back, vk.StateColorAttachment).Write(back, vk.StateColorAttachment)
+			},
+			kept: []string{"clear", "blend"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New()
+			tt.build(g)
+			plan, err := g.Compile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := passNames(plan); !reflect.DeepEqual(got, tt.kept) {
+				t.Errorf("passes = %q, want %q", got, tt.kept)
+			}
+			if !reflect.DeepEqual(plan.Culled, tt.culled) {
+				t.Errorf("culled = %q, want %q", plan.Culled, tt.culled)
+			}
+		})
+	}
+}
+
+func TestAlias(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(g *Graph)
+		slots []int // per resource
+		n     int
+	}{
+		{
+			// a lives in passes 0-1, b in 1-2, c in 2-3: c reuses a's memory.
+			name: "overlap and reuse",
+			build: func(g *Graph) {
+				back := importBack(g)
+				a := g.CreateImage("a", colorDesc)
+				b := g.CreateImage("b", colorDesc)
+				c := g.CreateImage("c", colorDesc)
+				g.AddPass("p0", nil).Write(a, vk.StateColorAttachment)
+				g.AddPass("p1", nil).Read(a, vk.StateSampledFragment).Write(b, vk.StateColorAttachment)
+				g.AddPass("p2", nil).Read(b, vk.StateSampledFragment).Write(c, vk.StateColorAttachment)
+				g.AddPass("p3", nil).Read(c, vk.StateSampledFragment).Write(back, vk.StateColorAttachment)
+			},
+			slots: []int{-1, 0, 1, 0},
+			n:     2,
+		},
+		{
+			name: "buffers and imports take no slot",
+			build: func(g *Graph) {
+				back := importBack(g)
+				buf := g.CreateBuffer("buf", BufferDesc{Size: 64})
+				img := g.CreateImage("img", colorDesc)
+				g.AddPass("p0", nil).Write(buf, vk.StateStorageCompute)
+				g.AddPass("p1", nil).Read(buf, vk.StateUniformVertex).Write(img, vk.StateColorAttachment)
+				g.AddPass("p2", nil).Read(img, vk.StateSampledFragment).Write(back, vk.StateColorAttachment)
+			},
+			slots: []int{-1, -1, 0},
+			n:     1,
+		},
+		{
+			name: "culled image",
+			build: func(g *Graph) {
+				back := importBack(g)
+				img := g.CreateImage("img", colorDesc)
+				g.AddPass("dead", nil).Write(img, vk.StateColorAttachment)
+				g.AddPass("draw", nil).Write(back, vk.StateColorAttachment)
+			},
+			slots: []int{-1, -1},
+			n:     0,
+		},
+		{
+			// Slots are assigned in declaration order: late, used last, takes
+			// slot 0 before early and mid, which could have shared it.
+			name: "declaration order",
+			build: func(g *Graph) {
+				back := importBack(g)
+				late := g.CreateImage("late", colorDesc)
+				early := g.CreateImage("early", colorDesc)
+				mid := g.CreateImage("mid", colorDesc)
+				g.AddPass("p0", nil).Write(early, vk.StateColorAttachment).SideEffect()
+				g.AddPass("p1", nil).Write(mid, vk.StateColorAttachment).SideEffect()
+				g.AddPass("p2", nil).Write(late, vk.StateColorAttachment)
+				g.AddPass("p3", nil).Read(late, vk.StateSampledFragment).Write(back, vk.StateColorAttachment)
+			},
+			slots: []int{-1, 0, 1, 1},
+			n:     2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New()
+			tt.build(g)
+			plan, err := g.Compile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var slots []int
+			for _, r := range plan.Resources {
+				slots = append(slots, r.Slot)
+			}
+			if !reflect.DeepEqual(slots, tt.slots) {
+				t.Errorf("slots = %v, want %v", slots, tt.slots)
+			}
+			if plan.Slots != tt.n {
+				t.Errorf("Slots = %d, want %d", plan.Slots, tt.n)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(g *Graph)
+		err   string
+	}{
+		{
+			name: "transient read before write",
+			build: func(g *Graph) {
+				back := importBack(g)
+				tmp := g.CreateImage("tmp", colorDesc)
+				g.AddPass("draw", nil).Read(tmp, vk.StateSampledFragment).Write(back, vk.StateColorAttachment)
+			},
+			err: `pass "draw" reads transient "tmp" before any pass writes it`,
+		},
+		{
+			name: "two layouts",
+			build: func(g *Graph) {
+				back := importBack(g)
+				g.AddPass("draw", nil).Read(back, vk.StateSampledFragment).Write(back, vk.StateColorAttachment)
+			},
+			err: `pass "draw" uses image "back" in two layouts`,
+		},
+		{
+			name: "transient export",
+			build: func(g *Graph) {
+				tmp := g.CreateImage("tmp", colorDesc)
+				g.Export(tmp, vk.StateSampledFragment)
+				g.AddPass("draw", nil).Write(tmp, vk.StateColorAttachment)
+			},
+			err: `transient resource "tmp" exported`,
+		},
+		{
+			name: "unknown resource",
+			build: func(g *Graph) {
+				g.AddPass("draw", nil).Write(Resource(3), vk.StateColorAttachment)
+			},
+			err: `pass "draw" uses unknown resource 3`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New()
+			tt.build(g)
+			_, err := g.Compile()
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("err = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestBarriers(t *testing.T) {
+	colorOut := vk.Stage2ColorAttachmentOutput
+	colorRW := vk.Access2ColorAttachmentRead | vk.Access2ColorAttachmentWrite
+	tests := []struct {
+		name   string
+		build  func(g *Graph)
+		passes [][]Barrier
+		final  []Barrier
+	}{
+		{
+			name: "render then sample",
+			build: func(g *Graph) {
+				back := importBack(g)
+				hdr := g.CreateImage("hdr", colorDesc)
+				g.AddPass("scene", nil).Write(hdr, vk.StateColorAttachment)
+				g.AddPass("tonemap", nil).Read(hdr, vk.StateSampledFragment).Write(back, vk.StateColorAttachment)
+			},
+			passes: [][]Barrier{
+				{{
+					Resource: 1,
+					DstStage: colorOut, DstAccess: colorRW,
+					OldLayout: vk.LayoutUndefined, NewLayout: vk.LayoutColorAttachmentOptimal,
+					Range: whole,
+				}},
+				{
+					{
+						Resource: 1,
+						SrcStage: colorOut, SrcAccess: vk.Access2ColorAttachmentWrite,
+						DstStage: vk.Stage2FragmentShader, DstAccess: vk.Access2ShaderSampledRead,
+						OldLayout: vk.LayoutColorAttachmentOptimal, NewLayout: vk.LayoutShaderReadOnlyOptimal,
+						Range: whole,
+					},
+					{
+						Resource: 0,
+						DstStage: colorOut, DstAccess: colorRW,
+						OldLayout: vk.LayoutUndefined, NewLayout: vk.LayoutColorAttachmentOptimal,
+						Range: whole,
+					},
+				},
+			},
+			final: []Barrier{{
+				Resource: 0,
+				SrcStage: colorOut, SrcAccess: vk.Access2ColorAttachmentWrite,
+				OldLayout: vk.LayoutColorAttachmentOptimal, NewLayout: vk.LayoutPresentSrcKHR,
+				Range: whole,
+			}},
+		},
+		{
+			// b takes a's memory, so its first barrier waits for every
+			// access to a.
+			name: "aliased image",
+			build: func(g *Graph) {
+				back := importBack(g)
+				a := g.CreateImage("a", colorDesc)
+				b := g.CreateImage("b", colorDesc)
+				g.AddPass("p0", nil).Write(a, vk.StateColorAttachment)
+				g.AddPass("p1", nil).Read(a, vk.StateSampledFragment).SideEffect()
+				g.AddPass("p2", nil).Write(b, vk.StateColorAttachment)
+				g.AddPass("p3", nil).Read(b, vk.StateSampledFragment).Write(back, vk.StateColorAttachment)
+			},
+			passes: [][]Barrier{
+				{{
+					Resource: 1,
+					DstStage: colorOut, DstAccess: colorRW,
+					OldLayout: vk.LayoutUndefined, NewLayout: vk.LayoutColorAttachmentOptimal,
+					Range: whole,
+				}},
+				{{
+					Resource: 1,
+					SrcStage: colorOut, SrcAccess: vk.Access2ColorAttachmentWrite,
+					DstStage: vk.Stage2FragmentShader, DstAccess: vk.Access2ShaderSampledRead,
+					OldLayout: vk.LayoutColorAttachmentOptimal, NewLayout: vk.LayoutShaderReadOnlyOptimal,
+					Range: whole,
+				}},
+				{{
+					Resource: 2,
+					SrcStage: colorOut | vk.Stage2FragmentShader, SrcAccess: vk.Access2ColorAttachmentWrite,
+					DstStage: colorOut, DstAccess: colorRW,
+					OldLayout: vk.LayoutUndefined, NewLayout: vk.LayoutColorAttachmentOptimal,
+					Range: whole,
+				}},
+				{
+					{
+						Resource: 2,
+						SrcStage: colorOut, SrcAccess: vk.Access2ColorAttachmentWrite,
+						DstStage: vk.Stage2FragmentShader, DstAccess: vk.Access2ShaderSampledRead,
+						OldLayout: vk.LayoutColorAttachmentOptimal, NewLayout: vk.LayoutShaderReadOnlyOptimal,
+						Range: whole,
+					},
+					{
+						Resource: 0,
+						DstStage: colorOut, DstAccess: colorRW,
+						OldLayout: vk.LayoutUndefined, NewLayout: vk.LayoutColorAttachmentOptimal,
+						Range: whole,
+					},
+				},
+			},
+			final: []Barrier{{
+				Resource: 0,
+				SrcStage: colorOut, SrcAccess: vk.Access2ColorAttachmentWrite,
+				OldLayout: vk.LayoutColorAttachmentOptimal, NewLayout: vk.LayoutPresentSrcKHR,
+				Range: whole,
+			}},
+		},
+		{
+			name: "imported buffer",
+			build: func(g *Graph) {
+				buf := g.ImportBuffer("particles", 1, 1024, vk.StateVertexInput)
+				g.Export(buf, vk.StateVertexInput)
+				g.AddPass("simulate", nil).Write(buf, vk.StateStorageCompute)
+			},
+			passes: [][]Barrier{
+				{{
+					Resource: 0,
+					SrcStage: vk.Stage2VertexInput,
+					DstStage: vk.Stage2ComputeShader, DstAccess: vk.Access2ShaderStorageRead | vk.Access2ShaderStorageWrite,
+				}},
+			},
+			final: []Barrier{{
+				Resource: 0,
+				SrcStage: vk.Stage2ComputeShader, SrcAccess: vk.Access2ShaderStorageWrite,
+				DstStage: vk.Stage2VertexInput, DstAccess: vk.Access2VertexAttributeRead | vk.Access2IndexRead,
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New()
+			tt.build(g)
+			plan, err := g.Compile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(plan.Passes) != len(tt.passes) {
+				t.Fatalf("%d passes, want %d", len(plan.Passes), len(tt.passes))
+			}
+			for i, pp := range plan.Passes {
+				if !reflect.DeepEqual(pp.Barriers, tt.passes[i]) {
+					t.Errorf("pass %s barriers\n got %+v\nwant %+v", pp.Name, pp.Barriers, tt.passes[i])
+				}
+			}
+			if !reflect.DeepEqual(plan.Final, tt.final) {
+				t.Errorf("final barriers\n got %+v\nwant %+v", plan.Final, tt.final)
+			}
+		})
+	}
+}
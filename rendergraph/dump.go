@@ -0,0 +1,54 @@
+package rendergraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Dot returns the graph of the plan in Graphviz dot syntax: passes as boxes
+// numbered in execution order, culled passes dashed, resources as ellipses
+// (imported ones doubled, transient images labelled with their memory slot),
+// and edges from written resources to passes reading them.
+func (p *Plan) Dot() string {
+	var b strings.Builder
+	b.WriteString("digraph rendergraph {\n\trankdir=LR;\n")
+	for i, r := range p.Resources {
+		label := r.Name
+		if r.Slot >= 0 {
+			label = fmt.Sprintf("%s\nslot %d", r.Name, r.Slot)
+		}
+		shape := "ellipse"
+		if r.Imported {
+			shape = "doubleoctagon"
+		}
+		fmt.Fprintf(&b, "\tr%d [label=%q shape=%s];\n", i, label, shape)
+	}
+	live := make(map[*Pass]int, len(p.Passes))
+	for i, pp := range p.Passes {
+		live[pp.pass] = i
+	}
+	for _, pass := range p.g.passes {
+		if i, ok := live[pass]; ok {
+			fmt.Fprintf(&b, "\tp%d [label=%q shape=box];\n", pass.index, fmt.Sprintf("%d: %s", i, pass.name))
+		} else {
+			fmt.Fprintf(&b, "\tp%d [label=%q shape=box style=dashed];\n", pass.index, pass.name)
+		}
+		for _, u := range pass.uses {
+			if u.read {
+				fmt.Fprintf(&b, "\tr%d -> p%d;\n", u.res, pass.index)
+			}
+			if u.write {
+				fmt.Fprintf(&b, "\tp%d -> r%d [color=red];\n", pass.index, u.res)
+			}
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// JSON returns the plan as indented JSON: its passes in order with their
+// barriers, the culled passes, the resources, and the final barriers.
+func (p *Plan) JSON() ([]byte, error) {
+	return json.MarshalIndent(p, "", "  ")
+}
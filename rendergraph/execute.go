@@ -0,0 +1,285 @@
+package rendergraph
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/christerso/vulkan-go/vk"
+)
+
+// Acquired is the initial state of a swapchain image just acquired, for a
+// frame whose submission waits on the acquire semaphore at
+// vk.Stage2ColorAttachmentOutput: its layout transition is ordered after
+// that wait.
+var Acquired = vk.ResourceState{Stage: vk.Stage2ColorAttachmentOutput}
+
+// ImportSwapchainImage imports an acquired swapchain image, in state
+// Acquired, and exports it for presentation.
+func (g *Graph) ImportSwapchainImage(name string, img vk.Image, view vk.ImageView, format vk.Format, extent vk.Extent2D) Resource {
+	r := g.ImportImage(name, img, view, ImageDesc{Format: format, Width: extent.Width, Height: extent.Height}, Acquired)
+	g.Export(r, vk.StatePresent)
+	return r
+}
+
+// Transients creates and keeps the transient resources of compiled plans.
+// As long as a plan's transient resources and memory slots match the
+// previous plan's, Execute reuses them; otherwise they are recreated, so the
+// caller must make sure no submitted frame still uses them when the graph
+// changes shape. Transient images carry no state between frames, so use one
+// Transients per frame in flight.
+type Transients struct {
+	device vk.Device
+	pd     vk.PhysicalDevice
+	key    string
+	images []vk.Image // by Resource; 0 for non-transient images
+	views  []vk.ImageView
+	bufs   []vk.AllocBuffer
+	memory []vk.DeviceMemory
+}
+
+// NewTransients returns an empty set of transient resources on device.
+func NewTransients(device vk.Device, pd vk.PhysicalDevice) *Transients {
+	return &Transients{device: device, pd: pd}
+}
+
+// Destroy destroys the transient resources and their memory.
+func (t *Transients) Destroy() {
+	t.release()
+	t.key = ""
+}
+
+func (t *Transients) release() {
+	for _, v := range t.views {
+		t.device.DestroyImageView(v)
+	}
+	for _, img := range t.images {
+		if img != 0 {
+			t.device.DestroyImage(vk.AllocImage{Image: img})
+		}
+	}
+	for _, b := range t.bufs {
+		t.device.DestroyBuffer(b)
+	}
+	for _, m := range t.memory {
+		t.device.FreeMemory(m)
+	}
+	t.images, t.views, t.bufs, t.memory = nil, nil, nil, nil
+}
+
+// planKey identifies the transient resources of p and their slots.
+func planKey(p *Plan) string {
+	var b strings.Builder
+	for i, r := range p.g.res {
+		if r.imported || p.Resources[i].First < 0 {
+			continue
+		}
+		if r.buffer {
+			fmt.Fprintf(&b, "%d:b%v;", i, r.buf)
+		} else {
+			fmt.Fprintf(&b, "%d:i%v@%d;", i, r.image, p.Resources[i].Slot)
+		}
+	}
+	return b.String()
+}
+
+// realize makes sure t holds the transient resources of p.
+func (t *Transients) realize(p *Plan) error {
+	key := planKey(p)
+	if key == t.key && len(t.images) == len(p.g.res) {
+		return nil
+	}
+	t.release()
+	t.key = ""
+	n := len(p.g.res)
+	t.images, t.views, t.bufs = make([]vk.Image, n), make([]vk.ImageView, n), make([]vk.AllocBuffer, n)
+	slots := make([][]Resource, p.Slots)
+	for i, r := range p.g.res {
+		info := p.Resources[i]
+		if r.imported || info.First < 0 {
+			continue
+		}
+		if r.buffer {
+			b, err := t.device.CreateBuffer(t.pd, vk.BufferConfig{Size: r.buf.Size, Usage: r.buf.Usage, Properties: vk.MemoryDeviceLocal})
+			if err != nil {
+				t.release()
+				return fmt.Errorf("rendergraph: buffer %q: %w", r.name, err)
+			}
+			t.bufs[i] = b
+			continue
+		}
+		img, err := t.device.CreateImage(vk.ImageConfig{
+			Format:    r.image.Format,
+			Extent:    vk.Extent2D{Width: r.image.Width, Height: r.image.Height},
+			MipLevels: r.image.Levels,
+			Samples:   r.image.Samples,
+			Usage:     r.image.Usage,
+		})
+		if err != nil {
+			t.release()
+			return fmt.Errorf("rendergraph: image %q: %w", r.name, err)
+		}
+		t.images[i] = img
+		slots[info.Slot] = append(slots[info.Slot], Resource(i))
+	}
+	for _, members := range slots {
+		if err := t.bindSlot(p, members); err != nil {
+			t.release()
+			return err
+		}
+	}
+	for i, img := range t.images {
+		if img == 0 {
+			continue
+		}
+		desc := p.g.res[i].image
+		aspect := desc.Aspect
+		if aspect == 0 {
+			aspect = vk.AspectColor
+		}
+		v, err := t.device.CreateImageViewMips(img, desc.Format, aspect, desc.Levels)
+		if err != nil {
+			t.release()
+			return fmt.Errorf("rendergraph: view of %q: %w", p.g.res[i].name, err)
+		}
+		t.views[i] = v
+	}
+	t.key = key
+	return nil
+}
+
+// bindSlot allocates the memory the images of one slot share and binds them
+// at offset 0. Images whose memory types have nothing in common with the
+// rest get a block of their own.
+func (t *Transients) bindSlot(p *Plan, members []Resource) error {
+	type block struct {
+		req    vk.MemoryRequirements
+		images []vk.Image
+	}
+	var blocks []*block
+	for _, r := range members {
+		img := t.images[r]
+		req := t.device.ImageMemoryRequirements(img)
+		var b *block
+		for _, cand := range blocks {
+			if cand.req.MemoryTypeBits&req.MemoryTypeBits != 0 {
+				b = cand
+				break
+			}
+		}
+		if b == nil {
+			b = &block{req: req}
+			blocks = append(blocks, b)
+		}
+		b.req.MemoryTypeBits &= req.MemoryTypeBits
+		b.req.Size = max(b.req.Size, req.Size)
+		b.images = append(b.images, img)
+	}
+	for _, b := range blocks {
+		mem, err := t.device.AllocateMemory(t.pd, b.req, vk.MemoryDeviceLocal)
+		if err != nil {
+			return fmt.Errorf("rendergraph: transient memory: %w", err)
+		}
+		t.memory = append(t.memory, mem)
+		for _, img := range b.images {
+			if err := t.device.BindImageMemory(img, mem, 0); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Context is what a pass's run function records with: the command buffer
+// and the handles of the graph's resources.
+type Context struct {
+	Cmd vk.CommandBuffer
+
+	plan *Plan
+	t    *Transients
+}
+
+// Image returns the image of r.
+func (c *Context) Image(r Resource) vk.Image {
+	return c.plan.image(r, c.t)
+}
+
+// View returns the image view of r.
+func (c *Context) View(r Resource) vk.ImageView {
+	if res := c.plan.g.res[r]; res.imported {
+		return res.view
+	}
+	return c.t.views[r]
+}
+
+// Buffer returns the buffer of r.
+func (c *Context) Buffer(r Resource) vk.Buffer {
+	return c.plan.buffer(r, c.t)
+}
+
+// Extent returns the size of image r.
+func (c *Context) Extent(r Resource) vk.Extent2D {
+	d := c.plan.g.res[r].image
+	return vk.Extent2D{Width: d.Width, Height: d.Height}
+}
+
+func (p *Plan) image(r Resource, t *Transients) vk.Image {
+	if res := p.g.res[r]; res.imported {
+		return res.handle
+	}
+	return t.images[r]
+}
+
+func (p *Plan) buffer(r Resource, t *Transients) vk.Buffer {
+	if res := p.g.res[r]; res.imported {
+		return res.bufh
+	}
+	return t.bufs[r].Buffer
+}
+
+// Execute records the plan into cmd: before each pass its barriers, in one
+// vkCmdPipelineBarrier2, then the pass's commands, and after the last pass
+// the transitions of exported resources. t provides the transient resources
+// and may be nil if the plan has none. The device needs
+// vk.DeviceConfig.Synchronization2.
+func (p *Plan) Execute(cmd vk.CommandBuffer, t *Transients) error {
+	if t == nil {
+		if planKey(p) != "" {
+			return fmt.Errorf("rendergraph: plan has transient resources but no Transients")
+		}
+		t = &Transients{}
+	}
+	if err := t.realize(p); err != nil {
+		return err
+	}
+	ctx := &Context{Cmd: cmd, plan: p, t: t}
+	var dep vk.DependencyInfo
+	for _, pp := range p.Passes {
+		p.record(cmd, &dep, pp.Barriers, t)
+		if pp.pass.run != nil {
+			pp.pass.run(ctx)
+		}
+	}
+	p.record(cmd, &dep, p.Final, t)
+	return nil
+}
+
+// record records bs with their resources' real handles.
+func (p *Plan) record(cmd vk.CommandBuffer, dep *vk.DependencyInfo, bs []Barrier, t *Transients) {
+	for _, b := range bs {
+		if p.g.res[b.Resource].buffer {
+			dep.Buffer(vk.BufferBarrier2{
+				SrcStage: b.SrcStage, SrcAccess: b.SrcAccess,
+				DstStage: b.DstStage, DstAccess: b.DstAccess,
+				Buffer: p.buffer(b.Resource, t),
+			})
+			continue
+		}
+		dep.Image(vk.ImageBarrier2{
+			SrcStage: b.SrcStage, SrcAccess: b.SrcAccess,
+			DstStage: b.DstStage, DstAccess: b.DstAccess,
+			OldLayout: b.OldLayout, NewLayout: b.NewLayout,
+			Image: p.image(b.Resource, t), Range: b.Range,
+		})
+	}
+	cmd.PipelineBarrier2(dep)
+}
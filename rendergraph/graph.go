@@ -0,0 +1,182 @@
+// Package rendergraph schedules the passes of a frame from the resources
+// they declare. Each pass names the images and buffers it reads and writes
+// and the state (vk.ResourceState) it uses them in; Compile turns that into
+// a Plan:
+//
+//   - passes whose results never reach an imported resource or a pass marked
+//     SideEffect are culled;
+//   - transient images whose lifetimes do not overlap share memory;
+//   - the barriers and layout transitions before each pass are derived with
+//     a vk.StateTracker, and exported resources end in their final state.
+//
+// Compile needs no device, so graphs can be checked, and dumped with Plan.Dot
+// or as JSON, without a GPU. Plan.Execute records the passes:
+//
+//	g := rendergraph.New()
+//	back := g.ImportImage("swapchain", img, view, rendergraph.ImageDesc{Format: format, Width: w, Height: h}, vk.StateUndefined)
+//	g.Export(back, vk.StatePresent)
+//	hdr := g.CreateImage("hdr", rendergraph.ImageDesc{Format: vk.FormatR16G16B16A16Sfloat, Width: w, Height: h,
+//		Usage: vk.ImageUsageColorAttachment | vk.ImageUsageSampled})
+//	g.AddPass("scene", drawScene).Write(hdr, vk.StateColorAttachment)
+//	g.AddPass("tonemap", tonemap).Read(hdr, vk.StateSampledFragment).Write(back, vk.StateColorAttachment)
+//	plan, err := g.Compile()
+//	...
+//	err = plan.Execute(cmd, transients)
+//
+// A graph describes one frame. Building and compiling it again every frame
+// is cheap; the Transients that back its transient resources persist.
+package rendergraph
+
+import "github.com/christerso/vulkan-go/vk"
+
+// Resource names an image or buffer of a Graph.
+type Resource int
+
+// ImageDesc describes a 2D image of the graph.
+type ImageDesc struct {
+	Format        vk.Format
+	Width, Height uint32
+	Levels        uint32 // mip levels; 0 means 1
+	Samples       uint32 // vk.SampleCount*; 0 means 1
+	// Usage holds the vk.ImageUsage* bits a transient image is created with.
+	Usage uint32
+	// Aspect holds the vk.Aspect* bits barriers cover; 0 means vk.AspectColor.
+	Aspect uint32
+}
+
+// BufferDesc describes a buffer of the graph.
+type BufferDesc struct {
+	Size  vk.DeviceSize
+	Usage uint32 // vk.BufferUsage* bits a transient buffer is created with
+}
+
+// resource is one image or buffer of the graph.
+type resource struct {
+	name     string
+	buffer   bool
+	image    ImageDesc
+	buf      BufferDesc
+	imported bool
+	initial  vk.ResourceState // imported resources
+	exported bool
+	final    vk.ResourceState // exported resources
+	// Handles of imported resources.
+	handle vk.Image
+	view   vk.ImageView
+	bufh   vk.Buffer
+}
+
+// use is one pass's access to one resource: reads, writes, or both, in a
+// single combined state.
+type use struct {
+	res         Resource
+	read, write bool
+	state       vk.ResourceState
+}
+
+// Pass is a pass of a Graph. Its methods declare what it accesses and return
+// the pass for chaining.
+type Pass struct {
+	name       string
+	index      int
+	run        func(*Context)
+	uses       []use
+	sideEffect bool
+}
+
+// Graph collects the resources and passes of a frame. Build it with the
+// Create, Import, and AddPass methods, then Compile it. A Graph is not safe
+// for concurrent use.
+type Graph struct {
+	res    []*resource
+	passes []*Pass
+}
+
+// New returns an empty graph.
+func New() *Graph { return &Graph{} }
+
+func (g *Graph) add(r *resource) Resource {
+	g.res = append(g.res, r)
+	return Resource(len(g.res) - 1)
+}
+
+// CreateImage declares a transient image: one the graph creates, that starts
+// each frame with undefined contents and lives only as long as the passes
+// using it.
+func (g *Graph) CreateImage(name string, desc ImageDesc) Resource {
+	return g.add(&resource{name: name, image: desc})
+}
+
+// CreateBuffer declares a transient buffer. Transient buffers get memory of
+// their own; only transient images alias.
+func (g *Graph) CreateBuffer(name string, desc BufferDesc) Resource {
+	return g.add(&resource{name: name, buffer: true, buf: desc})
+}
+
+// ImportImage declares an image that lives outside the graph, such as the
+// acquired swapchain image, currently in state initial. desc gives its size
+// and aspect; Usage is ignored. Writes to imported resources are results of
+// the frame, so the passes making them are never culled.
+func (g *Graph) ImportImage(name string, img vk.Image, view vk.ImageView, desc ImageDesc, initial vk.ResourceState) Resource {
+	return g.add(&resource{name: name, image: desc, imported: true, initial: initial, handle: img, view: view})
+}
+
+// ImportBuffer declares a buffer that lives outside the graph, currently in
+// state initial.
+func (g *Graph) ImportBuffer(name string, buf vk.Buffer, size vk.DeviceSize, initial vk.ResourceState) Resource {
+	return g.add(&resource{name: name, buffer: true, buf: BufferDesc{Size: size}, imported: true, initial: initial, bufh: buf})
+}
+
+// Export makes the frame leave imported resource r in state final, for
+// instance vk.StatePresent for a swapchain image.
+func (g *Graph) Export(r Resource, final vk.ResourceState) {
+	if int(r) < 0 || int(r) >= len(g.res) {
+		return // reported by Compile when r is used
+	}
+	g.res[r].exported = true
+	g.res[r].final = final
+}
+
+// AddPass adds a pass that records its commands with run. Passes run in the
+// order they are added, less the culled ones.
+func (g *Graph) AddPass(name string, run func(*Context)) *Pass {
+	p := &Pass{name: name, index: len(g.passes), run: run}
+	g.passes = append(g.passes, p)
+	return p
+}
+
+func (p *Pass) use(r Resource, s vk.ResourceState, read, write bool) *Pass {
+	for i := range p.uses {
+		if u := &p.uses[i]; u.res == r {
+			u.read, u.write = u.read || read, u.write || write
+			if s.Layout != vk.LayoutUndefined && u.state.Layout != vk.LayoutUndefined && s.Layout != u.state.Layout {
+				u.state.Layout = layoutConflict
+			} else if s.Layout != vk.LayoutUndefined {
+				u.state.Layout = s.Layout
+			}
+			u.state.Stage |= s.Stage
+			u.state.Access |= s.Access
+			return p
+		}
+	}
+	p.uses = append(p.uses, use{res: r, read: read, write: write, state: s})
+	return p
+}
+
+// layoutConflict marks a use whose declarations asked for two layouts.
+const layoutConflict vk.ImageLayout = 0x7FFFFFFF
+
+// Read declares that the pass reads r in state s.
+func (p *Pass) Read(r Resource, s vk.ResourceState) *Pass { return p.use(r, s, true, false) }
+
+// Write declares that the pass writes r in state s, replacing its contents.
+// A pass that also depends on the previous contents, such as one blending
+// into a color attachment, declares a Read as well.
+func (p *Pass) Write(r Resource, s vk.ResourceState) *Pass { return p.use(r, s, false, true) }
+
+// SideEffect keeps the pass even when nothing it writes is used, for passes
+// with effects the graph cannot see, such as host readback.
+func (p *Pass) SideEffect() *Pass {
+	p.sideEffect = true
+	return p
+}
@@ -0,0 +1,444 @@
+// Package builder constructs SPIR-V compute shaders in Go, for programs that
+// generate kernels at runtime and have no GLSL compiler to hand. The module
+// it produces is SPIR-V 1.3 for Vulkan 1.1 and later and feeds straight into
+// vk.Device.CreateShaderModule:
+//
+//	b := builder.New()
+//	f32 := b.Float32()
+//	src := b.StorageBuffer(0, 0, b.RuntimeArray(f32), true)
+//	dst := b.StorageBuffer(0, 1, b.RuntimeArray(f32), false)
+//	fn := b.Compute("main", 64, 1, 1)
+//	i := fn.Extract(fn.GlobalInvocationID(), 0)
+//	x := fn.Load(fn.Index(src, i))
+//	fn.Store(fn.Index(dst, i), fn.Mul(x, b.ConstFloat32(2)))
+//	code, err := b.Build()
+//
+// Operations pick the SPIR-V instruction from their operand types (Add is
+// OpIAdd or OpFAdd, Less is OpULessThan, OpSLessThan, or OpFOrdLessThan).
+// Misuse, such as adding an int to a float, does not panic: the first error
+// is kept and returned by Build, and the values produced after it are
+// invalid, as with bufio.Writer.
+//
+// Buffer and push-constant blocks get explicit layouts: std430 for storage
+// buffers and push constants, std140 for uniform buffers.
+package builder
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/christerso/vulkan-go/spirv"
+)
+
+// SPIR-V header values.
+const (
+	version = 0x00010300 // SPIR-V 1.3
+	schema  = 0
+)
+
+// Opcodes emitted, beyond those package spirv names.
+const (
+	opExtInstImport        = 11
+	opExtInst              = 12
+	opMemoryModel          = 14
+	opCapability           = 17
+	opTypeFunction         = 33
+	opFunction             = 54
+	opFunctionEnd          = 56
+	opLoad                 = 61
+	opStore                = 62
+	opAccessChain          = 65
+	opCompositeConstruct   = 80
+	opCompositeExtract     = 81
+	opConvertFToU          = 109
+	opConvertFToS          = 110
+	opConvertSToF          = 111
+	opConvertUToF          = 112
+	opUConvert             = 113
+	opSConvert             = 114
+	opFConvert             = 115
+	opBitcast              = 124
+	opSNegate              = 126
+	opFNegate              = 127
+	opIAdd                 = 128
+	opFAdd                 = 129
+	opISub                 = 130
+	opFSub                 = 131
+	opIMul                 = 132
+	opFMul                 = 133
+	opUDiv                 = 134
+	opSDiv                 = 135
+	opFDiv                 = 136
+	opUMod                 = 137
+	opSRem                 = 138
+	opFRem                 = 140
+	opLogicalEqual         = 164
+	opLogicalNotEqual      = 165
+	opLogicalOr            = 166
+	opLogicalAnd           = 167
+	opLogicalNot           = 168
+	opSelect               = 169
+	opIEqual               = 170
+	opINotEqual            = 171
+	opUGreaterThan         = 172
+	opSGreaterThan         = 173
+	opUGreaterThanEqual    = 174
+	opSGreaterThanEqual    = 175
+	opULessThan            = 176
+	opSLessThan            = 177
+	opULessThanEqual       = 178
+	opSLessThanEqual       = 179
+	opFOrdEqual            = 180
+	opFOrdNotEqual         = 182
+	opFOrdLessThan         = 184
+	opFOrdGreaterThan      = 186
+	opFOrdLessThanEqual    = 188
+	opFOrdGreaterThanEqual = 190
+	opShiftRightLogical    = 194
+	opShiftRightArithmetic = 195
+	opShiftLeftLogical     = 196
+	opBitwiseOr            = 197
+	opBitwiseXor           = 198
+	opBitwiseAnd           = 199
+	opNot                  = 200
+	opControlBarrier       = 224
+	opAtomicExchange       = 229
+	opAtomicIAdd           = 234
+	opAtomicSMin           = 236
+	opAtomicUMin           = 237
+	opAtomicSMax           = 238
+	opAtomicUMax           = 239
+	opLoopMerge            = 246
+	opSelectionMerge       = 247
+	opLabel                = 248
+	opBranch               = 249
+	opBranchConditional    = 250
+	opReturn               = 253
+)
+
+// Capabilities, decorations, and enumerants emitted.
+const (
+	capShader      = 1
+	capFloat64     = 10
+	capInt64       = 11
+	capInt64Atomic = 12
+
+	decorationBuiltIn = 11
+
+	builtInNumWorkgroups        = 24
+	builtInWorkgroupID          = 26
+	builtInLocalInvocationID    = 27
+	builtInGlobalInvocationID   = 28
+	builtInLocalInvocationIndex = 29
+
+	scopeDevice    = 1
+	scopeWorkgroup = 2
+
+	addressingLogical = 0
+	memoryGLSL450     = 1
+)
+
+// Builder accumulates one SPIR-V module. The zero value is not usable; call
+// New.
+type Builder struct {
+	next uint32 // next free result id
+	err  error
+
+	caps    map[uint32]bool
+	capList []uint32
+	glsl    uint32 // GLSL.std.450 import id, 0 until used
+
+	debug    []uint32 // OpName, OpMemberName
+	annot    []uint32 // decorations
+	globals  []uint32 // types, constants, global variables
+	funcs    []*Func
+	types    map[string]*Type
+	consts   map[string]Value
+	builtins map[uint32]Value // BuiltIn -> Input variable
+	wrapped  map[uint32]bool  // variables whose block wraps a non-struct type
+	laidOut  map[uint32]layoutRule
+	blocks   map[uint32]bool // struct types decorated Block
+}
+
+// New returns a Builder for a compute module.
+func New() *Builder {
+	b := &Builder{
+		next:     1,
+		caps:     map[uint32]bool{},
+		types:    map[string]*Type{},
+		consts:   map[string]Value{},
+		builtins: map[uint32]Value{},
+		wrapped:  map[uint32]bool{},
+		laidOut:  map[uint32]layoutRule{},
+		blocks:   map[uint32]bool{},
+	}
+	b.capability(capShader)
+	return b
+}
+
+// Err returns the first error recorded while building, or nil.
+func (b *Builder) Err() error { return b.err }
+
+// fail records the first error.
+func (b *Builder) fail(format string, args ...any) {
+	if b.err == nil {
+		b.err = fmt.Errorf("builder: "+format, args...)
+	}
+}
+
+// id allocates a result id.
+func (b *Builder) id() uint32 {
+	id := b.next
+	b.next++
+	return id
+}
+
+func (b *Builder) capability(c uint32) {
+	if !b.caps[c] {
+		b.caps[c] = true
+		b.capList = append(b.capList, c)
+	}
+}
+
+// emit appends one instruction to dst.
+func emit(dst *[]uint32, op uint32, operands ...uint32) {
+	*dst = append(*dst, uint32(len(operands)+1)<<16|op)
+	*dst = append(*dst, operands...)
+}
+
+// literal encodes s as a NUL-terminated SPIR-V literal string.
+func literal(s string) []uint32 {
+	b := append([]byte(s), 0)
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	w := make([]uint32, len(b)/4)
+	for i := range w {
+		w[i] = binary.LittleEndian.Uint32(b[4*i:])
+	}
+	return w
+}
+
+// Name attaches a debug name to v, shown by disassemblers.
+func (b *Builder) Name(v Value, name string) {
+	emit(&b.debug, uint32(spirv.OpName), append([]uint32{v.id}, literal(name)...)...)
+}
+
+// glslSet returns the id of the GLSL.std.450 instruction set import.
+func (b *Builder) glslSet() uint32 {
+	if b.glsl == 0 {
+		b.glsl = b.id()
+	}
+	return b.glsl
+}
+
+// Build assembles the module. It returns the first error recorded by any
+// builder call.
+func (b *Builder) Build() ([]byte, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.funcs) == 0 {
+		return nil, fmt.Errorf("builder: module has no entry point")
+	}
+	var w []uint32
+	for _, c := range b.capList {
+		emit(&w, opCapability, c)
+	}
+	if b.glsl != 0 {
+		emit(&w, opExtInstImport, append([]uint32{b.glsl}, literal("GLSL.std.450")...)...)
+	}
+	emit(&w, opMemoryModel, addressingLogical, memoryGLSL450)
+	for _, f := range b.funcs {
+		ops := append([]uint32{uint32(spirv.ModelGLCompute), f.id}, literal(f.name)...)
+		emit(&w, uint32(spirv.OpEntryPoint), append(ops, f.interfaceIDs()...)...)
+	}
+	for _, f := range b.funcs {
+		emit(&w, uint32(spirv.OpExecutionMode), f.id, 17, f.local[0], f.local[1], f.local[2]) // LocalSize
+	}
+	w = append(w, b.debug...)
+	w = append(w, b.annot...)
+	w = append(w, b.globals...)
+	for _, f := range b.funcs {
+		w = append(w, f.finish()...)
+	}
+	header := []uint32{spirv.Magic, version, 0, b.next, schema}
+	code := make([]byte, 4*(len(header)+len(w)))
+	for i, x := range append(header, w...) {
+		binary.LittleEndian.PutUint32(code[4*i:], x)
+	}
+	return code, nil
+}
+
+// Value is a result of the module: a constant, a variable (a pointer), or
+// the result of an instruction. The zero Value is invalid.
+type Value struct {
+	id uint32
+	t  *Type
+}
+
+// Type returns the type of v.
+func (v Value) Type() *Type { return v.t }
+
+// ID returns the result id of v.
+func (v Value) ID() uint32 { return v.id }
+
+// constant returns the deduplicated scalar constant of t with the given bits.
+func (b *Builder) constant(t *Type, bits uint64) Value {
+	key := fmt.Sprintf("%d:%d", t.id, bits)
+	if c, ok := b.consts[key]; ok {
+		return c
+	}
+	c := Value{id: b.id(), t: t}
+	switch {
+	case t.kind == kindBool && bits != 0:
+		emit(&b.globals, uint32(spirv.OpConstantTrue), t.id, c.id)
+	case t.kind == kindBool:
+		emit(&b.globals, uint32(spirv.OpConstantFalse), t.id, c.id)
+	case t.width == 64:
+		emit(&b.globals, uint32(spirv.OpConstant), t.id, c.id, uint32(bits), uint32(bits>>32))
+	default:
+		emit(&b.globals, uint32(spirv.OpConstant), t.id, c.id, uint32(bits))
+	}
+	b.consts[key] = c
+	return c
+}
+
+// Const returns the scalar constant v converted to t, a bool, integer, or
+// float type. For bool, any nonzero v is true.
+func (b *Builder) Const(t *Type, v float64) Value {
+	if t == nil {
+		b.fail("Const of nil type")
+		return Value{}
+	}
+	switch {
+	case t.kind == kindBool:
+		if v != 0 {
+			return b.constant(t, 1)
+		}
+		return b.constant(t, 0)
+	case t.kind == kindFloat && t.width == 32:
+		return b.constant(t, uint64(math.Float32bits(float32(v))))
+	case t.kind == kindFloat:
+		return b.constant(t, math.Float64bits(v))
+	case t.kind == kindInt && t.signed && t.width == 32:
+		return b.constant(t, uint64(uint32(int32(v))))
+	case t.kind == kindInt && t.signed:
+		return b.constant(t, uint64(int64(v)))
+	case t.kind == kindInt && t.width == 32:
+		return b.constant(t, uint64(uint32(v)))
+	case t.kind == kindInt:
+		return b.constant(t, uint64(v))
+	}
+	b.fail("Const of non-scalar type %s", t)
+	return Value{}
+}
+
+// ConstBool returns a boolean constant.
+func (b *Builder) ConstBool(v bool) Value {
+	if v {
+		return b.constant(b.Bool(), 1)
+	}
+	return b.constant(b.Bool(), 0)
+}
+
+// ConstInt32 returns a 32-bit signed integer constant.
+func (b *Builder) ConstInt32(v int32) Value { return b.constant(b.Int32(), uint64(uint32(v))) }
+
+// ConstUint32 returns a 32-bit unsigned integer constant.
+func (b *Builder) ConstUint32(v uint32) Value { return b.constant(b.Uint32(), uint64(v)) }
+
+// ConstFloat32 returns a 32-bit float constant.
+func (b *Builder) ConstFloat32(v float32) Value {
+	return b.constant(b.Float32(), uint64(math.Float32bits(v)))
+}
+
+// variable declares a global variable of type t in storage class sc.
+func (b *Builder) variable(t *Type, sc spirv.StorageClass) Value {
+	p := b.pointer(sc, t)
+	v := Value{id: b.id(), t: p}
+	emit(&b.globals, uint32(spirv.OpVariable), p.id, v.id, uint32(sc))
+	return v
+}
+
+// block wraps t in a struct unless it is one, decorates it Block unless an
+// earlier block did, and lays it out under rule. It reports whether it
+// wrapped.
+func (b *Builder) block(t *Type, rule layoutRule) (*Type, bool) {
+	wrapped := false
+	if t.kind != kindStruct {
+		t, wrapped = b.Struct(t), true
+	}
+	if !b.blocks[t.id] {
+		b.blocks[t.id] = true
+		emit(&b.annot, uint32(spirv.OpDecorate), t.id, spirv.DecorationBlock)
+	}
+	b.layout(t, rule)
+	return t, wrapped
+}
+
+// resource declares a block variable bound at set and binding.
+func (b *Builder) resource(set, binding uint32, t *Type, sc spirv.StorageClass, rule layoutRule) Value {
+	if t == nil {
+		b.fail("resource %d.%d of nil type", set, binding)
+		return Value{}
+	}
+	bt, wrapped := b.block(t, rule)
+	v := b.variable(bt, sc)
+	b.wrapped[v.id] = wrapped
+	emit(&b.annot, uint32(spirv.OpDecorate), v.id, spirv.DecorationDescriptorSet, set)
+	emit(&b.annot, uint32(spirv.OpDecorate), v.id, spirv.DecorationBinding, binding)
+	return v
+}
+
+// StorageBuffer declares a storage buffer at set and binding holding t,
+// typically a RuntimeArray or a Struct ending in one. A non-struct t is
+// wrapped in a one-member block that Index and Member see through. A
+// readOnly buffer is decorated NonWritable.
+func (b *Builder) StorageBuffer(set, binding uint32, t *Type, readOnly bool) Value {
+	v := b.resource(set, binding, t, spirv.StorageStorageBuffer, std430)
+	if readOnly && v.id != 0 {
+		emit(&b.annot, uint32(spirv.OpDecorate), v.id, spirv.DecorationNonWritable)
+	}
+	return v
+}
+
+// UniformBuffer declares a uniform buffer at set and binding holding t,
+// wrapped as StorageBuffer does.
+func (b *Builder) UniformBuffer(set, binding uint32, t *Type) Value {
+	return b.resource(set, binding, t, spirv.StorageUniform, std140)
+}
+
+// PushConstants declares the push constant block, wrapped as StorageBuffer
+// does. A module has at most one.
+func (b *Builder) PushConstants(t *Type) Value {
+	if t == nil {
+		b.fail("push constants of nil type")
+		return Value{}
+	}
+	bt, wrapped := b.block(t, std430)
+	v := b.variable(bt, spirv.StoragePushConstant)
+	b.wrapped[v.id] = wrapped
+	return v
+}
+
+// Shared declares a workgroup-shared variable of type t.
+func (b *Builder) Shared(t *Type) Value {
+	if t == nil {
+		b.fail("shared variable of nil type")
+		return Value{}
+	}
+	return b.variable(t, spirv.StorageWorkgroup)
+}
+
+// builtin returns the Input variable decorated with BuiltIn kind.
+func (b *Builder) builtin(kind uint32, t *Type) Value {
+	if v, ok := b.builtins[kind]; ok {
+		return v
+	}
+	v := b.variable(t, spirv.StorageInput)
+	emit(&b.annot, uint32(spirv.OpDecorate), v.id, decorationBuiltIn, kind)
+	b.builtins[kind] = v
+	return v
+}
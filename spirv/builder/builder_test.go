@@ -0,0 +1,366 @@
+package builder
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/christerso/vulkan-go/spirv"
+	"github.com/christerso/vulkan-go/vk"
+)
+
+// build assembles b and parses the result back.
+func build(t *testing.T, b *Builder) ([]byte, *spirv.Module) {
+	t.Helper()
+	code, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := spirv.Parse(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := spirv.Disassemble(code); err != nil {
+		t.Fatal(err)
+	}
+	return code, m
+}
+
+// find returns the instructions of m with opcode op.
+func find(m *spirv.Module, op uint32) []spirv.Instruction {
+	var out []spirv.Instruction
+	for _, inst := range m.Instructions {
+		if uint32(inst.Op) == op {
+			out = append(out, inst)
+		}
+	}
+	return out
+}
+
+// constants maps the result ids of m's 32-bit OpConstants to their values.
+func constants(m *spirv.Module) map[uint32]uint32 {
+	c := map[uint32]uint32{}
+	for _, inst := range find(m, uint32(spirv.OpConstant)) {
+		if len(inst.Operands) == 3 {
+			c[inst.Operands[1]] = inst.Operands[2]
+		}
+	}
+	return c
+}
+
+// decorations returns how often id is decorated with deco.
+func decorations(m *spirv.Module, id, deco uint32) int {
+	n := 0
+	for _, inst := range find(m, uint32(spirv.OpDecorate)) {
+		if inst.Operands[0] == id && inst.Operands[1] == deco {
+			n++
+		}
+	}
+	return n
+}
+
+func isTerminator(op uint32) bool {
+	return op == opBranch || op == opBranchConditional || op == opReturn
+}
+
+// checkBlocks checks that every block of every function ends in exactly one
+// terminator, and that merge instructions come right before their branch.
+func checkBlocks(t *testing.T, m *spirv.Module) {
+	t.Helper()
+	inBlock := false
+	var prev uint32
+	for i, inst := range m.Instructions {
+		op := uint32(inst.Op)
+		switch {
+		case op == opLabel:
+			if inBlock {
+				t.Fatalf("instruction %d: block without terminator", i)
+			}
+			inBlock = true
+		case op == opFunctionEnd:
+			if inBlock {
+				t.Fatalf("instruction %d: function ends inside a block", i)
+			}
+		case isTerminator(op):
+			if !inBlock {
+				t.Fatalf("instruction %d: terminator outside a block", i)
+			}
+			inBlock = false
+		case inBlock && (prev == opLoopMerge || prev == opSelectionMerge):
+			t.Fatalf("instruction %d: op %d follows a merge instruction", i, op)
+		}
+		if prev == opLoopMerge && op != opBranch && op != opBranchConditional {
+			t.Fatalf("instruction %d: OpLoopMerge followed by op %d", i, op)
+		}
+		if prev == opSelectionMerge && op != opBranchConditional {
+			t.Fatalf("instruction %d: OpSelectionMerge followed by op %d", i, op)
+		}
+		prev = op
+	}
+}
+
+func TestControlFlow(t *testing.T) {
+	b := New()
+	u32 := b.Uint32()
+	buf := b.StorageBuffer(0, 0, b.RuntimeArray(u32), false)
+	fn := b.Compute("main", 32, 1, 1)
+	i := fn.Extract(fn.GlobalInvocationID(), 0)
+	p := fn.Index(buf, i)
+	acc := fn.Var(u32)
+	fn.Store(acc, b.ConstUint32(0))
+	fn.For(b.ConstUint32(0), b.ConstUint32(16), func(j Value) {
+		fn.If(fn.Equal(j, b.ConstUint32(3)), func() { fn.Continue() })
+		fn.If(fn.Greater(j, i), func() { fn.Break() })
+		fn.Store(acc, fn.Add(fn.Load(acc), j))
+	})
+	n := fn.Var(u32)
+	fn.Store(n, i)
+	fn.While(func() Value { return fn.Greater(fn.Load(n), b.ConstUint32(1)) }, func() {
+		v := fn.Load(n)
+		fn.IfElse(fn.Equal(fn.Rem(v, b.ConstUint32(2)), b.ConstUint32(0)),
+			func() { fn.Store(n, fn.Shr(v, b.ConstUint32(1))) },
+			func() { fn.Store(n, fn.Add(fn.Mul(v, b.ConstUint32(3)), b.ConstUint32(1))) })
+	})
+	fn.Store(p, fn.Add(fn.Load(acc), fn.Load(n)))
+	code, m := build(t, b)
+
+	checkBlocks(t, m)
+	if got := len(find(m, opLoopMerge)); got != 2 {
+		t.Errorf("%d OpLoopMerge, want 2", got)
+	}
+	if got := len(find(m, opSelectionMerge)); got != 3 {
+		t.Errorf("%d OpSelectionMerge, want 3", got)
+	}
+	// Break and Continue branch to the For loop's merge and continue blocks.
+	loop := find(m, opLoopMerge)[0].Operands
+	merge, cont := loop[0], loop[1]
+	var toMerge, toCont int
+	for _, br := range find(m, opBranch) {
+		switch br.Operands[0] {
+		case merge:
+			toMerge++
+		case cont:
+			toCont++
+		}
+	}
+	// The loop condition exits to merge through OpBranchConditional; Break
+	// is the only OpBranch there. Continue and the end of the body both
+	// branch to the continue block.
+	if toMerge != 1 || toCont != 2 {
+		t.Errorf("branches to merge/continue = %d/%d, want 1/2", toMerge, toCont)
+	}
+
+	r, err := spirv.Reflect(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.EntryPoints) != 1 || r.EntryPoints[0].WorkgroupSize != [3]uint32{32, 1, 1} {
+		t.Errorf("entry points = %+v", r.EntryPoints)
+	}
+}
+
+func TestAtomicsAndShared(t *testing.T) {
+	b := New()
+	u32, i32 := b.Uint32(), b.Int32()
+	counts := b.StorageBuffer(0, 0, b.RuntimeArray(u32), false)
+	tile := b.Shared(b.Array(i32, 64))
+	fn := b.Compute("main", 64, 1, 1)
+	local := fn.LocalInvocationIndex()
+	fn.Store(fn.Index(tile, local), b.ConstInt32(0))
+	fn.Barrier()
+	fn.AtomicMax(fn.Index(tile, b.ConstUint32(0)), fn.Convert(local, i32))
+	fn.Barrier()
+	fn.AtomicAdd(fn.Index(counts, b.ConstUint32(0)), b.ConstUint32(1))
+	code, m := build(t, b)
+	checkBlocks(t, m)
+	c := constants(m)
+
+	barriers := find(m, opControlBarrier)
+	if len(barriers) != 2 {
+		t.Fatalf("%d OpControlBarrier, want 2", len(barriers))
+	}
+	for _, br := range barriers {
+		ops := br.Operands
+		if c[ops[0]] != scopeWorkgroup || c[ops[1]] != scopeWorkgroup || c[ops[2]] != 0x108 {
+			t.Errorf("barrier scopes/semantics = %d %d %#x", c[ops[0]], c[ops[1]], c[ops[2]])
+		}
+	}
+	// Atomics on shared memory use workgroup scope, on buffers device scope.
+	smax := find(m, opAtomicSMax)
+	add := find(m, opAtomicIAdd)
+	if len(smax) != 1 || len(add) != 1 {
+		t.Fatalf("%d OpAtomicSMax, %d OpAtomicIAdd, want 1 each", len(smax), len(add))
+	}
+	if s := c[smax[0].Operands[3]]; s != scopeWorkgroup {
+		t.Errorf("shared atomic scope = %d, want workgroup", s)
+	}
+	if s := c[add[0].Operands[3]]; s != scopeDevice {
+		t.Errorf("buffer atomic scope = %d, want device", s)
+	}
+
+	var workgroup int
+	for _, v := range find(m, uint32(spirv.OpVariable)) {
+		if spirv.StorageClass(v.Operands[2]) == spirv.StorageWorkgroup {
+			workgroup++
+			if v.Operands[1] != tile.ID() {
+				t.Errorf("workgroup variable %%%d, want %%%d", v.Operands[1], tile.ID())
+			}
+		}
+	}
+	if workgroup != 1 {
+		t.Errorf("%d workgroup variables, want 1", workgroup)
+	}
+
+	r, err := spirv.Reflect(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Bindings) != 1 || r.Bindings[0].Type != vk.DescriptorStorageBuffer || r.Bindings[0].ReadOnly {
+		t.Errorf("bindings = %+v", r.Bindings)
+	}
+}
+
+func TestBlockLayouts(t *testing.T) {
+	b := New()
+	f32 := b.Float32()
+	vec2, vec3, vec4 := b.Vector(f32, 2), b.Vector(f32, 3), b.Vector(f32, 4)
+	// The same members under both rules; the arrays differ in length since
+	// a type's layout decorations are shared by every block using it.
+	ubo := b.Struct(f32, vec3, f32, b.Array(f32, 3), vec2)
+	ssbo := b.Struct(f32, vec3, f32, b.Array(f32, 2), vec2, b.RuntimeArray(vec4))
+	push := b.Struct(b.Uint32(), vec4)
+	u := b.UniformBuffer(0, 0, ubo)
+	s := b.StorageBuffer(0, 1, ssbo, true)
+	// A second binding of the same struct shares its decorations.
+	s2 := b.StorageBuffer(0, 2, ssbo, false)
+	pc := b.PushConstants(push)
+	fn := b.Compute("main", 1, 1, 1)
+	x := fn.Load(fn.Member(u, 0))
+	x = fn.Add(x, fn.Load(fn.Member(s, 2)))
+	fn.Store(fn.Member(s2, 0), fn.Add(x, fn.Convert(fn.Load(fn.Member(pc, 0)), f32)))
+	code, m := build(t, b)
+
+	for _, st := range []*Type{ubo, ssbo, push} {
+		if n := decorations(m, st.id, spirv.DecorationBlock); n != 1 {
+			t.Errorf("%s decorated Block %d times, want 1", st, n)
+		}
+	}
+	offsets := map[uint32]int{}
+	for _, inst := range find(m, uint32(spirv.OpMemberDecorate)) {
+		if inst.Operands[0] == ssbo.id && inst.Operands[2] == spirv.DecorationOffset {
+			offsets[inst.Operands[1]]++
+		}
+	}
+	for member, n := range offsets {
+		if n != 1 {
+			t.Errorf("ssbo member %d has %d Offset decorations, want 1", member, n)
+		}
+	}
+	dis, err := spirv.Disassemble(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(dis, " Block\n"); n != 3 {
+		t.Errorf("disassembly has %d Block decorations, want 3", n)
+	}
+
+	r, err := spirv.Reflect(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Bindings) != 3 {
+		t.Fatalf("%d bindings, want 3", len(r.Bindings))
+	}
+	tests := []struct {
+		name    string
+		block   *spirv.Block
+		offsets []uint32
+		strides map[int]uint32 // member -> array stride
+		size    uint32
+	}{
+		{"std140", r.Bindings[0].Block, []uint32{0, 16, 28, 32, 80}, map[int]uint32{3: 16}, 88},
+		{"std430", r.Bindings[1].Block, []uint32{0, 16, 28, 32, 40, 48}, map[int]uint32{3: 4, 5: 16}, 48},
+		{"push", r.PushConstants, []uint32{0, 16}, nil, 32},
+	}
+	for _, tt := range tests {
+		if tt.block == nil {
+			t.Errorf("%s: no block", tt.name)
+			continue
+		}
+		var got []uint32
+		for i, mem := range tt.block.Members {
+			got = append(got, mem.Offset)
+			if want, ok := tt.strides[i]; ok && mem.Type.Stride != want {
+				t.Errorf("%s: member %d stride %d, want %d", tt.name, i, mem.Type.Stride, want)
+			}
+		}
+		if !slices.Equal(got, tt.offsets) {
+			t.Errorf("%s: offsets %v, want %v", tt.name, got, tt.offsets)
+		}
+		if tt.block.Size != tt.size {
+			t.Errorf("%s: size %d, want %d", tt.name, tt.block.Size, tt.size)
+		}
+	}
+	if !r.Bindings[1].ReadOnly || r.Bindings[2].ReadOnly {
+		t.Errorf("read-only = %v, %v, want true, false", r.Bindings[1].ReadOnly, r.Bindings[2].ReadOnly)
+	}
+	if r.Bindings[0].Type != vk.DescriptorUniformBuffer {
+		t.Errorf("binding 0 type %d, want uniform buffer", r.Bindings[0].Type)
+	}
+}
+
+func TestWrappedBlockDecoratedOnce(t *testing.T) {
+	b := New()
+	arr := b.RuntimeArray(b.Float32())
+	x := b.StorageBuffer(0, 0, arr, true)
+	y := b.StorageBuffer(0, 1, arr, false)
+	fn := b.Compute("main", 1, 1, 1)
+	zero := b.ConstUint32(0)
+	fn.Store(fn.Index(y, zero), fn.Load(fn.Index(x, zero)))
+	_, m := build(t, b)
+	if n := len(find(m, uint32(spirv.OpDecorate))); n == 0 {
+		t.Fatal("no decorations")
+	}
+	blocks := 0
+	for _, inst := range find(m, uint32(spirv.OpDecorate)) {
+		if inst.Operands[1] == spirv.DecorationBlock {
+			blocks++
+		}
+	}
+	if n := len(find(m, uint32(spirv.OpTypeStruct))); blocks != n {
+		t.Errorf("%d Block decorations for %d structs", blocks, n)
+	}
+	if strides := decorations(m, arr.id, spirv.DecorationArrayStride); strides != 1 {
+		t.Errorf("runtime array has %d ArrayStride decorations, want 1", strides)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(b *Builder)
+		err   string
+	}{
+		{"no entry point", func(b *Builder) {}, "no entry point"},
+		{"break outside loop", func(b *Builder) { b.Compute("main", 1, 1, 1).Break() }, "Break outside a loop"},
+		{"mixed layouts", func(b *Builder) {
+			arr := b.Array(b.Float32(), 4)
+			b.UniformBuffer(0, 0, b.Struct(arr))
+			b.StorageBuffer(0, 1, b.Struct(arr), true)
+			b.Compute("main", 1, 1, 1)
+		}, "both std140 and std430"},
+		{"float condition", func(b *Builder) {
+			b.Compute("main", 1, 1, 1).If(b.ConstFloat32(1), func() {})
+		}, "want bool"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New()
+			tt.build(b)
+			_, err := b.Build()
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("err = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
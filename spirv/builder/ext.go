@@ -0,0 +1,159 @@
+package builder
+
+// GLSL.std.450 extended instructions the helpers below emit. Ext takes any
+// instruction number from the GLSL.std.450 specification.
+const (
+	glslFAbs           = 4
+	glslSAbs           = 5
+	glslFloor          = 8
+	glslCeil           = 9
+	glslFract          = 10
+	glslSin            = 13
+	glslCos            = 14
+	glslPow            = 26
+	glslExp            = 27
+	glslLog            = 28
+	glslExp2           = 29
+	glslLog2           = 30
+	glslSqrt           = 31
+	glslInverseSqrt    = 32
+	glslFMin           = 37
+	glslUMin           = 38
+	glslSMin           = 39
+	glslFMax           = 40
+	glslUMax           = 41
+	glslSMax           = 42
+	glslFClamp         = 43
+	glslUClamp         = 44
+	glslSClamp         = 45
+	glslFMix           = 46
+	glslFma            = 50
+	glslPackUnorm4x8   = 55
+	glslPackHalf2x16   = 58
+	glslUnpackHalf2x16 = 62
+	glslUnpackUnorm4x8 = 64
+)
+
+// Ext emits GLSL.std.450 instruction inst with result type t.
+func (f *Func) Ext(inst uint32, t *Type, args ...Value) Value {
+	if !f.check(args...) {
+		return Value{}
+	}
+	if t == nil {
+		f.b.fail("Ext %d with nil result type", inst)
+		return Value{}
+	}
+	ops := []uint32{f.b.glslSet(), inst}
+	for _, a := range args {
+		ops = append(ops, a.id)
+	}
+	return f.result(t, opExtInst, ops...)
+}
+
+// numeric emits a GLSL.std.450 instruction whose operands and result share
+// one type, choosing the float, signed, or unsigned variant; 0 marks a
+// variant that does not exist.
+func (f *Func) numeric(name string, fi, si, ui uint32, args ...Value) Value {
+	if !f.check(args...) {
+		return Value{}
+	}
+	t := args[0].t
+	for _, a := range args[1:] {
+		if a.t != t {
+			f.b.fail("%s of %s and %s", name, t, a.t)
+			return Value{}
+		}
+	}
+	inst := ui
+	switch {
+	case t.IsFloat():
+		inst = fi
+	case t.IsSigned():
+		inst = si
+	case !t.IsInt():
+		inst = 0
+	}
+	if inst == 0 {
+		f.b.fail("%s of %s", name, t)
+		return Value{}
+	}
+	return f.Ext(inst, t, args...)
+}
+
+// Abs returns |a| of a float or signed integer.
+func (f *Func) Abs(a Value) Value { return f.numeric("Abs", glslFAbs, glslSAbs, 0, a) }
+
+// Min returns the lesser of a and c.
+func (f *Func) Min(a, c Value) Value { return f.numeric("Min", glslFMin, glslSMin, glslUMin, a, c) }
+
+// Max returns the greater of a and c.
+func (f *Func) Max(a, c Value) Value { return f.numeric("Max", glslFMax, glslSMax, glslUMax, a, c) }
+
+// Clamp returns x limited to [lo, hi].
+func (f *Func) Clamp(x, lo, hi Value) Value {
+	return f.numeric("Clamp", glslFClamp, glslSClamp, glslUClamp, x, lo, hi)
+}
+
+// Floor rounds a float down.
+func (f *Func) Floor(a Value) Value { return f.numeric("Floor", glslFloor, 0, 0, a) }
+
+// Ceil rounds a float up.
+func (f *Func) Ceil(a Value) Value { return f.numeric("Ceil", glslCeil, 0, 0, a) }
+
+// Fract returns a - Floor(a).
+func (f *Func) Fract(a Value) Value { return f.numeric("Fract", glslFract, 0, 0, a) }
+
+// Sqrt returns the square root of a float.
+func (f *Func) Sqrt(a Value) Value { return f.numeric("Sqrt", glslSqrt, 0, 0, a) }
+
+// InverseSqrt returns 1 / Sqrt(a).
+func (f *Func) InverseSqrt(a Value) Value { return f.numeric("InverseSqrt", glslInverseSqrt, 0, 0, a) }
+
+// Sin returns the sine of a, in radians.
+func (f *Func) Sin(a Value) Value { return f.numeric("Sin", glslSin, 0, 0, a) }
+
+// Cos returns the cosine of a, in radians.
+func (f *Func) Cos(a Value) Value { return f.numeric("Cos", glslCos, 0, 0, a) }
+
+// Pow returns a raised to the power c.
+func (f *Func) Pow(a, c Value) Value { return f.numeric("Pow", glslPow, 0, 0, a, c) }
+
+// Exp returns e raised to the power a.
+func (f *Func) Exp(a Value) Value { return f.numeric("Exp", glslExp, 0, 0, a) }
+
+// Exp2 returns 2 raised to the power a.
+func (f *Func) Exp2(a Value) Value { return f.numeric("Exp2", glslExp2, 0, 0, a) }
+
+// Log returns the natural logarithm of a.
+func (f *Func) Log(a Value) Value { return f.numeric("Log", glslLog, 0, 0, a) }
+
+// Log2 returns the base-2 logarithm of a.
+func (f *Func) Log2(a Value) Value { return f.numeric("Log2", glslLog2, 0, 0, a) }
+
+// Mix returns the linear blend a*(1-t) + c*t.
+func (f *Func) Mix(a, c, t Value) Value { return f.numeric("Mix", glslFMix, 0, 0, a, c, t) }
+
+// Fma returns a*c + d.
+func (f *Func) Fma(a, c, d Value) Value { return f.numeric("Fma", glslFma, 0, 0, a, c, d) }
+
+// PackUnorm4x8 packs a vec4 of floats in [0, 1] into a uint, one byte per
+// component with the first in the low byte.
+func (f *Func) PackUnorm4x8(v Value) Value {
+	return f.Ext(glslPackUnorm4x8, f.b.Uint32(), v)
+}
+
+// UnpackUnorm4x8 unpacks a uint into a vec4 of floats in [0, 1].
+func (f *Func) UnpackUnorm4x8(v Value) Value {
+	return f.Ext(glslUnpackUnorm4x8, f.b.Vector(f.b.Float32(), 4), v)
+}
+
+// PackHalf2x16 packs a vec2 of floats into a uint as two halfs, the first in
+// the low 16 bits.
+func (f *Func) PackHalf2x16(v Value) Value {
+	return f.Ext(glslPackHalf2x16, f.b.Uint32(), v)
+}
+
+// UnpackHalf2x16 unpacks a uint holding two halfs into a vec2 of floats.
+func (f *Func) UnpackHalf2x16(v Value) Value {
+	return f.Ext(glslUnpackHalf2x16, f.b.Vector(f.b.Float32(), 2), v)
+}
@@ -0,0 +1,270 @@
+package builder
+
+import (
+	"sort"
+
+	"github.com/christerso/vulkan-go/spirv"
+)
+
+// Func is the body of a compute entry point. Its methods append
+// instructions to the current block; the control-flow methods open and close
+// blocks around the Go functions they are given.
+type Func struct {
+	b     *Builder
+	id    uint32
+	name  string
+	local [3]uint32
+
+	vars       []uint32 // Function-storage OpVariables, hoisted to the entry block
+	body       []uint32
+	terminated bool            // the current block has its terminator
+	loops      []loop          // enclosing loops, innermost last
+	inputs     map[uint32]bool // Input variables used, for the entry point interface
+}
+
+// loop holds the branch targets of an enclosing loop.
+type loop struct {
+	merge, cont uint32
+}
+
+// Compute adds a GLCompute entry point with the given workgroup size and
+// returns its body to fill in.
+func (b *Builder) Compute(name string, x, y, z uint32) *Func {
+	fnType := b.function()
+	f := &Func{b: b, id: b.id(), name: name, local: [3]uint32{max(x, 1), max(y, 1), max(z, 1)}, inputs: map[uint32]bool{}}
+	b.funcs = append(b.funcs, f)
+	emit(&f.body, uint32(opFunction), b.void().id, f.id, 0, fnType.id)
+	emit(&f.body, opLabel, b.id())
+	b.Name(Value{id: f.id}, name)
+	return f
+}
+
+// emit appends an instruction to the current block, opening an unreachable
+// block first if the current one is already terminated.
+func (f *Func) emit(op uint32, operands ...uint32) {
+	if f.terminated {
+		emit(&f.body, opLabel, f.b.id())
+		f.terminated = false
+	}
+	emit(&f.body, op, operands...)
+}
+
+// result emits an instruction with a result of type t and returns it.
+func (f *Func) result(t *Type, op uint32, operands ...uint32) Value {
+	v := Value{id: f.b.id(), t: t}
+	f.emit(op, append([]uint32{t.id, v.id}, operands...)...)
+	return v
+}
+
+// terminate emits a block terminator.
+func (f *Func) terminate(op uint32, operands ...uint32) {
+	f.emit(op, operands...)
+	f.terminated = true
+}
+
+// label starts block id.
+func (f *Func) label(id uint32) {
+	emit(&f.body, opLabel, id)
+	f.terminated = false
+}
+
+// branch ends the current block with a branch to target, unless it has
+// already ended (with Return, Break, or Continue).
+func (f *Func) branch(target uint32) {
+	if !f.terminated {
+		f.terminate(opBranch, target)
+	}
+}
+
+// interfaceIDs lists the Input variables the function uses, in id order.
+func (f *Func) interfaceIDs() []uint32 {
+	ids := make([]uint32, 0, len(f.inputs))
+	for id := range f.inputs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// finish returns the function's instructions with its local variables
+// hoisted into the entry block and a final return.
+func (f *Func) finish() []uint32 {
+	if !f.terminated {
+		emit(&f.body, opReturn)
+	}
+	// The entry block's OpLabel follows the 5-word OpFunction.
+	const head = 5 + 2
+	w := append([]uint32{}, f.body[:head]...)
+	w = append(w, f.vars...)
+	w = append(w, f.body[head:]...)
+	emit(&w, opFunctionEnd)
+	return w
+}
+
+// Var declares a function-local variable of type t and returns a pointer to
+// it, for values that change across loop iterations or branches.
+func (f *Func) Var(t *Type) Value {
+	if t == nil {
+		f.b.fail("Var of nil type")
+		return Value{}
+	}
+	p := f.b.pointer(spirv.StorageFunction, t)
+	v := Value{id: f.b.id(), t: p}
+	emit(&f.vars, uint32(spirv.OpVariable), p.id, v.id, uint32(spirv.StorageFunction))
+	return v
+}
+
+// builtin loads a built-in input.
+func (f *Func) builtin(kind uint32, t *Type) Value {
+	v := f.b.builtin(kind, t)
+	f.inputs[v.id] = true
+	return f.Load(v)
+}
+
+// GlobalInvocationID returns the uvec3 index of the invocation in the
+// dispatch.
+func (f *Func) GlobalInvocationID() Value {
+	return f.builtin(builtInGlobalInvocationID, f.b.Vector(f.b.Uint32(), 3))
+}
+
+// LocalInvocationID returns the uvec3 index of the invocation in its
+// workgroup.
+func (f *Func) LocalInvocationID() Value {
+	return f.builtin(builtInLocalInvocationID, f.b.Vector(f.b.Uint32(), 3))
+}
+
+// LocalInvocationIndex returns the flattened uint index of the invocation in
+// its workgroup.
+func (f *Func) LocalInvocationIndex() Value {
+	return f.builtin(builtInLocalInvocationIndex, f.b.Uint32())
+}
+
+// WorkgroupID returns the uvec3 index of the workgroup in the dispatch.
+func (f *Func) WorkgroupID() Value {
+	return f.builtin(builtInWorkgroupID, f.b.Vector(f.b.Uint32(), 3))
+}
+
+// NumWorkgroups returns the uvec3 workgroup count of the dispatch.
+func (f *Func) NumWorkgroups() Value {
+	return f.builtin(builtInNumWorkgroups, f.b.Vector(f.b.Uint32(), 3))
+}
+
+// WorkgroupSize returns the workgroup size given to Compute.
+func (f *Func) WorkgroupSize() [3]uint32 { return f.local }
+
+// If runs then when cond is true.
+func (f *Func) If(cond Value, then func()) {
+	f.IfElse(cond, then, nil)
+}
+
+// IfElse runs then when cond is true and els, which may be nil, otherwise.
+func (f *Func) IfElse(cond Value, then, els func()) {
+	if !f.check(cond) {
+		return
+	}
+	if !cond.t.IsBool() || cond.t.kind == kindVector {
+		f.b.fail("condition of type %s, want bool", cond.t)
+		return
+	}
+	thenID, merge := f.b.id(), f.b.id()
+	elseID := merge
+	if els != nil {
+		elseID = f.b.id()
+	}
+	f.emit(opSelectionMerge, merge, 0)
+	f.terminate(opBranchConditional, cond.id, thenID, elseID)
+	f.label(thenID)
+	then()
+	f.branch(merge)
+	if els != nil {
+		f.label(elseID)
+		els()
+		f.branch(merge)
+	}
+	f.label(merge)
+}
+
+// While runs body as long as cond, evaluated before each iteration, returns
+// true.
+func (f *Func) While(cond func() Value, body func()) {
+	f.loop(cond, body, nil)
+}
+
+// For runs body(i) for i from start up to but excluding end, both integers
+// of the same type.
+func (f *Func) For(start, end Value, body func(i Value)) {
+	if !f.check(start, end) {
+		return
+	}
+	if !start.t.IsInt() || start.t != end.t {
+		f.b.fail("For over %s and %s, want matching integers", start.t, end.t)
+		return
+	}
+	i := f.Var(start.t)
+	f.Store(i, start)
+	one := f.b.Const(start.t, 1)
+	f.loop(
+		func() Value { return f.Less(f.Load(i), end) },
+		func() { body(f.Load(i)) },
+		func() { f.Store(i, f.Add(f.Load(i), one)) },
+	)
+}
+
+// loop emits a structured loop: header, condition check, body, and a
+// continue block running cont.
+func (f *Func) loop(cond func() Value, body, cont func()) {
+	header, check, bodyID, contID, merge := f.b.id(), f.b.id(), f.b.id(), f.b.id(), f.b.id()
+	f.branch(header)
+	f.label(header)
+	f.emit(opLoopMerge, merge, contID, 0)
+	f.terminate(opBranch, check)
+	f.label(check)
+	c := cond()
+	if !f.check(c) || !c.t.IsBool() || c.t.kind == kindVector {
+		f.b.fail("loop condition is not a bool")
+		c = f.b.ConstBool(false)
+	}
+	f.terminate(opBranchConditional, c.id, bodyID, merge)
+	f.label(bodyID)
+	f.loops = append(f.loops, loop{merge: merge, cont: contID})
+	body()
+	f.loops = f.loops[:len(f.loops)-1]
+	f.branch(contID)
+	f.label(contID)
+	if cont != nil {
+		cont()
+	}
+	f.terminate(opBranch, header)
+	f.label(merge)
+}
+
+// Break leaves the innermost loop.
+func (f *Func) Break() {
+	if len(f.loops) == 0 {
+		f.b.fail("Break outside a loop")
+		return
+	}
+	f.terminate(opBranch, f.loops[len(f.loops)-1].merge)
+}
+
+// Continue starts the next iteration of the innermost loop.
+func (f *Func) Continue() {
+	if len(f.loops) == 0 {
+		f.b.fail("Continue outside a loop")
+		return
+	}
+	f.terminate(opBranch, f.loops[len(f.loops)-1].cont)
+}
+
+// Return ends the invocation.
+func (f *Func) Return() {
+	f.terminate(opReturn)
+}
+
+// Barrier synchronizes the workgroup: every invocation waits until all have
+// reached it, and their writes to Shared variables become visible.
+func (f *Func) Barrier() {
+	b := f.b
+	const semantics = 0x8 | 0x100 // AcquireRelease | WorkgroupMemory
+	f.emit(opControlBarrier, b.ConstUint32(scopeWorkgroup).id, b.ConstUint32(scopeWorkgroup).id, b.ConstUint32(semantics).id)
+}
@@ -0,0 +1,439 @@
+package builder
+
+import "github.com/christerso/vulkan-go/spirv"
+
+// check records an error if any value is invalid, and reports whether all
+// are valid.
+func (f *Func) check(vs ...Value) bool {
+	for _, v := range vs {
+		if v.t == nil {
+			f.b.fail("use of an invalid value")
+			return false
+		}
+	}
+	return true
+}
+
+// Load reads through pointer p.
+func (f *Func) Load(p Value) Value {
+	if !f.check(p) {
+		return Value{}
+	}
+	if p.t.kind != kindPointer {
+		f.b.fail("Load from %s, want a pointer", p.t)
+		return Value{}
+	}
+	return f.result(p.t.elem, opLoad, p.id)
+}
+
+// Store writes v through pointer p.
+func (f *Func) Store(p, v Value) {
+	if !f.check(p, v) {
+		return
+	}
+	if p.t.kind != kindPointer || p.t.elem != v.t {
+		f.b.fail("Store of %s through %s", v.t, p.t)
+		return
+	}
+	f.emit(opStore, p.id, v.id)
+}
+
+// access emits an access chain from pointer p through one index, into
+// element type elem.
+func (f *Func) access(p Value, elem *Type, index uint32) Value {
+	ids := []uint32{p.id}
+	if f.b.wrapped[p.id] {
+		ids = append(ids, f.b.ConstUint32(0).id)
+	}
+	return f.result(f.b.pointer(p.t.storage, elem), opAccessChain, append(ids, index)...)
+}
+
+// pointee returns the type p points at, seeing through the block a non-struct
+// resource type is wrapped in.
+func (f *Func) pointee(p Value) *Type {
+	t := p.t.elem
+	if f.b.wrapped[p.id] {
+		t = t.members[0]
+	}
+	return t
+}
+
+// Index returns a pointer to element i of the array or vector p points at.
+func (f *Func) Index(p, i Value) Value {
+	if !f.check(p, i) {
+		return Value{}
+	}
+	if p.t.kind != kindPointer || !i.t.IsInt() || i.t.kind == kindVector {
+		f.b.fail("Index of %s by %s", p.t, i.t)
+		return Value{}
+	}
+	t := f.pointee(p)
+	switch t.kind {
+	case kindArray, kindRuntimeArray, kindVector:
+	default:
+		f.b.fail("Index into %s", t)
+		return Value{}
+	}
+	return f.access(p, t.elem, i.id)
+}
+
+// Member returns a pointer to member n of the struct p points at.
+func (f *Func) Member(p Value, n uint32) Value {
+	if !f.check(p) {
+		return Value{}
+	}
+	if p.t.kind != kindPointer {
+		f.b.fail("Member of %s", p.t)
+		return Value{}
+	}
+	t := f.pointee(p)
+	if t.kind != kindStruct || int(n) >= len(t.members) {
+		f.b.fail("no member %d in %s", n, t)
+		return Value{}
+	}
+	return f.access(p, t.members[n], f.b.ConstUint32(n).id)
+}
+
+// Extract returns component or member n of vector or struct v.
+func (f *Func) Extract(v Value, n uint32) Value {
+	if !f.check(v) {
+		return Value{}
+	}
+	var t *Type
+	switch {
+	case (v.t.kind == kindVector || v.t.kind == kindArray) && n < v.t.len:
+		t = v.t.elem
+	case v.t.kind == kindStruct && int(n) < len(v.t.members):
+		t = v.t.members[n]
+	default:
+		f.b.fail("Extract %d from %s", n, v.t)
+		return Value{}
+	}
+	return f.result(t, opCompositeExtract, v.id, n)
+}
+
+// Construct builds a vector of type t from scalars or smaller vectors.
+func (f *Func) Construct(t *Type, parts ...Value) Value {
+	if !f.check(parts...) {
+		return Value{}
+	}
+	ids := make([]uint32, len(parts))
+	for i, p := range parts {
+		ids[i] = p.id
+	}
+	return f.result(t, opCompositeConstruct, ids...)
+}
+
+// Splat returns a vector of n copies of scalar v.
+func (f *Func) Splat(v Value, n uint32) Value {
+	if !f.check(v) {
+		return Value{}
+	}
+	parts := make([]Value, n)
+	for i := range parts {
+		parts[i] = v
+	}
+	return f.Construct(f.b.Vector(v.t, n), parts...)
+}
+
+// binary emits an arithmetic instruction on two operands of one type,
+// choosing the float, signed, or unsigned opcode.
+func (f *Func) binary(name string, a, c Value, fop, sop, uop uint32) Value {
+	if !f.check(a, c) {
+		return Value{}
+	}
+	if a.t != c.t {
+		f.b.fail("%s of %s and %s", name, a.t, c.t)
+		return Value{}
+	}
+	op := uop
+	switch {
+	case a.t.IsFloat():
+		op = fop
+	case a.t.IsSigned():
+		op = sop
+	case !a.t.IsInt():
+		op = 0
+	}
+	if op == 0 {
+		f.b.fail("%s of %s", name, a.t)
+		return Value{}
+	}
+	return f.result(a.t, op, a.id, c.id)
+}
+
+// Add returns a + c.
+func (f *Func) Add(a, c Value) Value { return f.binary("Add", a, c, opFAdd, opIAdd, opIAdd) }
+
+// Sub returns a - c.
+func (f *Func) Sub(a, c Value) Value { return f.binary("Sub", a, c, opFSub, opISub, opISub) }
+
+// Mul returns a * c.
+func (f *Func) Mul(a, c Value) Value { return f.binary("Mul", a, c, opFMul, opIMul, opIMul) }
+
+// Div returns a / c.
+func (f *Func) Div(a, c Value) Value { return f.binary("Div", a, c, opFDiv, opSDiv, opUDiv) }
+
+// Rem returns the remainder of a / c, with the sign of a as Go's %.
+func (f *Func) Rem(a, c Value) Value { return f.binary("Rem", a, c, opFRem, opSRem, opUMod) }
+
+// bitwise emits an integer-only instruction on two operands.
+func (f *Func) bitwise(name string, a, c Value, op uint32) Value {
+	if !f.check(a, c) {
+		return Value{}
+	}
+	if !a.t.IsInt() || !c.t.IsInt() {
+		f.b.fail("%s of %s and %s", name, a.t, c.t)
+		return Value{}
+	}
+	return f.result(a.t, op, a.id, c.id)
+}
+
+// And returns the bitwise a & c.
+func (f *Func) And(a, c Value) Value { return f.bitwise("And", a, c, opBitwiseAnd) }
+
+// Or returns the bitwise a | c.
+func (f *Func) Or(a, c Value) Value { return f.bitwise("Or", a, c, opBitwiseOr) }
+
+// Xor returns the bitwise a ^ c.
+func (f *Func) Xor(a, c Value) Value { return f.bitwise("Xor", a, c, opBitwiseXor) }
+
+// Shl returns a << n.
+func (f *Func) Shl(a, n Value) Value { return f.bitwise("Shl", a, n, opShiftLeftLogical) }
+
+// Shr returns a >> n, arithmetic for signed a and logical for unsigned.
+func (f *Func) Shr(a, n Value) Value {
+	if f.check(a) && a.t.IsSigned() {
+		return f.bitwise("Shr", a, n, opShiftRightArithmetic)
+	}
+	return f.bitwise("Shr", a, n, opShiftRightLogical)
+}
+
+// Neg returns -a.
+func (f *Func) Neg(a Value) Value {
+	if !f.check(a) {
+		return Value{}
+	}
+	switch {
+	case a.t.IsFloat():
+		return f.result(a.t, opFNegate, a.id)
+	case a.t.IsInt():
+		return f.result(a.t, opSNegate, a.id)
+	}
+	f.b.fail("Neg of %s", a.t)
+	return Value{}
+}
+
+// Not returns the bitwise complement of an integer or the negation of a
+// boolean.
+func (f *Func) Not(a Value) Value {
+	if !f.check(a) {
+		return Value{}
+	}
+	switch {
+	case a.t.IsInt():
+		return f.result(a.t, opNot, a.id)
+	case a.t.IsBool():
+		return f.result(a.t, opLogicalNot, a.id)
+	}
+	f.b.fail("Not of %s", a.t)
+	return Value{}
+}
+
+// LogicalAnd returns a && c for booleans.
+func (f *Func) LogicalAnd(a, c Value) Value { return f.logical("LogicalAnd", a, c, opLogicalAnd) }
+
+// LogicalOr returns a || c for booleans.
+func (f *Func) LogicalOr(a, c Value) Value { return f.logical("LogicalOr", a, c, opLogicalOr) }
+
+func (f *Func) logical(name string, a, c Value, op uint32) Value {
+	if !f.check(a, c) {
+		return Value{}
+	}
+	if !a.t.IsBool() || a.t != c.t {
+		f.b.fail("%s of %s and %s", name, a.t, c.t)
+		return Value{}
+	}
+	return f.result(a.t, op, a.id, c.id)
+}
+
+// compare emits a comparison, choosing the float, signed, or unsigned
+// opcode. The result is a bool, or a bool vector for vector operands.
+func (f *Func) compare(name string, a, c Value, fop, sop, uop, bop uint32) Value {
+	if !f.check(a, c) {
+		return Value{}
+	}
+	if a.t != c.t {
+		f.b.fail("%s of %s and %s", name, a.t, c.t)
+		return Value{}
+	}
+	op := uop
+	switch {
+	case a.t.IsFloat():
+		op = fop
+	case a.t.IsSigned():
+		op = sop
+	case a.t.IsBool():
+		op = bop
+	}
+	if op == 0 {
+		f.b.fail("%s of %s", name, a.t)
+		return Value{}
+	}
+	rt := f.b.Bool()
+	if a.t.kind == kindVector {
+		rt = f.b.Vector(rt, a.t.len)
+	}
+	return f.result(rt, op, a.id, c.id)
+}
+
+// Equal returns a == c.
+func (f *Func) Equal(a, c Value) Value {
+	return f.compare("Equal", a, c, opFOrdEqual, opIEqual, opIEqual, opLogicalEqual)
+}
+
+// NotEqual returns a != c.
+func (f *Func) NotEqual(a, c Value) Value {
+	return f.compare("NotEqual", a, c, opFOrdNotEqual, opINotEqual, opINotEqual, opLogicalNotEqual)
+}
+
+// Less returns a < c.
+func (f *Func) Less(a, c Value) Value {
+	return f.compare("Less", a, c, opFOrdLessThan, opSLessThan, opULessThan, 0)
+}
+
+// LessEqual returns a <= c.
+func (f *Func) LessEqual(a, c Value) Value {
+	return f.compare("LessEqual", a, c, opFOrdLessThanEqual, opSLessThanEqual, opULessThanEqual, 0)
+}
+
+// Greater returns a > c.
+func (f *Func) Greater(a, c Value) Value {
+	return f.compare("Greater", a, c, opFOrdGreaterThan, opSGreaterThan, opUGreaterThan, 0)
+}
+
+// GreaterEqual returns a >= c.
+func (f *Func) GreaterEqual(a, c Value) Value {
+	return f.compare("GreaterEqual", a, c, opFOrdGreaterThanEqual, opSGreaterThanEqual, opUGreaterThanEqual, 0)
+}
+
+// Select returns a if cond is true and c otherwise, component-wise for a
+// bool vector cond.
+func (f *Func) Select(cond, a, c Value) Value {
+	if !f.check(cond, a, c) {
+		return Value{}
+	}
+	if !cond.t.IsBool() || a.t != c.t {
+		f.b.fail("Select on %s between %s and %s", cond.t, a.t, c.t)
+		return Value{}
+	}
+	return f.result(a.t, opSelect, cond.id, a.id, c.id)
+}
+
+// Convert converts v to numeric type t, value-preserving as far as t
+// allows: float to integer truncates, and integers change width with sign or
+// zero extension by the source's signedness. Vectors convert per component.
+func (f *Func) Convert(v Value, t *Type) Value {
+	if !f.check(v) {
+		return Value{}
+	}
+	if t == nil || v.t.kind == kindVector != (t.kind == kindVector) || (t.kind == kindVector && t.len != v.t.len) {
+		f.b.fail("Convert %s to %v", v.t, t)
+		return Value{}
+	}
+	from, to := v.t.scalar(), t.scalar()
+	var op uint32
+	switch {
+	case from == to:
+		return v
+	case from.kind == kindFloat && to.kind == kindFloat:
+		op = opFConvert
+	case from.kind == kindFloat && to.kind == kindInt && to.signed:
+		op = opConvertFToS
+	case from.kind == kindFloat && to.kind == kindInt:
+		op = opConvertFToU
+	case from.kind == kindInt && to.kind == kindFloat && from.signed:
+		op = opConvertSToF
+	case from.kind == kindInt && to.kind == kindFloat:
+		op = opConvertUToF
+	case from.kind == kindInt && to.kind == kindInt && from.width == to.width:
+		op = opBitcast
+	case from.kind == kindInt && to.kind == kindInt && from.signed:
+		// OpSConvert sign-extends or truncates into either signedness.
+		op = opSConvert
+	case from.kind == kindInt && to.kind == kindInt:
+		// OpUConvert zero-extends or truncates, but only into unsigned.
+		mid := f.b.Int(to.width, false)
+		if t.kind == kindVector {
+			mid = f.b.Vector(mid, t.len)
+		}
+		w := f.result(mid, opUConvert, v.id)
+		if mid == t {
+			return w
+		}
+		return f.result(t, opBitcast, w.id)
+	}
+	if op == 0 {
+		f.b.fail("Convert %s to %s", v.t, t)
+		return Value{}
+	}
+	return f.result(t, op, v.id)
+}
+
+// Bitcast reinterprets the bits of v as type t of the same total width.
+func (f *Func) Bitcast(v Value, t *Type) Value {
+	if !f.check(v) {
+		return Value{}
+	}
+	if t == nil || size(v.t, std430) != size(t, std430) {
+		f.b.fail("Bitcast %s to %v", v.t, t)
+		return Value{}
+	}
+	return f.result(t, opBitcast, v.id)
+}
+
+// atomic emits an atomic read-modify-write through pointer p with device
+// scope and relaxed ordering.
+func (f *Func) atomic(name string, p, v Value, op uint32) Value {
+	if !f.check(p, v) {
+		return Value{}
+	}
+	if p.t.kind != kindPointer || p.t.elem != v.t || !v.t.IsInt() || v.t.kind == kindVector {
+		f.b.fail("%s of %s through %s", name, v.t, p.t)
+		return Value{}
+	}
+	if v.t.width == 64 {
+		f.b.capability(capInt64Atomic)
+	}
+	scope := uint32(scopeDevice)
+	if p.t.storage == spirv.StorageWorkgroup {
+		scope = scopeWorkgroup
+	}
+	return f.result(v.t, op, p.id, f.b.ConstUint32(scope).id, f.b.ConstUint32(0).id, v.id)
+}
+
+// AtomicAdd adds v to the integer p points at and returns the old value.
+func (f *Func) AtomicAdd(p, v Value) Value { return f.atomic("AtomicAdd", p, v, opAtomicIAdd) }
+
+// AtomicExchange stores v through p and returns the old value.
+func (f *Func) AtomicExchange(p, v Value) Value {
+	return f.atomic("AtomicExchange", p, v, opAtomicExchange)
+}
+
+// AtomicMin stores the minimum of v and the integer p points at, and returns
+// the old value.
+func (f *Func) AtomicMin(p, v Value) Value {
+	if f.check(v) && v.t.IsSigned() {
+		return f.atomic("AtomicMin", p, v, opAtomicSMin)
+	}
+	return f.atomic("AtomicMin", p, v, opAtomicUMin)
+}
+
+// AtomicMax stores the maximum of v and the integer p points at, and returns
+// the old value.
+func (f *Func) AtomicMax(p, v Value) Value {
+	if f.check(v) && v.t.IsSigned() {
+		return f.atomic("AtomicMax", p, v, opAtomicSMax)
+	}
+	return f.atomic("AtomicMax", p, v, opAtomicUMax)
+}
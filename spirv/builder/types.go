@@ -0,0 +1,331 @@
+package builder
+
+import (
+	"fmt"
+
+	"github.com/christerso/vulkan-go/spirv"
+)
+
+type kind int
+
+const (
+	kindVoid kind = iota
+	kindBool
+	kindInt
+	kindFloat
+	kindVector
+	kindArray
+	kindRuntimeArray
+	kindStruct
+	kindPointer
+	kindFunction
+)
+
+// Type is a SPIR-V type declared in a Builder. Scalar, vector, array, and
+// pointer types are deduplicated; each Struct call declares a new type.
+type Type struct {
+	id      uint32
+	kind    kind
+	width   uint32 // bits of a scalar
+	signed  bool
+	elem    *Type // vector component, array element, pointee
+	len     uint32
+	members []*Type
+	storage spirv.StorageClass // of a pointer
+}
+
+// String returns a short description of t for error messages.
+func (t *Type) String() string {
+	switch t.kind {
+	case kindVoid:
+		return "void"
+	case kindBool:
+		return "bool"
+	case kindInt:
+		if t.signed {
+			return fmt.Sprintf("int%d", t.width)
+		}
+		return fmt.Sprintf("uint%d", t.width)
+	case kindFloat:
+		return fmt.Sprintf("float%d", t.width)
+	case kindVector:
+		return fmt.Sprintf("vec%d<%s>", t.len, t.elem)
+	case kindArray:
+		return fmt.Sprintf("[%d]%s", t.len, t.elem)
+	case kindRuntimeArray:
+		return "[]" + t.elem.String()
+	case kindStruct:
+		return fmt.Sprintf("struct#%d", t.id)
+	case kindPointer:
+		return "*" + t.elem.String()
+	}
+	return "func"
+}
+
+// scalar returns t, or its component type for a vector.
+func (t *Type) scalar() *Type {
+	if t.kind == kindVector {
+		return t.elem
+	}
+	return t
+}
+
+// IsFloat reports whether t is a float scalar or vector.
+func (t *Type) IsFloat() bool { return t.scalar().kind == kindFloat }
+
+// IsInt reports whether t is an integer scalar or vector.
+func (t *Type) IsInt() bool { return t.scalar().kind == kindInt }
+
+// IsSigned reports whether t is a signed integer scalar or vector.
+func (t *Type) IsSigned() bool { return t.IsInt() && t.scalar().signed }
+
+// IsBool reports whether t is a boolean scalar or vector.
+func (t *Type) IsBool() bool { return t.scalar().kind == kindBool }
+
+// declare returns the type with key, declaring it with decl on first use.
+func (b *Builder) declare(key string, t *Type, decl func(id uint32)) *Type {
+	if old, ok := b.types[key]; ok {
+		return old
+	}
+	t.id = b.id()
+	decl(t.id)
+	b.types[key] = t
+	return t
+}
+
+func (b *Builder) void() *Type {
+	return b.declare("void", &Type{kind: kindVoid}, func(id uint32) {
+		emit(&b.globals, uint32(spirv.OpTypeVoid), id)
+	})
+}
+
+// Bool returns the boolean type.
+func (b *Builder) Bool() *Type {
+	return b.declare("bool", &Type{kind: kindBool}, func(id uint32) {
+		emit(&b.globals, uint32(spirv.OpTypeBool), id)
+	})
+}
+
+// Int returns the integer type of width 32 or 64 bits. 64-bit integers need
+// the shaderInt64 device feature.
+func (b *Builder) Int(width uint32, signed bool) *Type {
+	if width != 32 && width != 64 {
+		b.fail("unsupported integer width %d", width)
+		width = 32
+	}
+	if width == 64 {
+		b.capability(capInt64)
+	}
+	t := &Type{kind: kindInt, width: width, signed: signed}
+	return b.declare(t.String(), t, func(id uint32) {
+		emit(&b.globals, uint32(spirv.OpTypeInt), id, width, boolWord(signed))
+	})
+}
+
+// Float returns the float type of width 32 or 64 bits. 64-bit floats need
+// the shaderFloat64 device feature.
+func (b *Builder) Float(width uint32) *Type {
+	if width != 32 && width != 64 {
+		b.fail("unsupported float width %d", width)
+		width = 32
+	}
+	if width == 64 {
+		b.capability(capFloat64)
+	}
+	t := &Type{kind: kindFloat, width: width}
+	return b.declare(t.String(), t, func(id uint32) {
+		emit(&b.globals, uint32(spirv.OpTypeFloat), id, width)
+	})
+}
+
+// Int32 returns the 32-bit signed integer type.
+func (b *Builder) Int32() *Type { return b.Int(32, true) }
+
+// Uint32 returns the 32-bit unsigned integer type.
+func (b *Builder) Uint32() *Type { return b.Int(32, false) }
+
+// Float32 returns the 32-bit float type.
+func (b *Builder) Float32() *Type { return b.Float(32) }
+
+// Vector returns the vector of n (2 to 4) components of scalar type elem.
+func (b *Builder) Vector(elem *Type, n uint32) *Type {
+	if elem == nil || elem.kind < kindBool || elem.kind > kindFloat || n < 2 || n > 4 {
+		b.fail("invalid vector of %d %v", n, elem)
+		return b.Float32()
+	}
+	return b.declare(fmt.Sprintf("v%d:%d", elem.id, n), &Type{kind: kindVector, elem: elem, len: n}, func(id uint32) {
+		emit(&b.globals, uint32(spirv.OpTypeVector), id, elem.id, n)
+	})
+}
+
+// Array returns the array of n elements of type elem.
+func (b *Builder) Array(elem *Type, n uint32) *Type {
+	if elem == nil || n == 0 {
+		b.fail("invalid array of %d %v", n, elem)
+		return b.Float32()
+	}
+	length := b.ConstUint32(n)
+	return b.declare(fmt.Sprintf("a%d:%d", elem.id, n), &Type{kind: kindArray, elem: elem, len: n}, func(id uint32) {
+		emit(&b.globals, uint32(spirv.OpTypeArray), id, elem.id, length.id)
+	})
+}
+
+// RuntimeArray returns the runtime-sized array of type elem, which may only
+// be the last member of a storage buffer.
+func (b *Builder) RuntimeArray(elem *Type) *Type {
+	if elem == nil {
+		b.fail("runtime array of nil type")
+		return b.Float32()
+	}
+	return b.declare(fmt.Sprintf("r%d", elem.id), &Type{kind: kindRuntimeArray, elem: elem}, func(id uint32) {
+		emit(&b.globals, uint32(spirv.OpTypeRuntimeArray), id, elem.id)
+	})
+}
+
+// Struct declares a new struct type with the given members.
+func (b *Builder) Struct(members ...*Type) *Type {
+	ids := make([]uint32, len(members))
+	for i, m := range members {
+		if m == nil {
+			b.fail("struct member %d of nil type", i)
+			return b.Float32()
+		}
+		ids[i] = m.id
+	}
+	t := &Type{id: b.id(), kind: kindStruct, members: members}
+	emit(&b.globals, uint32(spirv.OpTypeStruct), append([]uint32{t.id}, ids...)...)
+	return t
+}
+
+// NameMember attaches a debug name to member i of struct type t.
+func (b *Builder) NameMember(t *Type, i uint32, name string) {
+	emit(&b.debug, uint32(spirv.OpMemberName), append([]uint32{t.id, i}, literal(name)...)...)
+}
+
+func (b *Builder) pointer(sc spirv.StorageClass, elem *Type) *Type {
+	return b.declare(fmt.Sprintf("p%d:%d", sc, elem.id), &Type{kind: kindPointer, elem: elem, storage: sc}, func(id uint32) {
+		emit(&b.globals, uint32(spirv.OpTypePointer), id, uint32(sc), elem.id)
+	})
+}
+
+func (b *Builder) function() *Type {
+	void := b.void()
+	return b.declare("func", &Type{kind: kindFunction}, func(id uint32) {
+		emit(&b.globals, opTypeFunction, id, void.id)
+	})
+}
+
+func boolWord(v bool) uint32 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// layoutRule is an explicit memory layout standard.
+type layoutRule int
+
+const (
+	std430 layoutRule = iota + 1
+	std140
+)
+
+// layout decorates t and the types it contains with Offset and ArrayStride
+// under rule. A type laid out once cannot be laid out under the other rule,
+// since decorations belong to the type.
+func (b *Builder) layout(t *Type, rule layoutRule) {
+	switch t.kind {
+	case kindArray, kindRuntimeArray, kindStruct:
+	default:
+		return
+	}
+	if prev, ok := b.laidOut[t.id]; ok {
+		if prev != rule {
+			b.fail("%s is used in both std140 and std430 blocks", t)
+		}
+		return
+	}
+	b.laidOut[t.id] = rule
+	switch t.kind {
+	case kindArray, kindRuntimeArray:
+		b.layout(t.elem, rule)
+		emit(&b.annot, uint32(spirv.OpDecorate), t.id, spirv.DecorationArrayStride, stride(t.elem, rule))
+	case kindStruct:
+		var offset uint32
+		for i, m := range t.members {
+			b.layout(m, rule)
+			offset = roundUp(offset, align(m, rule))
+			emit(&b.annot, uint32(spirv.OpMemberDecorate), t.id, uint32(i), spirv.DecorationOffset, offset)
+			if m.kind == kindRuntimeArray && i != len(t.members)-1 {
+				b.fail("runtime array must be the last struct member")
+			}
+			offset += size(m, rule)
+		}
+	}
+}
+
+// align returns the base alignment of t under rule.
+func align(t *Type, rule layoutRule) uint32 {
+	switch t.kind {
+	case kindBool:
+		return 4
+	case kindInt, kindFloat:
+		return t.width / 8
+	case kindVector:
+		n := t.len
+		if n == 3 {
+			n = 4
+		}
+		return n * align(t.elem, rule)
+	case kindArray, kindRuntimeArray:
+		a := align(t.elem, rule)
+		if rule == std140 {
+			a = roundUp(a, 16)
+		}
+		return a
+	case kindStruct:
+		a := uint32(1)
+		for _, m := range t.members {
+			a = max(a, align(m, rule))
+		}
+		if rule == std140 {
+			a = roundUp(a, 16)
+		}
+		return a
+	}
+	return 1
+}
+
+// size returns the size of t under rule; 0 for a runtime array.
+func size(t *Type, rule layoutRule) uint32 {
+	switch t.kind {
+	case kindBool:
+		return 4
+	case kindInt, kindFloat:
+		return t.width / 8
+	case kindVector:
+		return t.len * size(t.elem, rule)
+	case kindArray:
+		return t.len * stride(t.elem, rule)
+	case kindStruct:
+		var offset uint32
+		for _, m := range t.members {
+			offset = roundUp(offset, align(m, rule)) + size(m, rule)
+		}
+		return roundUp(offset, align(t, rule))
+	}
+	return 0
+}
+
+// stride returns the array stride of elements of type elem under rule.
+func stride(elem *Type, rule layoutRule) uint32 {
+	a := align(elem, rule)
+	if rule == std140 {
+		a = roundUp(a, 16)
+	}
+	return roundUp(size(elem, rule), a)
+}
+
+func roundUp(v, a uint32) uint32 {
+	return (v + a - 1) / a * a
+}
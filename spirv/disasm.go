@@ -0,0 +1,487 @@
+package spirv
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Operand kinds in opInfo.operands, separated by spaces. A trailing * repeats
+// the kind over the remaining operands; every operand is optional, so an
+// instruction may stop early.
+//
+//	T   result type id      R   result id
+//	i   id                  l   literal number
+//	s   literal string      c   literal typed by the result type
+//	o   opcode literal      x   extended instruction number
+//	w   switch literal/label pair
+//
+// Any other word names an enum (enumNames) or mask (maskNames) operand.
+
+// DisassembleOptions tunes Module.Disassemble.
+type DisassembleOptions struct {
+	// RawIDs prints every id as %N instead of resolving names from OpName and
+	// deriving them for types and constants.
+	RawIDs bool
+}
+
+// Disassemble returns code in the standard SPIR-V textual form, with ids named
+// after OpName debug names and, for types and constants, after their
+// definitions (%v4float, %uint_3), as spirv-dis prints them.
+func Disassemble(code []byte) (string, error) {
+	m, err := Parse(code)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := m.Disassemble(&b, DisassembleOptions{}); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// typeDesc is what the disassembler knows of a scalar type, for printing
+// typed literals.
+type typeDesc struct {
+	op     Op
+	width  uint32
+	signed bool
+}
+
+// disassembler holds the per-module state of Disassemble.
+type disassembler struct {
+	m       *Module
+	names   map[uint32]string // id -> name without %
+	used    map[string]bool
+	types   map[uint32]typeDesc
+	extSets map[uint32]string // OpExtInstImport id -> set name
+}
+
+// Disassemble writes m in the standard SPIR-V textual form to w.
+func (m *Module) Disassemble(w io.Writer, opts DisassembleOptions) error {
+	d := &disassembler{
+		m:       m,
+		names:   map[uint32]string{},
+		used:    map[string]bool{},
+		types:   map[uint32]typeDesc{},
+		extSets: map[uint32]string{},
+	}
+	d.index(opts.RawIDs)
+
+	var b bytes.Buffer
+	gen := m.Generator >> 16
+	genName, ok := generators[gen]
+	if !ok {
+		genName = fmt.Sprintf("Unknown(%d)", gen)
+	}
+	fmt.Fprintf(&b, "; SPIR-V\n; Version: %d.%d\n; Generator: %s; %d\n; Bound: %d\n; Schema: 0\n",
+		m.Version>>16&0xFF, m.Version>>8&0xFF, genName, m.Generator&0xFFFF, m.Bound)
+	for _, inst := range m.Instructions {
+		b.WriteString(d.instruction(inst))
+		b.WriteByte('\n')
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// index records types, extended instruction sets, and names.
+func (d *disassembler) index(raw bool) {
+	debugNames := map[uint32]string{}
+	for _, inst := range d.m.Instructions {
+		ops := inst.Operands
+		switch inst.Op {
+		case OpName:
+			if len(ops) >= 1 {
+				if s, _ := String(ops[1:]); s != "" {
+					debugNames[ops[0]] = s
+				}
+			}
+		case OpExtInstImport:
+			if len(ops) >= 1 {
+				d.extSets[ops[0]], _ = String(ops[1:])
+			}
+		case OpTypeInt:
+			if len(ops) >= 3 {
+				d.types[ops[0]] = typeDesc{op: inst.Op, width: ops[1], signed: ops[2] != 0}
+			}
+		case OpTypeFloat, OpTypeBool:
+			t := typeDesc{op: inst.Op}
+			if len(ops) >= 2 {
+				t.width = ops[1]
+			}
+			if len(ops) >= 1 {
+				d.types[ops[0]] = t
+			}
+		}
+	}
+	if raw {
+		return
+	}
+	for _, inst := range d.m.Instructions {
+		id, ok := resultID(inst)
+		if !ok {
+			continue
+		}
+		if n, ok := debugNames[id]; ok {
+			d.name(id, sanitize(n))
+		} else if n := d.friendly(inst); n != "" {
+			d.name(id, n)
+		}
+	}
+}
+
+// resultID returns the result id of inst, if it has one.
+func resultID(inst Instruction) (uint32, bool) {
+	info, ok := opcodes[inst.Op]
+	if !ok {
+		return 0, false
+	}
+	switch {
+	case strings.HasPrefix(info.operands, "T R"):
+		if len(inst.Operands) >= 2 {
+			return inst.Operands[1], true
+		}
+	case strings.HasPrefix(info.operands, "R"):
+		if len(inst.Operands) >= 1 {
+			return inst.Operands[0], true
+		}
+	}
+	return 0, false
+}
+
+// name assigns a unique name to id, suffixing _0, _1, ... on collision.
+func (d *disassembler) name(id uint32, n string) {
+	unique := n
+	for i := 0; d.used[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", n, i)
+	}
+	d.used[unique] = true
+	d.names[id] = unique
+}
+
+// sanitize turns a debug name into an id name: characters other than letters,
+// digits, and underscores become underscores, and a leading digit is
+// prefixed so it cannot read as a raw id.
+func sanitize(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	if len(b) > 0 && b[0] >= '0' && b[0] <= '9' {
+		return "_" + string(b)
+	}
+	return string(b)
+}
+
+// friendly derives a name for a type or constant declaration, or "".
+func (d *disassembler) friendly(inst Instruction) string {
+	ops := inst.Operands
+	ref := func(id uint32) string {
+		if n, ok := d.names[id]; ok {
+			return n
+		}
+		return strconv.FormatUint(uint64(id), 10)
+	}
+	switch inst.Op {
+	case OpTypeVoid:
+		return "void"
+	case OpTypeBool:
+		return "bool"
+	case OpTypeInt:
+		t := d.types[ops[0]]
+		n := map[uint32]string{8: "char", 16: "short", 32: "int", 64: "long"}[t.width]
+		if n == "" {
+			return ""
+		}
+		if !t.signed {
+			n = "u" + n
+		}
+		return n
+	case OpTypeFloat:
+		return map[uint32]string{16: "half", 32: "float", 64: "double"}[d.types[ops[0]].width]
+	case OpTypeVector:
+		if len(ops) >= 3 {
+			return fmt.Sprintf("v%d%s", ops[2], ref(ops[1]))
+		}
+	case OpTypeMatrix:
+		if len(ops) >= 3 {
+			return fmt.Sprintf("mat%d%s", ops[2], ref(ops[1]))
+		}
+	case OpTypeArray:
+		if len(ops) >= 3 {
+			return fmt.Sprintf("_arr_%s_%s", ref(ops[1]), ref(ops[2]))
+		}
+	case OpTypeRuntimeArray:
+		if len(ops) >= 2 {
+			return "_runtimearr_" + ref(ops[1])
+		}
+	case OpTypeStruct:
+		return fmt.Sprintf("_struct_%d", ops[0])
+	case OpTypePointer:
+		if len(ops) >= 3 {
+			return fmt.Sprintf("_ptr_%s_%s", enumName("StorageClass", ops[1]), ref(ops[2]))
+		}
+	case OpConstantTrue, OpSpecConstantTrue:
+		return "true"
+	case OpConstantFalse, OpSpecConstantFalse:
+		return "false"
+	case OpConstant:
+		if len(ops) < 3 {
+			return ""
+		}
+		t, ok := d.types[ops[0]]
+		if !ok {
+			return ""
+		}
+		v := d.typedLiteral(t, ops[2:])
+		v = strings.NewReplacer("-", "n", ".", "_", "+", "").Replace(v)
+		return ref(ops[0]) + "_" + v
+	}
+	return ""
+}
+
+// enumName returns the name of value in enum kind, or its number.
+func enumName(kind string, v uint32) string {
+	if n, ok := enumNames[kind][v]; ok {
+		return n
+	}
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+// maskName returns the |-joined bit names of a mask operand.
+func maskName(kind string, v uint32) string {
+	if v == 0 {
+		return "None"
+	}
+	var parts []string
+	for _, b := range maskNames[kind] {
+		if v&b.bit != 0 {
+			parts = append(parts, b.name)
+			v &^= b.bit
+		}
+	}
+	if v != 0 {
+		parts = append(parts, fmt.Sprintf("%#x", v))
+	}
+	return strings.Join(parts, "|")
+}
+
+// id formats an id operand.
+func (d *disassembler) id(v uint32) string {
+	if n, ok := d.names[v]; ok {
+		return "%" + n
+	}
+	return "%" + strconv.FormatUint(uint64(v), 10)
+}
+
+// typedLiteral formats the literal words of a constant of type t.
+func (d *disassembler) typedLiteral(t typeDesc, words []uint32) string {
+	wide := uint64(words[0])
+	if len(words) >= 2 {
+		wide |= uint64(words[1]) << 32
+	}
+	switch {
+	case t.op == OpTypeFloat && t.width == 16:
+		return strconv.FormatFloat(float64(halfToFloat(uint16(words[0]))), 'g', -1, 32)
+	case t.op == OpTypeFloat && t.width == 32:
+		return strconv.FormatFloat(float64(math.Float32frombits(words[0])), 'g', -1, 32)
+	case t.op == OpTypeFloat && t.width == 64:
+		return strconv.FormatFloat(math.Float64frombits(wide), 'g', -1, 64)
+	case t.op == OpTypeInt && t.width == 64 && t.signed:
+		return strconv.FormatInt(int64(wide), 10)
+	case t.op == OpTypeInt && t.width == 64:
+		return strconv.FormatUint(wide, 10)
+	case t.op == OpTypeInt && t.signed:
+		// Narrower signed literals are sign-extended into the word.
+		shift := 32 - t.width
+		return strconv.FormatInt(int64(int32(words[0]<<shift)>>shift), 10)
+	}
+	return strconv.FormatUint(uint64(words[0]), 10)
+}
+
+// halfToFloat widens an IEEE 754 binary16 value.
+func halfToFloat(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1F
+	frac := uint32(h) & 0x3FF
+	switch {
+	case exp == 0 && frac == 0:
+		return math.Float32frombits(sign)
+	case exp == 0:
+		// Subnormal: value is frac * 2^-24.
+		f := float32(frac) / (1 << 24)
+		if sign != 0 {
+			f = -f
+		}
+		return f
+	case exp == 0x1F:
+		return math.Float32frombits(sign | 0xFF<<23 | frac<<13)
+	}
+	return math.Float32frombits(sign | (exp+112)<<23 | frac<<13)
+}
+
+// quote formats a literal string operand.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// instruction formats one instruction as a line of text.
+func (d *disassembler) instruction(inst Instruction) string {
+	info, known := opcodes[inst.Op]
+	name := info.name
+	if !known {
+		name = fmt.Sprintf("OpUnknown(%d)", inst.Op)
+	}
+	ops := inst.Operands
+	var result string
+	var args []string
+	kinds := strings.Fields(info.operands)
+	k := 0
+	var resultType typeDesc
+	var extSet string
+	for len(ops) > 0 {
+		if k >= len(kinds) {
+			// Operands beyond the grammar, or an unknown opcode.
+			args = append(args, strconv.FormatUint(uint64(ops[0]), 10))
+			ops = ops[1:]
+			continue
+		}
+		kind, repeat := strings.CutSuffix(kinds[k], "*")
+		if !repeat {
+			k++
+		}
+		switch kind {
+		case "T":
+			resultType = d.types[ops[0]]
+			args = append(args, d.id(ops[0]))
+			ops = ops[1:]
+		case "R":
+			result = d.id(ops[0])
+			ops = ops[1:]
+		case "i":
+			if inst.Op == OpExtInst && extSet == "" {
+				extSet = d.extSets[ops[0]]
+			}
+			args = append(args, d.id(ops[0]))
+			ops = ops[1:]
+		case "l":
+			args = append(args, strconv.FormatUint(uint64(ops[0]), 10))
+			ops = ops[1:]
+		case "s":
+			s, n := String(ops)
+			args = append(args, quote(s))
+			ops = ops[n:]
+		case "c":
+			if resultType.op == 0 {
+				for _, w := range ops {
+					args = append(args, strconv.FormatUint(uint64(w), 10))
+				}
+			} else {
+				args = append(args, d.typedLiteral(resultType, ops))
+			}
+			ops = nil
+		case "o":
+			if op, ok := opcodes[Op(ops[0])]; ok {
+				args = append(args, strings.TrimPrefix(op.name, "Op"))
+			} else {
+				args = append(args, strconv.FormatUint(uint64(ops[0]), 10))
+			}
+			ops = ops[1:]
+		case "x":
+			if extSet == "GLSL.std.450" && int(ops[0]) < len(glslStd450) && glslStd450[ops[0]] != "" {
+				args = append(args, glslStd450[ops[0]])
+			} else {
+				args = append(args, strconv.FormatUint(uint64(ops[0]), 10))
+			}
+			ops = ops[1:]
+		case "w":
+			args = append(args, strconv.FormatUint(uint64(ops[0]), 10))
+			if len(ops) >= 2 {
+				args = append(args, d.id(ops[1]))
+				ops = ops[2:]
+			} else {
+				ops = ops[1:]
+			}
+		case "Decoration":
+			args = append(args, enumName(kind, ops[0]))
+			ops = d.decorationArgs(inst.Op, ops[0], ops[1:], &args)
+		case "FunctionControl", "SelectionControl", "LoopControl", "MemoryAccess", "ImageOperands":
+			mask := ops[0]
+			args = append(args, maskName(kind, mask))
+			ops = ops[1:]
+			switch kind {
+			case "LoopControl":
+				for _, w := range ops {
+					args = append(args, strconv.FormatUint(uint64(w), 10))
+				}
+				ops = nil
+			case "MemoryAccess":
+				if mask&0x2 != 0 && len(ops) > 0 { // Aligned takes a literal
+					args = append(args, strconv.FormatUint(uint64(ops[0]), 10))
+					ops = ops[1:]
+				}
+				for _, w := range ops {
+					args = append(args, d.id(w))
+				}
+				ops = nil
+			case "ImageOperands":
+				for _, w := range ops {
+					args = append(args, d.id(w))
+				}
+				ops = nil
+			}
+		default:
+			args = append(args, enumName(kind, ops[0]))
+			ops = ops[1:]
+		}
+	}
+
+	line := name
+	if len(args) > 0 {
+		line += " " + strings.Join(args, " ")
+	}
+	if result == "" {
+		return strings.Repeat(" ", 15) + line
+	}
+	return fmt.Sprintf("%12s = %s", result, line)
+}
+
+// decorationArgs formats the operands that follow decoration deco and
+// returns what is left.
+func (d *disassembler) decorationArgs(op Op, deco uint32, ops []uint32, args *[]string) []uint32 {
+	switch {
+	case op == OpDecorateID:
+		for _, w := range ops {
+			*args = append(*args, d.id(w))
+		}
+		return nil
+	case op == OpDecorateString || op == OpMemberDecorateString:
+		for len(ops) > 0 {
+			s, n := String(ops)
+			*args = append(*args, quote(s))
+			ops = ops[n:]
+		}
+		return nil
+	case deco == DecorationBuiltIn && len(ops) > 0:
+		*args = append(*args, enumName("BuiltIn", ops[0]))
+		return ops[1:]
+	case deco == 41 && len(ops) > 0: // LinkageAttributes: name, linkage type
+		s, n := String(ops)
+		*args = append(*args, quote(s))
+		ops = ops[n:]
+		if len(ops) > 0 {
+			*args = append(*args, map[uint32]string{0: "Export", 1: "Import", 2: "LinkOnceODR"}[ops[0]])
+			ops = ops[1:]
+		}
+		return ops
+	}
+	for _, w := range ops {
+		*args = append(*args, strconv.FormatUint(uint64(w), 10))
+	}
+	return nil
+}
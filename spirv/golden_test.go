@@ -0,0 +1,86 @@
+package spirv
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// flythroughShaders are the committed glslang builds of the flythrough
+// example's shaders.
+var flythroughShaders = []string{
+	"sky.vert", "sky.frag",
+	"terrain.vert", "terrain.frag",
+	"tree.vert", "tree.frag",
+}
+
+// golden compares got with testdata/name, or rewrites it with -update.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		gl, wl := strings.Split(string(got), "\n"), strings.Split(string(want), "\n")
+		for i := 0; i < len(gl) && i < len(wl); i++ {
+			if gl[i] != wl[i] {
+				t.Fatalf("%s differs at line %d:\ngot:  %s\nwant: %s", path, i+1, gl[i], wl[i])
+			}
+		}
+		t.Fatalf("%s differs: got %d lines, want %d", path, len(gl), len(wl))
+	}
+}
+
+func readShader(t *testing.T, name string) []byte {
+	t.Helper()
+	code, err := os.ReadFile(filepath.Join("..", "examples", "flythrough", "shaders", name+".spv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestDisassembleGolden(t *testing.T) {
+	for _, name := range flythroughShaders {
+		t.Run(name, func(t *testing.T) {
+			got, err := Disassemble(readShader(t, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden(t, filepath.Join("flythrough", name+".dis"), []byte(got))
+		})
+	}
+}
+
+func TestReflectGolden(t *testing.T) {
+	for _, name := range flythroughShaders {
+		t.Run(name, func(t *testing.T) {
+			r, err := Reflect(readShader(t, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.MarshalIndent(r, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden(t, filepath.Join("flythrough", name+".json"), append(got, '\n'))
+		})
+	}
+}
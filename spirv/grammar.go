@@ -0,0 +1,304 @@
+package spirv
+
+// opInfo is the grammar of one opcode: its name and operand kinds, in the
+// operand notation described in disasm.go.
+type opInfo struct {
+	name     string
+	operands string
+}
+
+// opcodes is the core grammar, plus the extension opcodes common in Vulkan
+// shaders. Opcodes missing from it disassemble with raw operands.
+var opcodes = map[Op]opInfo{
+	0:    {"OpNop", ""},
+	1:    {"OpUndef", "T R"},
+	2:    {"OpSourceContinued", "s"},
+	3:    {"OpSource", "SourceLanguage l i s"},
+	4:    {"OpSourceExtension", "s"},
+	5:    {"OpName", "i s"},
+	6:    {"OpMemberName", "i l s"},
+	7:    {"OpString", "R s"},
+	8:    {"OpLine", "i l l"},
+	10:   {"OpExtension", "s"},
+	11:   {"OpExtInstImport", "R s"},
+	12:   {"OpExtInst", "T R i x i*"},
+	14:   {"OpMemoryModel", "AddressingModel MemoryModel"},
+	15:   {"OpEntryPoint", "ExecutionModel i s i*"},
+	16:   {"OpExecutionMode", "i ExecutionMode l*"},
+	17:   {"OpCapability", "Capability"},
+	19:   {"OpTypeVoid", "R"},
+	20:   {"OpTypeBool", "R"},
+	21:   {"OpTypeInt", "R l l"},
+	22:   {"OpTypeFloat", "R l l"},
+	23:   {"OpTypeVector", "R i l"},
+	24:   {"OpTypeMatrix", "R i l"},
+	25:   {"OpTypeImage", "R i Dim l l l l ImageFormat AccessQualifier"},
+	26:   {"OpTypeSampler", "R"},
+	27:   {"OpTypeSampledImage", "R i"},
+	28:   {"OpTypeArray", "R i i"},
+	29:   {"OpTypeRuntimeArray", "R i"},
+	30:   {"OpTypeStruct", "R i*"},
+	31:   {"OpTypeOpaque", "R s"},
+	32:   {"OpTypePointer", "R StorageClass i"},
+	33:   {"OpTypeFunction", "R i i*"},
+	34:   {"OpTypeEvent", "R"},
+	35:   {"OpTypeDeviceEvent", "R"},
+	36:   {"OpTypeReserveId", "R"},
+	37:   {"OpTypeQueue", "R"},
+	38:   {"OpTypePipe", "R AccessQualifier"},
+	39:   {"OpTypeForwardPointer", "i StorageClass"},
+	41:   {"OpConstantTrue", "T R"},
+	42:   {"OpConstantFalse", "T R"},
+	43:   {"OpConstant", "T R c"},
+	44:   {"OpConstantComposite", "T R i*"},
+	45:   {"OpConstantSampler", "T R SamplerAddressingMode l SamplerFilterMode"},
+	46:   {"OpConstantNull", "T R"},
+	48:   {"OpSpecConstantTrue", "T R"},
+	49:   {"OpSpecConstantFalse", "T R"},
+	50:   {"OpSpecConstant", "T R c"},
+	51:   {"OpSpecConstantComposite", "T R i*"},
+	52:   {"OpSpecConstantOp", "T R o i*"},
+	54:   {"OpFunction", "T R FunctionControl i"},
+	55:   {"OpFunctionParameter", "T R"},
+	56:   {"OpFunctionEnd", ""},
+	57:   {"OpFunctionCall", "T R i i*"},
+	59:   {"OpVariable", "T R StorageClass i"},
+	60:   {"OpImageTexelPointer", "T R i i i"},
+	61:   {"OpLoad", "T R i MemoryAccess"},
+	62:   {"OpStore", "i i MemoryAccess"},
+	63:   {"OpCopyMemory", "i i MemoryAccess"},
+	64:   {"OpCopyMemorySized", "i i i MemoryAccess"},
+	65:   {"OpAccessChain", "T R i i*"},
+	66:   {"OpInBoundsAccessChain", "T R i i*"},
+	67:   {"OpPtrAccessChain", "T R i i i*"},
+	68:   {"OpArrayLength", "T R i l"},
+	70:   {"OpInBoundsPtrAccessChain", "T R i i i*"},
+	71:   {"OpDecorate", "i Decoration"},
+	72:   {"OpMemberDecorate", "i l Decoration"},
+	73:   {"OpDecorationGroup", "R"},
+	74:   {"OpGroupDecorate", "i i*"},
+	75:   {"OpGroupMemberDecorate", "i i*"},
+	77:   {"OpVectorExtractDynamic", "T R i i"},
+	78:   {"OpVectorInsertDynamic", "T R i i i"},
+	79:   {"OpVectorShuffle", "T R i i l*"},
+	80:   {"OpCompositeConstruct", "T R i*"},
+	81:   {"OpCompositeExtract", "T R i l*"},
+	82:   {"OpCompositeInsert", "T R i i l*"},
+	83:   {"OpCopyObject", "T R i"},
+	84:   {"OpTranspose", "T R i"},
+	86:   {"OpSampledImage", "T R i i"},
+	87:   {"OpImageSampleImplicitLod", "T R i i ImageOperands"},
+	88:   {"OpImageSampleExplicitLod", "T R i i ImageOperands"},
+	89:   {"OpImageSampleDrefImplicitLod", "T R i i i ImageOperands"},
+	90:   {"OpImageSampleDrefExplicitLod", "T R i i i ImageOperands"},
+	91:   {"OpImageSampleProjImplicitLod", "T R i i ImageOperands"},
+	92:   {"OpImageSampleProjExplicitLod", "T R i i ImageOperands"},
+	93:   {"OpImageSampleProjDrefImplicitLod", "T R i i i ImageOperands"},
+	94:   {"OpImageSampleProjDrefExplicitLod", "T R i i i ImageOperands"},
+	95:   {"OpImageFetch", "T R i i ImageOperands"},
+	96:   {"OpImageGather", "T R i i i ImageOperands"},
+	97:   {"OpImageDrefGather", "T R i i i ImageOperands"},
+	98:   {"OpImageRead", "T R i i ImageOperands"},
+	99:   {"OpImageWrite", "i i i ImageOperands"},
+	100:  {"OpImage", "T R i"},
+	101:  {"OpImageQueryFormat", "T R i"},
+	102:  {"OpImageQueryOrder", "T R i"},
+	103:  {"OpImageQuerySizeLod", "T R i i"},
+	104:  {"OpImageQuerySize", "T R i"},
+	105:  {"OpImageQueryLod", "T R i i"},
+	106:  {"OpImageQueryLevels", "T R i"},
+	107:  {"OpImageQuerySamples", "T R i"},
+	109:  {"OpConvertFToU", "T R i"},
+	110:  {"OpConvertFToS", "T R i"},
+	111:  {"OpConvertSToF", "T R i"},
+	112:  {"OpConvertUToF", "T R i"},
+	113:  {"OpUConvert", "T R i"},
+	114:  {"OpSConvert", "T R i"},
+	115:  {"OpFConvert", "T R i"},
+	116:  {"OpQuantizeToF16", "T R i"},
+	117:  {"OpConvertPtrToU", "T R i"},
+	118:  {"OpSatConvertSToU", "T R i"},
+	119:  {"OpSatConvertUToS", "T R i"},
+	120:  {"OpConvertUToPtr", "T R i"},
+	121:  {"OpPtrCastToGeneric", "T R i"},
+	122:  {"OpGenericCastToPtr", "T R i"},
+	123:  {"OpGenericCastToPtrExplicit", "T R i StorageClass"},
+	124:  {"OpBitcast", "T R i"},
+	126:  {"OpSNegate", "T R i"},
+	127:  {"OpFNegate", "T R i"},
+	128:  {"OpIAdd", "T R i i"},
+	129:  {"OpFAdd", "T R i i"},
+	130:  {"OpISub", "T R i i"},
+	131:  {"OpFSub", "T R i i"},
+	132:  {"OpIMul", "T R i i"},
+	133:  {"OpFMul", "T R i i"},
+	134:  {"OpUDiv", "T R i i"},
+	135:  {"OpSDiv", "T R i i"},
+	136:  {"OpFDiv", "T R i i"},
+	137:  {"OpUMod", "T R i i"},
+	138:  {"OpSRem", "T R i i"},
+	139:  {"OpSMod", "T R i i"},
+	140:  {"OpFRem", "T R i i"},
+	141:  {"OpFMod", "T R i i"},
+	142:  {"OpVectorTimesScalar", "T R i i"},
+	143:  {"OpMatrixTimesScalar", "T R i i"},
+	144:  {"OpVectorTimesMatrix", "T R i i"},
+	145:  {"OpMatrixTimesVector", "T R i i"},
+	146:  {"OpMatrixTimesMatrix", "T R i i"},
+	147:  {"OpOuterProduct", "T R i i"},
+	148:  {"OpDot", "T R i i"},
+	149:  {"OpIAddCarry", "T R i i"},
+	150:  {"OpISubBorrow", "T R i i"},
+	151:  {"OpUMulExtended", "T R i i"},
+	152:  {"OpSMulExtended", "T R i i"},
+	154:  {"OpAny", "T R i"},
+	155:  {"OpAll", "T R i"},
+	156:  {"OpIsNan", "T R i"},
+	157:  {"OpIsInf", "T R i"},
+	158:  {"OpIsFinite", "T R i"},
+	159:  {"OpIsNormal", "T R i"},
+	160:  {"OpSignBitSet", "T R i"},
+	161:  {"OpLessOrGreater", "T R i i"},
+	162:  {"OpOrdered", "T R i i"},
+	163:  {"OpUnordered", "T R i i"},
+	164:  {"OpLogicalEqual", "T R i i"},
+	165:  {"OpLogicalNotEqual", "T R i i"},
+	166:  {"OpLogicalOr", "T R i i"},
+	167:  {"OpLogicalAnd", "T R i i"},
+	168:  {"OpLogicalNot", "T R i"},
+	169:  {"OpSelect", "T R i i i"},
+	170:  {"OpIEqual", "T R i i"},
+	171:  {"OpINotEqual", "T R i i"},
+	172:  {"OpUGreaterThan", "T R i i"},
+	173:  {"OpSGreaterThan", "T R i i"},
+	174:  {"OpUGreaterThanEqual", "T R i i"},
+	175:  {"OpSGreaterThanEqual", "T R i i"},
+	176:  {"OpULessThan", "T R i i"},
+	177:  {"OpSLessThan", "T R i i"},
+	178:  {"OpULessThanEqual", "T R i i"},
+	179:  {"OpSLessThanEqual", "T R i i"},
+	180:  {"OpFOrdEqual", "T R i i"},
+	181:  {"OpFUnordEqual", "T R i i"},
+	182:  {"OpFOrdNotEqual", "T R i i"},
+	183:  {"OpFUnordNotEqual", "T R i i"},
+	184:  {"OpFOrdLessThan", "T R i i"},
+	185:  {"OpFUnordLessThan", "T R i i"},
+	186:  {"OpFOrdGreaterThan", "T R i i"},
+	187:  {"OpFUnordGreaterThan", "T R i i"},
+	188:  {"OpFOrdLessThanEqual", "T R i i"},
+	189:  {"OpFUnordLessThanEqual", "T R i i"},
+	190:  {"OpFOrdGreaterThanEqual", "T R i i"},
+	191:  {"OpFUnordGreaterThanEqual", "T R i i"},
+	194:  {"OpShiftRightLogical", "T R i i"},
+	195:  {"OpShiftRightArithmetic", "T R i i"},
+	196:  {"OpShiftLeftLogical", "T R i i"},
+	197:  {"OpBitwiseOr", "T R i i"},
+	198:  {"OpBitwiseXor", "T R i i"},
+	199:  {"OpBitwiseAnd", "T R i i"},
+	200:  {"OpNot", "T R i"},
+	201:  {"OpBitFieldInsert", "T R i i i i"},
+	202:  {"OpBitFieldSExtract", "T R i i i"},
+	203:  {"OpBitFieldUExtract", "T R i i i"},
+	204:  {"OpBitReverse", "T R i"},
+	205:  {"OpBitCount", "T R i"},
+	207:  {"OpDPdx", "T R i"},
+	208:  {"OpDPdy", "T R i"},
+	209:  {"OpFwidth", "T R i"},
+	210:  {"OpDPdxFine", "T R i"},
+	211:  {"OpDPdyFine", "T R i"},
+	212:  {"OpFwidthFine", "T R i"},
+	213:  {"OpDPdxCoarse", "T R i"},
+	214:  {"OpDPdyCoarse", "T R i"},
+	215:  {"OpFwidthCoarse", "T R i"},
+	218:  {"OpEmitVertex", ""},
+	219:  {"OpEndPrimitive", ""},
+	220:  {"OpEmitStreamVertex", "i"},
+	221:  {"OpEndStreamPrimitive", "i"},
+	224:  {"OpControlBarrier", "i i i"},
+	225:  {"OpMemoryBarrier", "i i"},
+	227:  {"OpAtomicLoad", "T R i i i"},
+	228:  {"OpAtomicStore", "i i i i"},
+	229:  {"OpAtomicExchange", "T R i i i i"},
+	230:  {"OpAtomicCompareExchange", "T R i i i i i i"},
+	231:  {"OpAtomicCompareExchangeWeak", "T R i i i i i i"},
+	232:  {"OpAtomicIIncrement", "T R i i i"},
+	233:  {"OpAtomicIDecrement", "T R i i i"},
+	234:  {"OpAtomicIAdd", "T R i i i i"},
+	235:  {"OpAtomicISub", "T R i i i i"},
+	236:  {"OpAtomicSMin", "T R i i i i"},
+	237:  {"OpAtomicUMin", "T R i i i i"},
+	238:  {"OpAtomicSMax", "T R i i i i"},
+	239:  {"OpAtomicUMax", "T R i i i i"},
+	240:  {"OpAtomicAnd", "T R i i i i"},
+	241:  {"OpAtomicOr", "T R i i i i"},
+	242:  {"OpAtomicXor", "T R i i i i"},
+	245:  {"OpPhi", "T R i*"},
+	246:  {"OpLoopMerge", "i i LoopControl"},
+	247:  {"OpSelectionMerge", "i SelectionControl"},
+	248:  {"OpLabel", "R"},
+	249:  {"OpBranch", "i"},
+	250:  {"OpBranchConditional", "i i i l*"},
+	251:  {"OpSwitch", "i i w*"},
+	252:  {"OpKill", ""},
+	253:  {"OpReturn", ""},
+	254:  {"OpReturnValue", "i"},
+	255:  {"OpUnreachable", ""},
+	256:  {"OpLifetimeStart", "i l"},
+	257:  {"OpLifetimeStop", "i l"},
+	317:  {"OpNoLine", ""},
+	321:  {"OpSizeOf", "T R i"},
+	330:  {"OpModuleProcessed", "s"},
+	331:  {"OpExecutionModeId", "i ExecutionMode i*"},
+	332:  {"OpDecorateId", "i Decoration i*"},
+	333:  {"OpGroupNonUniformElect", "T R i"},
+	334:  {"OpGroupNonUniformAll", "T R i i"},
+	335:  {"OpGroupNonUniformAny", "T R i i"},
+	336:  {"OpGroupNonUniformAllEqual", "T R i i"},
+	337:  {"OpGroupNonUniformBroadcast", "T R i i i"},
+	338:  {"OpGroupNonUniformBroadcastFirst", "T R i i"},
+	339:  {"OpGroupNonUniformBallot", "T R i i"},
+	340:  {"OpGroupNonUniformInverseBallot", "T R i i"},
+	341:  {"OpGroupNonUniformBallotBitExtract", "T R i i i"},
+	342:  {"OpGroupNonUniformBallotBitCount", "T R i GroupOperation i"},
+	343:  {"OpGroupNonUniformBallotFindLSB", "T R i i"},
+	344:  {"OpGroupNonUniformBallotFindMSB", "T R i i"},
+	345:  {"OpGroupNonUniformShuffle", "T R i i i"},
+	346:  {"OpGroupNonUniformShuffleXor", "T R i i i"},
+	347:  {"OpGroupNonUniformShuffleUp", "T R i i i"},
+	348:  {"OpGroupNonUniformShuffleDown", "T R i i i"},
+	349:  {"OpGroupNonUniformIAdd", "T R i GroupOperation i i"},
+	350:  {"OpGroupNonUniformFAdd", "T R i GroupOperation i i"},
+	351:  {"OpGroupNonUniformIMul", "T R i GroupOperation i i"},
+	352:  {"OpGroupNonUniformFMul", "T R i GroupOperation i i"},
+	353:  {"OpGroupNonUniformSMin", "T R i GroupOperation i i"},
+	354:  {"OpGroupNonUniformUMin", "T R i GroupOperation i i"},
+	355:  {"OpGroupNonUniformFMin", "T R i GroupOperation i i"},
+	356:  {"OpGroupNonUniformSMax", "T R i GroupOperation i i"},
+	357:  {"OpGroupNonUniformUMax", "T R i GroupOperation i i"},
+	358:  {"OpGroupNonUniformFMax", "T R i GroupOperation i i"},
+	359:  {"OpGroupNonUniformBitwiseAnd", "T R i GroupOperation i i"},
+	360:  {"OpGroupNonUniformBitwiseOr", "T R i GroupOperation i i"},
+	361:  {"OpGroupNonUniformBitwiseXor", "T R i GroupOperation i i"},
+	362:  {"OpGroupNonUniformLogicalAnd", "T R i GroupOperation i i"},
+	363:  {"OpGroupNonUniformLogicalOr", "T R i GroupOperation i i"},
+	364:  {"OpGroupNonUniformLogicalXor", "T R i GroupOperation i i"},
+	365:  {"OpGroupNonUniformQuadBroadcast", "T R i i i"},
+	366:  {"OpGroupNonUniformQuadSwap", "T R i i i"},
+	400:  {"OpCopyLogical", "T R i"},
+	401:  {"OpPtrEqual", "T R i i"},
+	402:  {"OpPtrNotEqual", "T R i i"},
+	403:  {"OpPtrDiff", "T R i i"},
+	4416: {"OpTerminateInvocation", ""},
+	4445: {"OpTraceRayKHR", "i i i i i i i i i i i"},
+	4446: {"OpExecuteCallableKHR", "i i"},
+	4447: {"OpConvertUToAccelerationStructureKHR", "T R i"},
+	4448: {"OpIgnoreIntersectionKHR", ""},
+	4449: {"OpTerminateRayKHR", ""},
+	5294: {"OpEmitMeshTasksEXT", "i i i i"},
+	5295: {"OpSetMeshOutputsEXT", "i i"},
+	5341: {"OpTypeAccelerationStructureKHR", "R"},
+	5380: {"OpDemoteToHelperInvocation", ""},
+	5381: {"OpIsHelperInvocationEXT", "T R"},
+	5632: {"OpDecorateString", "i Decoration"},
+	5633: {"OpMemberDecorateString", "i l Decoration"},
+}
@@ -0,0 +1,120 @@
+package spirv
+
+import (
+	"fmt"
+
+	"github.com/christerso/vulkan-go/vk"
+)
+
+// Layout is the combined resource interface of the shader stages of one
+// pipeline, ready to create Vulkan layouts from.
+type Layout struct {
+	// Sets holds the bindings of each descriptor set, indexed by set number.
+	// Set numbers the shaders skip have no bindings. Counts may be edited
+	// before Create, for example to size a runtime array.
+	Sets       [][]vk.DescriptorBinding
+	PushStages uint32 // stages that read the push constant block
+	PushSize   uint32 // bytes of push constants; 0 if none
+}
+
+// MergeLayouts combines the interfaces of a pipeline's stages. A binding used
+// by several stages gets all their stage bits; the stages must agree on its
+// descriptor type and count.
+func MergeLayouts(stages ...*Reflection) (*Layout, error) {
+	l := &Layout{}
+	for _, r := range stages {
+		for _, b := range r.Bindings {
+			if err := l.add(b.Set, vk.DescriptorBinding{Binding: b.Binding, Type: b.Type, Count: b.Count, Stages: b.Stages}); err != nil {
+				return nil, err
+			}
+		}
+		if r.PushConstants != nil {
+			l.PushStages |= r.PushStages
+			l.PushSize = max(l.PushSize, r.PushConstants.Size)
+		}
+	}
+	return l, nil
+}
+
+// JoinLayouts combines layouts as MergeLayouts combines reflections, for
+// layouts that were reflected ahead of time, such as those cmd/vkshaderc
+// generates.
+func JoinLayouts(layouts ...*Layout) (*Layout, error) {
+	l := &Layout{}
+	for _, o := range layouts {
+		for set, bindings := range o.Sets {
+			for _, b := range bindings {
+				if err := l.add(uint32(set), b); err != nil {
+					return nil, err
+				}
+			}
+		}
+		l.PushStages |= o.PushStages
+		l.PushSize = max(l.PushSize, o.PushSize)
+	}
+	return l, nil
+}
+
+// add adds b to set, merging stage bits into an existing binding.
+func (l *Layout) add(set uint32, b vk.DescriptorBinding) error {
+	for int(set) >= len(l.Sets) {
+		l.Sets = append(l.Sets, nil)
+	}
+	bindings := l.Sets[set]
+	for i := range bindings {
+		if bindings[i].Binding != b.Binding {
+			continue
+		}
+		if bindings[i].Type != b.Type || bindings[i].Count != b.Count {
+			return fmt.Errorf("spirv: binding %d.%d declared as type %d count %d and type %d count %d",
+				set, b.Binding, bindings[i].Type, bindings[i].Count, b.Type, b.Count)
+		}
+		bindings[i].Stages |= b.Stages
+		return nil
+	}
+	l.Sets[set] = append(bindings, b)
+	return nil
+}
+
+// Create creates one descriptor set layout per set and a pipeline layout over
+// them. On error nothing is left allocated.
+func (l *Layout) Create(d vk.Device) ([]vk.DescriptorSetLayout, vk.PipelineLayout, error) {
+	setLayouts := make([]vk.DescriptorSetLayout, 0, len(l.Sets))
+	fail := func(err error) ([]vk.DescriptorSetLayout, vk.PipelineLayout, error) {
+		for _, sl := range setLayouts {
+			d.DestroyDescriptorSetLayout(sl)
+		}
+		return nil, 0, err
+	}
+	for set, bindings := range l.Sets {
+		for _, b := range bindings {
+			if b.Count == 0 {
+				return fail(fmt.Errorf("spirv: binding %d.%d is a runtime array; set its Count before Create", set, b.Binding))
+			}
+		}
+		sl, err := d.CreateDescriptorSetLayout(bindings)
+		if err != nil {
+			return fail(err)
+		}
+		setLayouts = append(setLayouts, sl)
+	}
+	pl, err := d.CreatePipelineLayout(setLayouts, l.PushStages, l.PushSize)
+	if err != nil {
+		return fail(err)
+	}
+	return setLayouts, pl, nil
+}
+
+// VertexInput returns a vertex buffer binding and attributes that feed every
+// vertex input of r from one interleaved buffer, with attributes packed
+// tightly in location order. A 64-bit three- or four-component input is one
+// attribute at its first location; its second location takes no attribute.
+func (r *Reflection) VertexInput(binding, inputRate uint32) (vk.VertexInputBinding, []vk.VertexInputAttribute) {
+	attrs := make([]vk.VertexInputAttribute, len(r.VertexInputs))
+	var offset uint32
+	for i, in := range r.VertexInputs {
+		attrs[i] = vk.VertexInputAttribute{Location: in.Location, Binding: binding, Format: in.Format, Offset: offset}
+		offset += in.Size
+	}
+	return vk.VertexInputBinding{Binding: binding, Stride: offset, InputRate: inputRate}, attrs
+}
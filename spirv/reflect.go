@@ -0,0 +1,783 @@
+package spirv
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/christerso/vulkan-go/vk"
+)
+
+// Kind classifies a Type.
+type Kind int
+
+// Type kinds.
+const (
+	KindVoid Kind = iota
+	KindBool
+	KindInt
+	KindFloat
+	KindVector
+	KindMatrix
+	KindArray
+	KindRuntimeArray
+	KindStruct
+	KindImage
+	KindSampler
+	KindSampledImage
+	KindAccelerationStructure
+	KindPointer
+	KindOther
+)
+
+// Image dimensionalities (SpvDim) with descriptor-type consequences.
+const (
+	DimBuffer      = 5
+	DimSubpassData = 6
+)
+
+// Type is a reflected SPIR-V type.
+type Type struct {
+	Kind   Kind
+	Name   string // OpName of the type, if any (struct types)
+	Width  uint32 // bits, for KindInt and KindFloat
+	Signed bool   // for KindInt
+	// Elem is the component of a vector, the column of a matrix, the element
+	// of an array, the sampled type of an image, the image of a sampled image,
+	// or the pointee of a pointer.
+	Elem *Type
+	Len  uint32 // components, columns, or array length
+	// Stride is the ArrayStride of an array type, 0 if undecorated.
+	Stride  uint32
+	Members []Member // for KindStruct
+	// Image properties (KindImage).
+	Dim     uint32
+	Sampled uint32 // 1: used with a sampler, 2: storage image
+	// Storage is the storage class of a KindPointer.
+	Storage StorageClass
+
+	id uint32 // result id of the declaration
+}
+
+// Member is one member of a struct type with its explicit layout.
+type Member struct {
+	Name         string
+	Type         *Type
+	Offset       uint32
+	Size         uint32 // 0 for a trailing runtime array
+	MatrixStride uint32
+}
+
+// size returns the byte size of t under explicit layout, or 0 when it has no
+// fixed size (runtime arrays, opaque types).
+func (t *Type) size(matrixStride uint32) uint32 {
+	switch t.Kind {
+	case KindBool:
+		return 4
+	case KindInt, KindFloat:
+		return t.Width / 8
+	case KindVector:
+		return t.Len * t.Elem.size(0)
+	case KindMatrix:
+		if matrixStride != 0 {
+			return t.Len * matrixStride
+		}
+		return t.Len * t.Elem.size(0)
+	case KindArray:
+		stride := t.Stride
+		if stride == 0 {
+			stride = t.Elem.size(matrixStride)
+		}
+		return t.Len * stride
+	case KindStruct:
+		var end uint32
+		for _, m := range t.Members {
+			end = max(end, m.Offset+m.Size)
+		}
+		return end
+	}
+	return 0
+}
+
+// String returns the GLSL spelling of t (vec4, mat3x4, uint[8], sampler2D).
+// Structs print as their name only, so recursive types terminate.
+func (t *Type) String() string {
+	switch t.Kind {
+	case KindVoid:
+		return "void"
+	case KindBool:
+		return "bool"
+	case KindInt:
+		switch {
+		case t.Width == 32 && t.Signed:
+			return "int"
+		case t.Width == 32:
+			return "uint"
+		case t.Signed:
+			return fmt.Sprintf("int%d_t", t.Width)
+		}
+		return fmt.Sprintf("uint%d_t", t.Width)
+	case KindFloat:
+		switch t.Width {
+		case 32:
+			return "float"
+		case 64:
+			return "double"
+		}
+		return fmt.Sprintf("float%d_t", t.Width)
+	case KindVector:
+		return fmt.Sprintf("%svec%d", t.Elem.prefix(), t.Len)
+	case KindMatrix:
+		if t.Elem.Len == t.Len {
+			return fmt.Sprintf("%smat%d", t.Elem.Elem.prefix(), t.Len)
+		}
+		return fmt.Sprintf("%smat%dx%d", t.Elem.Elem.prefix(), t.Len, t.Elem.Len)
+	case KindArray:
+		return fmt.Sprintf("%s[%d]", t.Elem, t.Len)
+	case KindRuntimeArray:
+		return t.Elem.String() + "[]"
+	case KindStruct:
+		if t.Name != "" {
+			return t.Name
+		}
+		return fmt.Sprintf("struct_%d", t.id)
+	case KindImage:
+		if t.Dim == DimSubpassData {
+			return "subpassInput"
+		}
+		if t.Sampled == 2 {
+			return t.Elem.prefix() + "image" + dimNames[t.Dim]
+		}
+		return t.Elem.prefix() + "texture" + dimNames[t.Dim]
+	case KindSampler:
+		return "sampler"
+	case KindSampledImage:
+		return t.Elem.Elem.prefix() + "sampler" + dimNames[t.Elem.Dim]
+	case KindAccelerationStructure:
+		return "accelerationStructureEXT"
+	case KindPointer:
+		return t.Elem.String() + "*"
+	}
+	return "unknown"
+}
+
+// prefix returns the GLSL vector-name prefix for scalar type t: "" for
+// float, "d" for double, "i", "u", "b", or a sized form such as "f16".
+func (t *Type) prefix() string {
+	if t == nil {
+		return ""
+	}
+	switch {
+	case t.Kind == KindBool:
+		return "b"
+	case t.Kind == KindFloat && t.Width == 32:
+		return ""
+	case t.Kind == KindFloat && t.Width == 64:
+		return "d"
+	case t.Kind == KindFloat:
+		return fmt.Sprintf("f%d", t.Width)
+	case t.Kind == KindInt && t.Width == 32 && t.Signed:
+		return "i"
+	case t.Kind == KindInt && t.Width == 32:
+		return "u"
+	case t.Kind == KindInt && t.Signed:
+		return fmt.Sprintf("i%d", t.Width)
+	case t.Kind == KindInt:
+		return fmt.Sprintf("u%d", t.Width)
+	}
+	return ""
+}
+
+// dimNames spells image dimensionalities as GLSL sampler suffixes.
+var dimNames = map[uint32]string{0: "1D", 1: "2D", 2: "3D", 3: "Cube", 4: "2DRect", DimBuffer: "Buffer"}
+
+// MarshalJSON encodes t as its String form, which keeps recursive types
+// finite and the output readable; struct layouts are in Block.Members.
+func (t *Type) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// EntryPoint is one OpEntryPoint of a module.
+type EntryPoint struct {
+	Name  string
+	Model ExecutionModel
+	Stage uint32 // vk.ShaderStage* bit; 0 for models without one
+	// WorkgroupSize is the LocalSize of a compute, task, or mesh entry point.
+	// WorkgroupSpecIDs holds, per axis, the SpecId that overrides it, or -1.
+	WorkgroupSize    [3]uint32
+	WorkgroupSpecIDs [3]int
+}
+
+// Block is a buffer or push-constant block: its struct layout.
+type Block struct {
+	Name    string
+	Size    uint32 // size of the fixed part; a runtime array adds to it
+	Members []Member
+}
+
+// Binding is one descriptor binding the module declares.
+type Binding struct {
+	Set, Binding uint32
+	Name         string
+	Type         vk.DescriptorType
+	// Count is the descriptor count, the array length of an arrayed resource;
+	// 0 means a runtime-sized array, which needs descriptor indexing.
+	Count  uint32
+	Stages uint32 // stages of the module's entry points
+	// Block is the layout of a uniform or storage buffer, nil otherwise.
+	Block *Block
+	// ReadOnly reports a storage buffer or image decorated NonWritable.
+	ReadOnly bool
+}
+
+// VertexInput is one input attribute of a vertex entry point. Matrix inputs
+// appear once per column. A 64-bit three- or four-component vector occupies
+// two locations, Location and Location+1; every other input occupies one.
+type VertexInput struct {
+	Location  uint32
+	Locations uint32 // locations occupied, 1 or 2
+	Name      string
+	Format    vk.Format
+	Size      uint32 // bytes per vertex
+}
+
+// SpecConstant is one specialization constant.
+type SpecConstant struct {
+	ID      uint32
+	Name    string
+	Type    *Type  // scalar: KindBool, KindInt, or KindFloat
+	Size    uint32 // bytes in a vk.Specialization (4 for booleans)
+	Default uint64 // raw bits of the default value; booleans are 0 or 1
+}
+
+// Reflection is the interface a module exposes to Vulkan. Resources are
+// gathered module-wide and attributed to the stages of all its entry points.
+type Reflection struct {
+	EntryPoints   []EntryPoint
+	Bindings      []Binding // sorted by set, then binding
+	PushConstants *Block    // nil if the module has none
+	PushStages    uint32
+	VertexInputs  []VertexInput // sorted by location; vertex entry points only
+	SpecConstants []SpecConstant
+}
+
+// Stages returns the vk.ShaderStage* bits of all entry points.
+func (r *Reflection) Stages() uint32 {
+	var s uint32
+	for _, e := range r.EntryPoints {
+		s |= e.Stage
+	}
+	return s
+}
+
+// Reflect parses code and reflects its interface.
+func Reflect(code []byte) (*Reflection, error) {
+	m, err := Parse(code)
+	if err != nil {
+		return nil, err
+	}
+	return m.Reflect()
+}
+
+// decoration is one OpDecorate or OpMemberDecorate.
+type decoration struct {
+	kind uint32
+	args []uint32
+}
+
+// reflector holds the module indexes built by Reflect.
+type reflector struct {
+	defs        map[uint32]Instruction // result id -> defining instruction
+	names       map[uint32]string
+	memberNames map[uint32]map[uint32]string
+	decos       map[uint32][]decoration
+	memberDecos map[uint32]map[uint32][]decoration
+	types       map[uint32]*Type
+}
+
+func (r *reflector) deco(id, kind uint32) ([]uint32, bool) {
+	for _, d := range r.decos[id] {
+		if d.kind == kind {
+			return d.args, true
+		}
+	}
+	return nil, false
+}
+
+func (r *reflector) decoValue(id, kind uint32) (uint32, bool) {
+	args, ok := r.deco(id, kind)
+	if !ok || len(args) == 0 {
+		return 0, ok
+	}
+	return args[0], true
+}
+
+func (r *reflector) memberDecoValue(id, member, kind uint32) (uint32, bool) {
+	for _, d := range r.memberDecos[id][member] {
+		if d.kind == kind {
+			if len(d.args) == 0 {
+				return 0, true
+			}
+			return d.args[0], true
+		}
+	}
+	return 0, false
+}
+
+// constant returns the low word of a scalar constant's value.
+func (r *reflector) constant(id uint32) (uint32, bool) {
+	inst, ok := r.defs[id]
+	if !ok {
+		return 0, false
+	}
+	switch inst.Op {
+	case OpConstant, OpSpecConstant:
+		if len(inst.Operands) >= 3 {
+			return inst.Operands[2], true
+		}
+	case OpConstantTrue, OpSpecConstantTrue:
+		return 1, true
+	case OpConstantFalse, OpSpecConstantFalse:
+		return 0, true
+	}
+	return 0, false
+}
+
+// typ resolves a type id, memoizing so recursive pointer types terminate.
+func (r *reflector) typ(id uint32) *Type {
+	if t, ok := r.types[id]; ok {
+		return t
+	}
+	t := &Type{Kind: KindOther, Name: r.names[id], id: id}
+	r.types[id] = t
+	inst, ok := r.defs[id]
+	if !ok || len(inst.Operands) < typeOperands[inst.Op] {
+		return t
+	}
+	ops := inst.Operands
+	switch inst.Op {
+	case OpTypeVoid:
+		t.Kind = KindVoid
+	case OpTypeBool:
+		t.Kind = KindBool
+	case OpTypeInt:
+		t.Kind, t.Width, t.Signed = KindInt, ops[1], ops[2] != 0
+	case OpTypeFloat:
+		t.Kind, t.Width = KindFloat, ops[1]
+	case OpTypeVector:
+		t.Kind, t.Elem, t.Len = KindVector, r.typ(ops[1]), ops[2]
+	case OpTypeMatrix:
+		t.Kind, t.Elem, t.Len = KindMatrix, r.typ(ops[1]), ops[2]
+	case OpTypeImage:
+		t.Kind, t.Elem, t.Dim, t.Sampled = KindImage, r.typ(ops[1]), ops[2], ops[6]
+	case OpTypeSampler:
+		t.Kind = KindSampler
+	case OpTypeSampledImage:
+		t.Kind, t.Elem = KindSampledImage, r.typ(ops[1])
+	case OpTypeAccelerationStructureKHR:
+		t.Kind = KindAccelerationStructure
+	case OpTypeArray:
+		t.Kind, t.Elem = KindArray, r.typ(ops[1])
+		t.Len, _ = r.constant(ops[2])
+		t.Stride, _ = r.decoValue(id, DecorationArrayStride)
+	case OpTypeRuntimeArray:
+		t.Kind, t.Elem = KindRuntimeArray, r.typ(ops[1])
+		t.Stride, _ = r.decoValue(id, DecorationArrayStride)
+	case OpTypeStruct:
+		t.Kind = KindStruct
+		for i, mid := range ops[1:] {
+			mt := r.typ(mid)
+			m := Member{Name: r.memberNames[id][uint32(i)], Type: mt}
+			m.Offset, _ = r.memberDecoValue(id, uint32(i), DecorationOffset)
+			m.MatrixStride, _ = r.memberDecoValue(id, uint32(i), DecorationMatrixStride)
+			m.Size = mt.size(m.MatrixStride)
+			t.Members = append(t.Members, m)
+		}
+	case OpTypePointer:
+		t.Kind, t.Storage, t.Elem = KindPointer, StorageClass(ops[1]), r.typ(ops[2])
+	}
+	return t
+}
+
+// typeOperands is the minimum operand count of each type declaration typ
+// decodes; shorter (malformed) declarations reflect as KindOther.
+var typeOperands = map[Op]int{
+	OpTypeInt:          3,
+	OpTypeFloat:        2,
+	OpTypeVector:       3,
+	OpTypeMatrix:       3,
+	OpTypeImage:        8,
+	OpTypeSampledImage: 2,
+	OpTypeArray:        3,
+	OpTypeRuntimeArray: 2,
+	OpTypeStruct:       1,
+	OpTypePointer:      3,
+}
+
+// stageOf maps an execution model to its vk.ShaderStage* bit.
+func stageOf(m ExecutionModel) uint32 {
+	switch m {
+	case ModelVertex:
+		return vk.ShaderStageVertex
+	case ModelTessellationControl:
+		return vk.ShaderStageTessControl
+	case ModelTessellationEvaluation:
+		return vk.ShaderStageTessEvaluation
+	case ModelGeometry:
+		return vk.ShaderStageGeometry
+	case ModelFragment:
+		return vk.ShaderStageFragment
+	case ModelGLCompute:
+		return vk.ShaderStageCompute
+	case ModelTaskEXT:
+		return 0x40 // VK_SHADER_STAGE_TASK_BIT_EXT
+	case ModelMeshEXT:
+		return 0x80 // VK_SHADER_STAGE_MESH_BIT_EXT
+	}
+	return 0
+}
+
+// Reflect extracts the module's interface.
+func (m *Module) Reflect() (*Reflection, error) {
+	r := &reflector{
+		defs:        map[uint32]Instruction{},
+		names:       map[uint32]string{},
+		memberNames: map[uint32]map[uint32]string{},
+		decos:       map[uint32][]decoration{},
+		memberDecos: map[uint32]map[uint32][]decoration{},
+		types:       map[uint32]*Type{},
+	}
+	var entries []Instruction
+	var modes []Instruction
+	var vars []Instruction
+	for _, inst := range m.Instructions {
+		ops := inst.Operands
+		switch inst.Op {
+		case OpName:
+			if len(ops) >= 1 {
+				r.names[ops[0]], _ = String(ops[1:])
+			}
+		case OpMemberName:
+			if len(ops) >= 2 {
+				if r.memberNames[ops[0]] == nil {
+					r.memberNames[ops[0]] = map[uint32]string{}
+				}
+				r.memberNames[ops[0]][ops[1]], _ = String(ops[2:])
+			}
+		case OpDecorate:
+			if len(ops) >= 2 {
+				r.decos[ops[0]] = append(r.decos[ops[0]], decoration{ops[1], ops[2:]})
+			}
+		case OpMemberDecorate:
+			if len(ops) >= 3 {
+				if r.memberDecos[ops[0]] == nil {
+					r.memberDecos[ops[0]] = map[uint32][]decoration{}
+				}
+				r.memberDecos[ops[0]][ops[1]] = append(r.memberDecos[ops[0]][ops[1]], decoration{ops[2], ops[3:]})
+			}
+		case OpEntryPoint:
+			entries = append(entries, inst)
+		case OpExecutionMode, OpExecutionModeID:
+			modes = append(modes, inst)
+		case OpTypeVoid, OpTypeBool, OpTypeInt, OpTypeFloat, OpTypeVector, OpTypeMatrix,
+			OpTypeImage, OpTypeSampler, OpTypeSampledImage, OpTypeArray, OpTypeRuntimeArray,
+			OpTypeStruct, OpTypePointer, OpTypeFunction, OpTypeAccelerationStructureKHR:
+			if len(ops) >= 1 {
+				r.defs[ops[0]] = inst
+			}
+		case OpConstantTrue, OpConstantFalse, OpConstant, OpConstantComposite,
+			OpSpecConstantTrue, OpSpecConstantFalse, OpSpecConstant, OpSpecConstantComposite:
+			if len(ops) >= 2 {
+				r.defs[ops[1]] = inst
+			}
+		case OpVariable:
+			if len(ops) >= 3 {
+				r.defs[ops[1]] = inst
+				vars = append(vars, inst)
+			}
+		}
+	}
+
+	out := &Reflection{}
+	if err := r.entryPoints(out, entries, modes); err != nil {
+		return nil, err
+	}
+	stages := out.Stages()
+	hasVertex := stages&vk.ShaderStageVertex != 0
+
+	for _, v := range vars {
+		id := v.Operands[1]
+		ptr := r.typ(v.Operands[0])
+		if ptr.Kind != KindPointer {
+			return nil, fmt.Errorf("spirv: variable %%%d does not have a pointer type", id)
+		}
+		switch StorageClass(v.Operands[2]) {
+		case StorageUniformConstant, StorageUniform, StorageStorageBuffer:
+			b, ok, err := r.binding(id, ptr)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				b.Stages = stages
+				out.Bindings = append(out.Bindings, b)
+			}
+		case StoragePushConstant:
+			if out.PushConstants != nil {
+				return nil, fmt.Errorf("spirv: more than one push constant block")
+			}
+			out.PushConstants = block(r.names[id], ptr.Elem)
+			out.PushStages = stages
+		case StorageInput:
+			if !hasVertex {
+				continue
+			}
+			if _, builtin := r.deco(id, DecorationBuiltIn); builtin || ptr.Elem.Kind == KindStruct {
+				continue
+			}
+			loc, ok := r.decoValue(id, DecorationLocation)
+			if !ok {
+				return nil, fmt.Errorf("spirv: vertex input %q has no Location", r.names[id])
+			}
+			inputs, err := vertexInputs(r.names[id], loc, ptr.Elem)
+			if err != nil {
+				return nil, err
+			}
+			out.VertexInputs = append(out.VertexInputs, inputs...)
+		}
+	}
+	sort.Slice(out.Bindings, func(i, j int) bool {
+		a, b := out.Bindings[i], out.Bindings[j]
+		return a.Set < b.Set || a.Set == b.Set && a.Binding < b.Binding
+	})
+	sort.Slice(out.VertexInputs, func(i, j int) bool { return out.VertexInputs[i].Location < out.VertexInputs[j].Location })
+
+	for id, inst := range r.defs {
+		switch inst.Op {
+		case OpSpecConstantTrue, OpSpecConstantFalse, OpSpecConstant:
+		default:
+			continue
+		}
+		specID, ok := r.decoValue(id, DecorationSpecID)
+		if !ok {
+			continue
+		}
+		t := r.typ(inst.Operands[0])
+		sc := SpecConstant{ID: specID, Name: r.names[id], Type: t, Size: t.size(0)}
+		switch inst.Op {
+		case OpSpecConstantTrue:
+			sc.Default = 1
+		case OpSpecConstant:
+			if len(inst.Operands) >= 3 {
+				sc.Default = uint64(inst.Operands[2])
+			}
+			if len(inst.Operands) >= 4 {
+				sc.Default |= uint64(inst.Operands[3]) << 32
+			}
+		}
+		out.SpecConstants = append(out.SpecConstants, sc)
+	}
+	sort.Slice(out.SpecConstants, func(i, j int) bool { return out.SpecConstants[i].ID < out.SpecConstants[j].ID })
+	return out, nil
+}
+
+// entryPoints fills out.EntryPoints, including compute workgroup sizes from
+// LocalSize, LocalSizeId, or a WorkgroupSize built-in constant.
+func (r *reflector) entryPoints(out *Reflection, entries, modes []Instruction) error {
+	// A constant decorated BuiltIn WorkgroupSize overrides LocalSize for every
+	// entry point.
+	var builtinSize []uint32
+	for id, inst := range r.defs {
+		if inst.Op != OpConstantComposite && inst.Op != OpSpecConstantComposite {
+			continue
+		}
+		if v, ok := r.decoValue(id, DecorationBuiltIn); ok && v == builtInWorkgroupSize {
+			builtinSize = inst.Operands[2:]
+		}
+	}
+	for _, inst := range entries {
+		ops := inst.Operands
+		if len(ops) < 3 {
+			return fmt.Errorf("spirv: malformed OpEntryPoint")
+		}
+		name, _ := String(ops[2:])
+		e := EntryPoint{Name: name, Model: ExecutionModel(ops[0]), WorkgroupSpecIDs: [3]int{-1, -1, -1}}
+		e.Stage = stageOf(e.Model)
+		fn := ops[1]
+		for _, mode := range modes {
+			mo := mode.Operands
+			if len(mo) < 5 || mo[0] != fn {
+				continue
+			}
+			switch {
+			case mode.Op == OpExecutionMode && mo[1] == executionModeLocalSize:
+				copy(e.WorkgroupSize[:], mo[2:5])
+			case mode.Op == OpExecutionModeID && mo[1] == executionModeLocalSizeID:
+				r.workgroupFromIDs(&e, mo[2:5])
+			}
+		}
+		if len(builtinSize) == 3 {
+			r.workgroupFromIDs(&e, builtinSize)
+		}
+		out.EntryPoints = append(out.EntryPoints, e)
+	}
+	return nil
+}
+
+// workgroupFromIDs sets e's workgroup size from three constant ids, noting
+// which are specialization constants.
+func (r *reflector) workgroupFromIDs(e *EntryPoint, ids []uint32) {
+	for axis, id := range ids {
+		e.WorkgroupSize[axis], _ = r.constant(id)
+		e.WorkgroupSpecIDs[axis] = -1
+		if sid, ok := r.decoValue(id, DecorationSpecID); ok {
+			e.WorkgroupSpecIDs[axis] = int(sid)
+		}
+	}
+}
+
+// binding reflects a resource variable. ok is false for variables without a
+// descriptor set and binding, which do not consume descriptors.
+func (r *reflector) binding(id uint32, ptr *Type) (Binding, bool, error) {
+	set, hasSet := r.decoValue(id, DecorationDescriptorSet)
+	bnd, hasBinding := r.decoValue(id, DecorationBinding)
+	if !hasSet && !hasBinding {
+		return Binding{}, false, nil
+	}
+	b := Binding{Set: set, Binding: bnd, Name: r.names[id], Count: 1}
+	t := ptr.Elem
+	switch t.Kind {
+	case KindArray:
+		b.Count, t = t.Len, t.Elem
+	case KindRuntimeArray:
+		b.Count, t = 0, t.Elem
+	}
+	_, b.ReadOnly = r.deco(id, DecorationNonWritable)
+	switch t.Kind {
+	case KindSampler:
+		b.Type = vk.DescriptorSampler
+	case KindSampledImage:
+		b.Type = vk.DescriptorCombinedImageSampler
+		if t.Elem.Dim == DimBuffer {
+			b.Type = vk.DescriptorUniformTexelBuffer
+		}
+	case KindImage:
+		switch {
+		case t.Dim == DimSubpassData:
+			b.Type = vk.DescriptorInputAttachment
+		case t.Dim == DimBuffer && t.Sampled == 2:
+			b.Type = vk.DescriptorStorageTexelBuffer
+		case t.Dim == DimBuffer:
+			b.Type = vk.DescriptorUniformTexelBuffer
+		case t.Sampled == 2:
+			b.Type = vk.DescriptorStorageImage
+		default:
+			b.Type = vk.DescriptorSampledImage
+		}
+	case KindAccelerationStructure:
+		b.Type = vk.DescriptorAccelerationStructure
+	case KindStruct:
+		_, bufferBlock := r.deco(t.id, DecorationBufferBlock)
+		if ptr.Storage == StorageStorageBuffer || bufferBlock {
+			b.Type = vk.DescriptorStorageBuffer
+			b.ReadOnly = b.ReadOnly || allMembersNonWritable(r, t)
+		} else {
+			b.Type = vk.DescriptorUniformBuffer
+		}
+		b.Block = block(b.Name, t)
+		b.Name = b.Block.Name
+	default:
+		return Binding{}, false, fmt.Errorf("spirv: binding %d.%d (%q) has unsupported type", set, bnd, b.Name)
+	}
+	return b, true, nil
+}
+
+// allMembersNonWritable reports whether every member of struct t is decorated
+// NonWritable, which is how glslang marks a readonly buffer block.
+func allMembersNonWritable(r *reflector, t *Type) bool {
+	for i := range t.Members {
+		if _, ok := r.memberDecoValue(t.id, uint32(i), DecorationNonWritable); !ok {
+			return false
+		}
+	}
+	return len(t.Members) > 0
+}
+
+// block describes struct t as a buffer block named name.
+func block(name string, t *Type) *Block {
+	if name == "" {
+		name = t.Name
+	}
+	return &Block{Name: name, Size: t.size(0), Members: t.Members}
+}
+
+// vertexInputs expands an input variable of type t at location loc into one
+// VertexInput per location.
+func vertexInputs(name string, loc uint32, t *Type) ([]VertexInput, error) {
+	switch t.Kind {
+	case KindMatrix:
+		f, size, err := vertexFormat(t.Elem)
+		if err != nil {
+			return nil, fmt.Errorf("spirv: vertex input %q: %w", name, err)
+		}
+		locs := vertexLocations(size)
+		out := make([]VertexInput, t.Len)
+		for i := range out {
+			out[i] = VertexInput{Location: loc + uint32(i)*locs, Locations: locs, Name: fmt.Sprintf("%s[%d]", name, i), Format: f, Size: size}
+		}
+		return out, nil
+	case KindArray:
+		var out []VertexInput
+		next := loc
+		for i := uint32(0); i < t.Len; i++ {
+			in, err := vertexInputs(fmt.Sprintf("%s[%d]", name, i), next, t.Elem)
+			if err != nil {
+				return nil, err
+			}
+			last := in[len(in)-1]
+			next = last.Location + last.Locations
+			out = append(out, in...)
+		}
+		return out, nil
+	}
+	f, size, err := vertexFormat(t)
+	if err != nil {
+		return nil, fmt.Errorf("spirv: vertex input %q: %w", name, err)
+	}
+	return []VertexInput{{Location: loc, Locations: vertexLocations(size), Name: name, Format: f, Size: size}}, nil
+}
+
+// vertexLocations returns the locations an input of size bytes occupies:
+// two for 64-bit three- and four-component vectors, one otherwise.
+func vertexLocations(size uint32) uint32 {
+	if size > 16 {
+		return 2
+	}
+	return 1
+}
+
+// vertexFormat maps a scalar or vector input type to a vk.Format.
+func vertexFormat(t *Type) (vk.Format, uint32, error) {
+	n, scalar := uint32(1), t
+	if t.Kind == KindVector {
+		n, scalar = t.Len, t.Elem
+	}
+	if n < 1 || n > 4 {
+		return 0, 0, fmt.Errorf("%d-component vector", n)
+	}
+	var formats [4]vk.Format
+	switch {
+	case scalar.Kind == KindFloat && scalar.Width == 32:
+		formats = [4]vk.Format{vk.FormatR32Sfloat, vk.FormatR32G32Sfloat, vk.FormatR32G32B32Sfloat, vk.FormatR32G32B32A32Sfloat}
+	case scalar.Kind == KindFloat && scalar.Width == 16:
+		formats = [4]vk.Format{vk.FormatR16Sfloat, vk.FormatR16G16Sfloat, vk.FormatR16G16B16Sfloat, vk.FormatR16G16B16A16Sfloat}
+	case scalar.Kind == KindFloat && scalar.Width == 64:
+		formats = [4]vk.Format{vk.FormatR64Sfloat, vk.FormatR64G64Sfloat, vk.FormatR64G64B64Sfloat, vk.FormatR64G64B64A64Sfloat}
+	case scalar.Kind == KindInt && scalar.Width == 32 && scalar.Signed:
+		formats = [4]vk.Format{vk.FormatR32Sint, vk.FormatR32G32Sint, vk.FormatR32G32B32Sint, vk.FormatR32G32B32A32Sint}
+	case scalar.Kind == KindInt && scalar.Width == 32:
+		formats = [4]vk.Format{vk.FormatR32Uint, vk.FormatR32G32Uint, vk.FormatR32G32B32Uint, vk.FormatR32G32B32A32Uint}
+	default:
+		return 0, 0, fmt.Errorf("unsupported component type")
+	}
+	return formats[n-1], n * scalar.Width / 8, nil
+}
@@ -0,0 +1,24 @@
+package spirv
+
+import "github.com/christerso/vulkan-go/vk"
+
+// Shader is a compiled shader together with the interface facts a pipeline
+// needs from it, reflected at build time. cmd/vkshaderc generates one per
+// shader source, so programs need not reflect at startup:
+//
+//	layout, _ := spirv.JoinLayouts(&terrainVert.Layout, &terrainFrag.Layout)
+//	setLayouts, pipelineLayout, _ := layout.Create(device)
+//	module, _ := device.CreateShaderModule(terrainVert.Code)
+type Shader struct {
+	Code  []byte // SPIR-V
+	Entry string // entry point name
+	Stage uint32 // vk.ShaderStage* bit of the entry point
+	// Layout is the resource interface of this stage alone.
+	Layout Layout
+	// VertexBinding and VertexAttributes feed the inputs of a vertex shader
+	// from one interleaved buffer at binding 0, as Reflection.VertexInput.
+	VertexBinding    vk.VertexInputBinding
+	VertexAttributes []vk.VertexInputAttribute
+	// WorkgroupSize is the LocalSize of a compute shader.
+	WorkgroupSize [3]uint32
+}
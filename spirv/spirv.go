@@ -0,0 +1,183 @@
+// Package spirv parses SPIR-V binaries in pure Go and reflects the interface a
+// shader exposes to Vulkan: entry points, descriptor bindings, push constants,
+// vertex inputs, specialization constants, and workgroup size. From that it
+// derives the vk descriptor set layouts, pipeline layouts, and vertex input
+// descriptions that would otherwise be written by hand to match the shader:
+//
+//	vert, _ := spirv.Reflect(vertSPV)
+//	frag, _ := spirv.Reflect(fragSPV)
+//	layout, _ := spirv.MergeLayouts(vert, frag)
+//	setLayouts, pipelineLayout, _ := layout.Create(device)
+//	binding, attrs := vert.VertexInput(0, vk.VertexInputRateVertex)
+//
+// Parse gives access to the raw instruction stream for tools.
+package spirv
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Magic is the SPIR-V magic number, the first word of every module.
+const Magic = 0x07230203
+
+// Op is a SPIR-V opcode.
+type Op uint16
+
+// Opcodes the parser, reflection, and disassembler interpret.
+const (
+	OpNop                          Op = 0
+	OpName                         Op = 5
+	OpMemberName                   Op = 6
+	OpString                       Op = 7
+	OpExtInstImport                Op = 11
+	OpExtInst                      Op = 12
+	OpEntryPoint                   Op = 15
+	OpExecutionMode                Op = 16
+	OpTypeVoid                     Op = 19
+	OpTypeBool                     Op = 20
+	OpTypeInt                      Op = 21
+	OpTypeFloat                    Op = 22
+	OpTypeVector                   Op = 23
+	OpTypeMatrix                   Op = 24
+	OpTypeImage                    Op = 25
+	OpTypeSampler                  Op = 26
+	OpTypeSampledImage             Op = 27
+	OpTypeArray                    Op = 28
+	OpTypeRuntimeArray             Op = 29
+	OpTypeStruct                   Op = 30
+	OpTypePointer                  Op = 32
+	OpTypeFunction                 Op = 33
+	OpTypeForwardPointer           Op = 39
+	OpConstantTrue                 Op = 41
+	OpConstantFalse                Op = 42
+	OpConstant                     Op = 43
+	OpConstantComposite            Op = 44
+	OpSpecConstantTrue             Op = 48
+	OpSpecConstantFalse            Op = 49
+	OpSpecConstant                 Op = 50
+	OpSpecConstantComposite        Op = 51
+	OpVariable                     Op = 59
+	OpDecorate                     Op = 71
+	OpMemberDecorate               Op = 72
+	OpExecutionModeID              Op = 331
+	OpDecorateID                   Op = 332
+	OpTypeAccelerationStructureKHR Op = 5341
+	OpDecorateString               Op = 5632
+	OpMemberDecorateString         Op = 5633
+)
+
+// Decoration values (SpvDecoration) the reflection reads.
+const (
+	DecorationSpecID        = 1
+	DecorationBlock         = 2
+	DecorationBufferBlock   = 3
+	DecorationArrayStride   = 6
+	DecorationMatrixStride  = 7
+	DecorationBuiltIn       = 11
+	DecorationNonWritable   = 24
+	DecorationLocation      = 30
+	DecorationBinding       = 33
+	DecorationDescriptorSet = 34
+	DecorationOffset        = 35
+)
+
+// StorageClass is a SPIR-V storage class.
+type StorageClass uint32
+
+// Storage classes the reflection and builder distinguish.
+const (
+	StorageUniformConstant       StorageClass = 0
+	StorageInput                 StorageClass = 1
+	StorageUniform               StorageClass = 2
+	StorageOutput                StorageClass = 3
+	StorageWorkgroup             StorageClass = 4
+	StorageFunction              StorageClass = 7
+	StoragePushConstant          StorageClass = 9
+	StorageImage                 StorageClass = 11
+	StorageStorageBuffer         StorageClass = 12
+	StoragePhysicalStorageBuffer StorageClass = 5349
+)
+
+// ExecutionModel is the shader stage an entry point is for.
+type ExecutionModel uint32
+
+// Execution models.
+const (
+	ModelVertex                 ExecutionModel = 0
+	ModelTessellationControl    ExecutionModel = 1
+	ModelTessellationEvaluation ExecutionModel = 2
+	ModelGeometry               ExecutionModel = 3
+	ModelFragment               ExecutionModel = 4
+	ModelGLCompute              ExecutionModel = 5
+	ModelTaskEXT                ExecutionModel = 5364
+	ModelMeshEXT                ExecutionModel = 5365
+)
+
+// Execution modes and built-ins used for workgroup size.
+const (
+	executionModeLocalSize   = 17
+	executionModeLocalSizeID = 38
+	builtInWorkgroupSize     = 25
+)
+
+// Instruction is one decoded instruction: its opcode and the operand words
+// that follow the opcode word.
+type Instruction struct {
+	Op       Op
+	Operands []uint32
+}
+
+// Module is a parsed SPIR-V module.
+type Module struct {
+	Version      uint32 // 0x00MMmm00: major MM, minor mm
+	Generator    uint32
+	Bound        uint32 // every result id is below Bound
+	Instructions []Instruction
+}
+
+// Parse decodes a SPIR-V binary of either byte order into its instructions.
+// It checks the header and instruction framing only; it does not validate the
+// module's semantics.
+func Parse(code []byte) (*Module, error) {
+	if len(code) < 20 || len(code)%4 != 0 {
+		return nil, fmt.Errorf("spirv: length %d is not a multiple of 4 holding a header", len(code))
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if order.Uint32(code) != Magic {
+		order = binary.BigEndian
+		if order.Uint32(code) != Magic {
+			return nil, fmt.Errorf("spirv: bad magic number %#08x", binary.LittleEndian.Uint32(code))
+		}
+	}
+	words := make([]uint32, len(code)/4)
+	for i := range words {
+		words[i] = order.Uint32(code[4*i:])
+	}
+	m := &Module{Version: words[1], Generator: words[2], Bound: words[3]}
+	for i := 5; i < len(words); {
+		n := int(words[i] >> 16)
+		if n == 0 || i+n > len(words) {
+			return nil, fmt.Errorf("spirv: malformed instruction at word %d", i)
+		}
+		m.Instructions = append(m.Instructions, Instruction{Op: Op(words[i] & 0xFFFF), Operands: words[i+1 : i+n]})
+		i += n
+	}
+	return m, nil
+}
+
+// String decodes the NUL-terminated literal string starting at words[0] and
+// returns it with the number of words it occupies.
+func String(words []uint32) (string, int) {
+	var b []byte
+	for i, w := range words {
+		for j := 0; j < 4; j++ {
+			c := byte(w >> (8 * j))
+			if c == 0 {
+				return string(b), i + 1
+			}
+			b = append(b, c)
+		}
+	}
+	return string(b), len(words)
+}
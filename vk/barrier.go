@@ -0,0 +1,364 @@
+package vk
+
+import (
+	"runtime"
+	"unsafe"
+
+	vulkan "github.com/christerso/vulkan-go/vulkan"
+)
+
+// Pipeline stage flag bits of synchronization2 (VkPipelineStageFlagBits2).
+const (
+	Stage2None                    uint64 = 0
+	Stage2TopOfPipe               uint64 = 0x00000001
+	Stage2DrawIndirect            uint64 = 0x00000002
+	Stage2VertexInput             uint64 = 0x00000004
+	Stage2VertexShader            uint64 = 0x00000008
+	Stage2TessControlShader       uint64 = 0x00000010
+	Stage2TessEvaluationShader    uint64 = 0x00000020
+	Stage2GeometryShader          uint64 = 0x00000040
+	Stage2FragmentShader          uint64 = 0x00000080
+	Stage2EarlyFragmentTests      uint64 = 0x00000100
+	Stage2LateFragmentTests       uint64 = 0x00000200
+	Stage2ColorAttachmentOutput   uint64 = 0x00000400
+	Stage2ComputeShader           uint64 = 0x00000800
+	Stage2AllTransfer             uint64 = 0x00001000
+	Stage2BottomOfPipe            uint64 = 0x00002000
+	Stage2Host                    uint64 = 0x00004000
+	Stage2AllGraphics             uint64 = 0x00008000
+	Stage2AllCommands             uint64 = 0x00010000
+	Stage2Copy                    uint64 = 0x100000000
+	Stage2Resolve                 uint64 = 0x200000000
+	Stage2Blit                    uint64 = 0x400000000
+	Stage2Clear                   uint64 = 0x800000000
+	Stage2IndexInput              uint64 = 0x1000000000
+	Stage2VertexAttributeInput    uint64 = 0x2000000000
+	Stage2PreRasterizationShaders uint64 = 0x4000000000
+)
+
+// Access flag bits of synchronization2 (VkAccessFlagBits2).
+const (
+	Access2None                        uint64 = 0
+	Access2IndirectCommandRead         uint64 = 0x00000001
+	Access2IndexRead                   uint64 = 0x00000002
+	Access2VertexAttributeRead         uint64 = 0x00000004
+	Access2UniformRead                 uint64 = 0x00000008
+	Access2InputAttachmentRead         uint64 = 0x00000010
+	Access2ShaderRead                  uint64 = 0x00000020
+	Access2ShaderWrite                 uint64 = 0x00000040
+	Access2ColorAttachmentRead         uint64 = 0x00000080
+	Access2ColorAttachmentWrite        uint64 = 0x00000100
+	Access2DepthStencilAttachmentRead  uint64 = 0x00000200
+	Access2DepthStencilAttachmentWrite uint64 = 0x00000400
+	Access2TransferRead                uint64 = 0x00000800
+	Access2TransferWrite               uint64 = 0x00001000
+	Access2HostRead                    uint64 = 0x00002000
+	Access2HostWrite                   uint64 = 0x00004000
+	Access2MemoryRead                  uint64 = 0x00008000
+	Access2MemoryWrite                 uint64 = 0x00010000
+	Access2ShaderSampledRead           uint64 = 0x100000000
+	Access2ShaderStorageRead           uint64 = 0x200000000
+	Access2ShaderStorageWrite          uint64 = 0x400000000
+)
+
+// QueueFamilyIgnored maps to VK_QUEUE_FAMILY_IGNORED.
+const QueueFamilyIgnored uint32 = 0xFFFFFFFF
+
+// remaining maps to VK_REMAINING_MIP_LEVELS and VK_REMAINING_ARRAY_LAYERS.
+const remaining uint32 = 0xFFFFFFFF
+
+// ImageRange selects mip levels and array layers of one or more aspects of an
+// image. The zero value is every level and layer of the color aspect.
+type ImageRange struct {
+	Aspect    uint32 // Aspect* bits; 0 means AspectColor
+	BaseMip   uint32
+	Levels    uint32 // 0 means every level from BaseMip on
+	BaseLayer uint32
+	Layers    uint32 // 0 means every layer from BaseLayer on
+}
+
+func (r ImageRange) vk() vulkan.VkImageSubresourceRange {
+	out := vulkan.VkImageSubresourceRange{
+		AspectMask:     r.Aspect,
+		BaseMipLevel:   r.BaseMip,
+		LevelCount:     r.Levels,
+		BaseArrayLayer: r.BaseLayer,
+		LayerCount:     r.Layers,
+	}
+	if out.AspectMask == 0 {
+		out.AspectMask = AspectColor
+	}
+	if out.LevelCount == 0 {
+		out.LevelCount = remaining
+	}
+	if out.LayerCount == 0 {
+		out.LayerCount = remaining
+	}
+	return out
+}
+
+// ResourceState is how a resource is used on one side of a barrier: the
+// stages that touch it, their accesses, and, for images, the layout they
+// need. Buffers ignore Layout.
+type ResourceState struct {
+	Stage  uint64 // Stage2* bits
+	Access uint64 // Access2* bits
+	Layout ImageLayout
+}
+
+// Common resource states, spelled out once so barriers do not have to be.
+var (
+	// StateUndefined is the state of a fresh image, or of one whose contents
+	// may be discarded.
+	StateUndefined = ResourceState{}
+	// StateTransferSrc and StateTransferDst are the source and destination
+	// of copies, blits, and clears.
+	StateTransferSrc = ResourceState{Stage2AllTransfer, Access2TransferRead, LayoutTransferSrcOptimal}
+	StateTransferDst = ResourceState{Stage2AllTransfer, Access2TransferWrite, LayoutTransferDstOptimal}
+	// StateSampledFragment and StateSampledCompute are images sampled, or
+	// uniform texel buffers read, by fragment or compute shaders.
+	StateSampledFragment = ResourceState{Stage2FragmentShader, Access2ShaderSampledRead, LayoutShaderReadOnlyOptimal}
+	StateSampledCompute  = ResourceState{Stage2ComputeShader, Access2ShaderSampledRead, LayoutShaderReadOnlyOptimal}
+	// StateStorageCompute is a storage image or buffer read and written by
+	// compute shaders.
+	StateStorageCompute = ResourceState{Stage2ComputeShader, Access2ShaderStorageRead | Access2ShaderStorageWrite, LayoutGeneral}
+	// StateUniformVertex is a uniform buffer read by vertex shaders.
+	StateUniformVertex = ResourceState{Stage2VertexShader, Access2UniformRead, LayoutUndefined}
+	// StateVertexInput is a vertex or index buffer.
+	StateVertexInput = ResourceState{Stage2VertexInput, Access2VertexAttributeRead | Access2IndexRead, LayoutUndefined}
+	// StateColorAttachment is a color attachment that is rendered to.
+	StateColorAttachment = ResourceState{Stage2ColorAttachmentOutput, Access2ColorAttachmentRead | Access2ColorAttachmentWrite, LayoutColorAttachmentOptimal}
+	// StateDepthAttachment is a depth/stencil attachment that is tested and
+	// written.
+	StateDepthAttachment = ResourceState{Stage2EarlyFragmentTests | Stage2LateFragmentTests, Access2DepthStencilAttachmentRead | Access2DepthStencilAttachmentWrite, LayoutDepthStencilAttachmentOptimal}
+	// StatePresent is a swapchain image handed to the presentation engine,
+	// which the present semaphore orders, so no stage or access is needed.
+	StatePresent = ResourceState{Stage2None, Access2None, LayoutPresentSrcKHR}
+)
+
+// MemoryBarrier2 mirrors VkMemoryBarrier2: a global dependency covering
+// every buffer and image.
+type MemoryBarrier2 struct {
+	SrcStage, SrcAccess uint64
+	DstStage, DstAccess uint64
+}
+
+// BufferBarrier2 mirrors VkBufferMemoryBarrier2. Size 0 means WholeSize.
+// Equal queue families, such as the zero value, mean no ownership transfer.
+type BufferBarrier2 struct {
+	SrcStage, SrcAccess            uint64
+	DstStage, DstAccess            uint64
+	SrcQueueFamily, DstQueueFamily uint32
+	Buffer                         Buffer
+	Offset, Size                   DeviceSize
+}
+
+// ImageBarrier2 mirrors VkImageMemoryBarrier2. Equal queue families, such as
+// the zero value, mean no ownership transfer.
+type ImageBarrier2 struct {
+	SrcStage, SrcAccess            uint64
+	DstStage, DstAccess            uint64
+	OldLayout, NewLayout           ImageLayout
+	SrcQueueFamily, DstQueueFamily uint32
+	Image                          Image
+	Range                          ImageRange
+}
+
+// queueFamilies returns the families of a barrier, both ignored when there
+// is no ownership transfer.
+func queueFamilies(src, dst uint32) (uint32, uint32) {
+	if src == dst {
+		return QueueFamilyIgnored, QueueFamilyIgnored
+	}
+	return src, dst
+}
+
+// DependencyInfo collects memory, buffer, and image barriers and records them
+// with a single vkCmdPipelineBarrier2 (CommandBuffer.PipelineBarrier2). The
+// zero value is ready to use, and it can be reused once recorded.
+//
+//	var dep vk.DependencyInfo
+//	dep.Transition(tex.Image, vk.ImageRange{}, vk.StateTransferDst, vk.StateSampledFragment).
+//		Transition(target, vk.ImageRange{}, vk.StateUndefined, vk.StateColorAttachment)
+//	cmd.PipelineBarrier2(&dep)
+type DependencyInfo struct {
+	Flags   uint32 // Dependency* bits
+	memory  []vulkan.VkMemoryBarrier2
+	buffers []vulkan.VkBufferMemoryBarrier2
+	images  []vulkan.VkImageMemoryBarrier2
+}
+
+// Memory adds a global memory barrier.
+func (d *DependencyInfo) Memory(b MemoryBarrier2) *DependencyInfo {
+	d.memory = append(d.memory, vulkan.VkMemoryBarrier2{
+		SType:         vulkan.VkStructureType(stMemoryBarrier2),
+		SrcStageMask:  b.SrcStage,
+		SrcAccessMask: b.SrcAccess,
+		DstStageMask:  b.DstStage,
+		DstAccessMask: b.DstAccess,
+	})
+	return d
+}
+
+// Buffer adds a buffer memory barrier.
+func (d *DependencyInfo) Buffer(b BufferBarrier2) *DependencyInfo {
+	size := b.Size
+	if size == 0 {
+		size = WholeSize
+	}
+	src, dst := queueFamilies(b.SrcQueueFamily, b.DstQueueFamily)
+	d.buffers = append(d.buffers, vulkan.VkBufferMemoryBarrier2{
+		SType:               vulkan.VkStructureType(stBufferMemoryBarrier2),
+		SrcStageMask:        b.SrcStage,
+		SrcAccessMask:       b.SrcAccess,
+		DstStageMask:        b.DstStage,
+		DstAccessMask:       b.DstAccess,
+		SrcQueueFamilyIndex: src,
+		DstQueueFamilyIndex: dst,
+		Buffer:              vulkan.VkBuffer(b.Buffer),
+		Offset:              vulkan.VkDeviceSize(b.Offset),
+		Size:                vulkan.VkDeviceSize(size),
+	})
+	return d
+}
+
+// Image adds an image memory barrier.
+func (d *DependencyInfo) Image(b ImageBarrier2) *DependencyInfo {
+	src, dst := queueFamilies(b.SrcQueueFamily, b.DstQueueFamily)
+	d.images = append(d.images, vulkan.VkImageMemoryBarrier2{
+		SType:               vulkan.VkStructureType(stImageMemoryBarrier2),
+		SrcStageMask:        b.SrcStage,
+		SrcAccessMask:       b.SrcAccess,
+		DstStageMask:        b.DstStage,
+		DstAccessMask:       b.DstAccess,
+		OldLayout:           vulkan.VkImageLayout(b.OldLayout),
+		NewLayout:           vulkan.VkImageLayout(b.NewLayout),
+		SrcQueueFamilyIndex: src,
+		DstQueueFamilyIndex: dst,
+		Image:               vulkan.VkImage(b.Image),
+		SubresourceRange:    b.Range.vk(),
+	})
+	return d
+}
+
+// Transition adds an image barrier from one state to another, changing the
+// layout if the states' layouts differ.
+func (d *DependencyInfo) Transition(img Image, rng ImageRange, from, to ResourceState) *DependencyInfo {
+	return d.Image(ImageBarrier2{
+		SrcStage: from.Stage, SrcAccess: from.Access,
+		DstStage: to.Stage, DstAccess: to.Access,
+		OldLayout: from.Layout, NewLayout: to.Layout,
+		Image: img, Range: rng,
+	})
+}
+
+// BufferTransition adds a barrier ordering the from use of size bytes of buf
+// at offset (size 0 means the rest of the buffer) before the to use.
+func (d *DependencyInfo) BufferTransition(buf Buffer, offset, size DeviceSize, from, to ResourceState) *DependencyInfo {
+	return d.Buffer(BufferBarrier2{
+		SrcStage: from.Stage, SrcAccess: from.Access,
+		DstStage: to.Stage, DstAccess: to.Access,
+		Buffer: buf, Offset: offset, Size: size,
+	})
+}
+
+// TransferDstToShaderRead adds the transition of an uploaded image to
+// sampling by fragment and compute shaders.
+func (d *DependencyInfo) TransferDstToShaderRead(img Image, rng ImageRange) *DependencyInfo {
+	to := StateSampledFragment
+	to.Stage |= Stage2ComputeShader
+	return d.Transition(img, rng, StateTransferDst, to)
+}
+
+// ColorAttachmentToPresent adds the transition of a rendered swapchain image
+// to presentation.
+func (d *DependencyInfo) ColorAttachmentToPresent(img Image) *DependencyInfo {
+	return d.Transition(img, ImageRange{}, StateColorAttachment, StatePresent)
+}
+
+// ReleaseImage adds the release half of moving img from srcFamily to
+// dstFamily, making from's writes available and changing the layout to
+// to.Layout. A queue family ownership transfer takes two barriers with the
+// same families and layouts: the release recorded on a queue of srcFamily,
+// then, after a semaphore wait, the acquire (AcquireImage) recorded on a
+// queue of dstFamily, so pass the same arguments to both.
+func (d *DependencyInfo) ReleaseImage(img Image, rng ImageRange, from, to ResourceState, srcFamily, dstFamily uint32) *DependencyInfo {
+	return d.Image(ImageBarrier2{
+		SrcStage: from.Stage, SrcAccess: from.Access,
+		OldLayout: from.Layout, NewLayout: to.Layout,
+		SrcQueueFamily: srcFamily, DstQueueFamily: dstFamily,
+		Image: img, Range: rng,
+	})
+}
+
+// AcquireImage adds the acquire half of moving img from srcFamily to
+// dstFamily, making it visible to the to use.
+func (d *DependencyInfo) AcquireImage(img Image, rng ImageRange, from, to ResourceState, srcFamily, dstFamily uint32) *DependencyInfo {
+	return d.Image(ImageBarrier2{
+		DstStage: to.Stage, DstAccess: to.Access,
+		OldLayout: from.Layout, NewLayout: to.Layout,
+		SrcQueueFamily: srcFamily, DstQueueFamily: dstFamily,
+		Image: img, Range: rng,
+	})
+}
+
+// ReleaseBuffer adds the release half of moving size bytes of buf at offset
+// from srcFamily to dstFamily.
+func (d *DependencyInfo) ReleaseBuffer(buf Buffer, offset, size DeviceSize, from ResourceState, srcFamily, dstFamily uint32) *DependencyInfo {
+	return d.Buffer(BufferBarrier2{
+		SrcStage: from.Stage, SrcAccess: from.Access,
+		SrcQueueFamily: srcFamily, DstQueueFamily: dstFamily,
+		Buffer: buf, Offset: offset, Size: size,
+	})
+}
+
+// AcquireBuffer adds the acquire half of moving size bytes of buf at offset
+// from srcFamily to dstFamily.
+func (d *DependencyInfo) AcquireBuffer(buf Buffer, offset, size DeviceSize, to ResourceState, srcFamily, dstFamily uint32) *DependencyInfo {
+	return d.Buffer(BufferBarrier2{
+		DstStage: to.Stage, DstAccess: to.Access,
+		SrcQueueFamily: srcFamily, DstQueueFamily: dstFamily,
+		Buffer: buf, Offset: offset, Size: size,
+	})
+}
+
+// Len returns the number of barriers added.
+func (d *DependencyInfo) Len() int { return len(d.memory) + len(d.buffers) + len(d.images) }
+
+// Reset drops the barriers added, keeping Flags.
+func (d *DependencyInfo) Reset() {
+	d.memory = d.memory[:0]
+	d.buffers = d.buffers[:0]
+	d.images = d.images[:0]
+}
+
+// PipelineBarrier2 records every barrier of dep in one vkCmdPipelineBarrier2
+// and resets dep. An empty dep records nothing. Needs
+// DeviceConfig.Synchronization2.
+func (c CommandBuffer) PipelineBarrier2(dep *DependencyInfo) {
+	if dep.Len() == 0 {
+		return
+	}
+	di := vulkan.VkDependencyInfo{
+		SType:                    vulkan.VkStructureType(stDependencyInfo),
+		DependencyFlags:          dep.Flags,
+		MemoryBarrierCount:       uint32(len(dep.memory)),
+		BufferMemoryBarrierCount: uint32(len(dep.buffers)),
+		ImageMemoryBarrierCount:  uint32(len(dep.images)),
+	}
+	if len(dep.memory) > 0 {
+		di.PMemoryBarriers = unsafe.Pointer(&dep.memory[0])
+	}
+	if len(dep.buffers) > 0 {
+		di.PBufferMemoryBarriers = unsafe.Pointer(&dep.buffers[0])
+	}
+	if len(dep.images) > 0 {
+		di.PImageMemoryBarriers = unsafe.Pointer(&dep.images[0])
+	}
+	vulkan.VkCmdPipelineBarrier2(vulkan.VkCommandBuffer(c), unsafe.Pointer(&di))
+	runtime.KeepAlive(&di)
+	runtime.KeepAlive(dep.memory)
+	runtime.KeepAlive(dep.buffers)
+	runtime.KeepAlive(dep.images)
+	dep.Reset()
+}
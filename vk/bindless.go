@@ -0,0 +1,273 @@
+package vk
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Bindings of the descriptor set of a BindlessTable. Shaders declare them as
+// runtime arrays indexed with the values Add* return, for example
+//
+//	layout(set = 0, binding = 0) uniform texture2D textures[];
+//	layout(set = 0, binding = 2) uniform sampler samplers[];
+//	texture(sampler2D(textures[nonuniformEXT(i)], samplers[s]), uv)
+const (
+	BindlessSampledImages  uint32 = 0
+	BindlessStorageImages  uint32 = 1
+	BindlessSamplers       uint32 = 2
+	BindlessStorageBuffers uint32 = 3
+)
+
+// ErrBindlessFull is returned by the BindlessTable Add methods when every
+// slot of the array is taken or still in use by frames in flight.
+var ErrBindlessFull = errors.New("vk: bindless table full")
+
+// BindlessConfig sizes the arrays of a BindlessTable. An array of capacity 0
+// is declared but cannot hold descriptors.
+type BindlessConfig struct {
+	SampledImages  uint32
+	StorageImages  uint32
+	Samplers       uint32
+	StorageBuffers uint32
+	// Stages holds the ShaderStage* bits that may access the table; 0 means
+	// all graphics stages and compute.
+	Stages uint32
+}
+
+// bindlessArray hands out the slots of one binding: fresh ones in order,
+// then released ones once they are reclaimed.
+type bindlessArray struct {
+	t        DescriptorType
+	capacity uint32
+	next     uint32   // slots below next have been handed out at least once
+	free     []uint32 // reclaimed slots
+	live     []uint64 // bit i is set while slot i is handed out
+}
+
+func (a *bindlessArray) alloc() (uint32, bool) {
+	var i uint32
+	if n := len(a.free); n > 0 {
+		i = a.free[n-1]
+		a.free = a.free[:n-1]
+	} else if a.next < a.capacity {
+		i = a.next
+		a.next++
+	} else {
+		return 0, false
+	}
+	for int(i/64) >= len(a.live) {
+		a.live = append(a.live, 0)
+	}
+	a.live[i/64] |= 1 << (i % 64)
+	return i, true
+}
+
+// release marks slot i no longer handed out. It reports false if i was not
+// live: never allocated, or already released.
+func (a *bindlessArray) release(i uint32) bool {
+	if int(i/64) >= len(a.live) || a.live[i/64]&(1<<(i%64)) == 0 {
+		return false
+	}
+	a.live[i/64] &^= 1 << (i % 64)
+	return true
+}
+
+// bindlessSlot names one released descriptor.
+type bindlessSlot struct {
+	binding, index uint32
+}
+
+// bindlessFrame holds the slots released before an EndFrame, reusable once
+// fence signals.
+type bindlessFrame struct {
+	fence Fence
+	slots []bindlessSlot
+}
+
+// BindlessTable is a single descriptor set of large, partially bound
+// descriptor arrays (sampled images, storage images, samplers, and storage
+// buffers) that stays bound for a whole frame while resources come and go.
+// Each Add returns a stable index into its array. Released indices are reused
+// only after the fence of every frame that might still read them has
+// signaled, and new descriptors are only ever written to slots no pending
+// submission uses, so the set never needs to be rebuilt or rebound.
+//
+// Add queues the descriptor write; Update applies the queued writes in one
+// call and must run before submitting work that uses the new indices. The
+// table needs DeviceConfig.DescriptorIndexing. A BindlessTable is not safe
+// for concurrent use.
+type BindlessTable struct {
+	device   Device
+	layout   DescriptorSetLayout
+	pool     DescriptorPool
+	set      DescriptorSet
+	arrays   [4]bindlessArray // indexed by binding
+	writes   DescriptorSetBuilder
+	released []bindlessSlot // since the last EndFrame
+	pending  []bindlessFrame
+}
+
+// CreateBindlessTable creates the table's layout, pool, and set.
+func (d Device) CreateBindlessTable(cfg BindlessConfig) (*BindlessTable, error) {
+	stages := cfg.Stages
+	if stages == 0 {
+		stages = ShaderStageAllGraphics | ShaderStageCompute
+	}
+	t := &BindlessTable{device: d}
+	t.arrays[BindlessSampledImages] = bindlessArray{t: DescriptorSampledImage, capacity: cfg.SampledImages}
+	t.arrays[BindlessStorageImages] = bindlessArray{t: DescriptorStorageImage, capacity: cfg.StorageImages}
+	t.arrays[BindlessSamplers] = bindlessArray{t: DescriptorSampler, capacity: cfg.Samplers}
+	t.arrays[BindlessStorageBuffers] = bindlessArray{t: DescriptorStorageBuffer, capacity: cfg.StorageBuffers}
+
+	const flags = DescriptorBindingPartiallyBound | DescriptorBindingUpdateAfterBind | DescriptorBindingUpdateUnusedWhilePending
+	bindings := make([]DescriptorBinding, len(t.arrays))
+	sizes := map[DescriptorType]uint32{}
+	for i, a := range t.arrays {
+		bindings[i] = DescriptorBinding{Binding: uint32(i), Type: a.t, Count: a.capacity, Stages: stages, Flags: flags}
+		if a.capacity > 0 {
+			sizes[a.t] = a.capacity
+		}
+	}
+	if len(sizes) == 0 {
+		return nil, fmt.Errorf("vk: bindless table with no capacity")
+	}
+	var err error
+	if t.layout, err = d.CreateDescriptorSetLayout(bindings); err != nil {
+		return nil, err
+	}
+	if t.pool, err = d.createDescriptorPool(1, sizes, DescriptorPoolCreateUpdateAfterBind); err != nil {
+		t.Destroy()
+		return nil, err
+	}
+	if t.set, err = d.AllocateDescriptorSet(t.pool, t.layout); err != nil {
+		t.Destroy()
+		return nil, err
+	}
+	return t, nil
+}
+
+// Destroy destroys the table's pool and layout. The caller must ensure no
+// submitted work still uses the set.
+func (t *BindlessTable) Destroy() {
+	t.device.DestroyDescriptorPool(t.pool)
+	t.device.DestroyDescriptorSetLayout(t.layout)
+	*t = BindlessTable{}
+}
+
+// Layout returns the set layout, for building pipeline layouts.
+func (t *BindlessTable) Layout() DescriptorSetLayout { return t.layout }
+
+// Set returns the descriptor set to bind.
+func (t *BindlessTable) Set() DescriptorSet { return t.set }
+
+// alloc takes a slot of binding, reclaiming signaled frames if it is full.
+func (t *BindlessTable) alloc(binding uint32) (uint32, error) {
+	a := &t.arrays[binding]
+	if i, ok := a.alloc(); ok {
+		return i, nil
+	}
+	if err := t.Reclaim(); err != nil {
+		return 0, err
+	}
+	if i, ok := a.alloc(); ok {
+		return i, nil
+	}
+	return 0, ErrBindlessFull
+}
+
+// AddSampledImage adds a sampled image view in layout (0 means
+// LayoutShaderReadOnlyOptimal) and returns its index.
+func (t *BindlessTable) AddSampledImage(view ImageView, layout ImageLayout) (uint32, error) {
+	i, err := t.alloc(BindlessSampledImages)
+	if err != nil {
+		return 0, err
+	}
+	t.writes.Images(t.set, BindlessSampledImages, i, DescriptorSampledImage, []DescriptorImageInfo{{View: view, Layout: layout}})
+	return i, nil
+}
+
+// AddStorageImage adds a storage image view, in LayoutGeneral, and returns
+// its index.
+func (t *BindlessTable) AddStorageImage(view ImageView) (uint32, error) {
+	i, err := t.alloc(BindlessStorageImages)
+	if err != nil {
+		return 0, err
+	}
+	t.writes.Images(t.set, BindlessStorageImages, i, DescriptorStorageImage, []DescriptorImageInfo{{View: view}})
+	return i, nil
+}
+
+// AddSampler adds a sampler and returns its index.
+func (t *BindlessTable) AddSampler(s Sampler) (uint32, error) {
+	i, err := t.alloc(BindlessSamplers)
+	if err != nil {
+		return 0, err
+	}
+	t.writes.Images(t.set, BindlessSamplers, i, DescriptorSampler, []DescriptorImageInfo{{Sampler: s}})
+	return i, nil
+}
+
+// AddStorageBuffer adds size bytes of buf from offset (size 0 means the
+// rest of the buffer) and returns its index.
+func (t *BindlessTable) AddStorageBuffer(buf Buffer, offset, size DeviceSize) (uint32, error) {
+	i, err := t.alloc(BindlessStorageBuffers)
+	if err != nil {
+		return 0, err
+	}
+	t.writes.Buffers(t.set, BindlessStorageBuffers, i, DescriptorStorageBuffer, []DescriptorBufferInfo{{Buffer: buf, Offset: offset, Range: size}})
+	return i, nil
+}
+
+// Release gives back index of the array at binding (one of the Bindless*
+// constants). The slot is reused once the frames in flight at the next
+// EndFrame have finished; until then shaders may still read the old
+// descriptor, so the resource it names must outlive those frames too.
+// Releasing a slot that is not handed out, including one released before,
+// is an error.
+func (t *BindlessTable) Release(binding, index uint32) error {
+	if binding >= uint32(len(t.arrays)) || !t.arrays[binding].release(index) {
+		return fmt.Errorf("vk: release of unallocated bindless slot %d.%d", binding, index)
+	}
+	t.released = append(t.released, bindlessSlot{binding: binding, index: index})
+	return nil
+}
+
+// Update writes every descriptor added since the last Update in one
+// vkUpdateDescriptorSets call.
+func (t *BindlessTable) Update() {
+	t.writes.Update(t.device)
+}
+
+// EndFrame retires the slots released since the previous EndFrame against
+// fence, the fence of the frame's last submission using the table. They are
+// reused once fence is observed signaled.
+func (t *BindlessTable) EndFrame(fence Fence) {
+	if len(t.released) == 0 {
+		return
+	}
+	t.pending = append(t.pending, bindlessFrame{fence: fence, slots: t.released})
+	t.released = nil
+}
+
+// Reclaim makes the slots of retired frames whose fences have signaled
+// reusable, oldest first, without blocking. As with StagingRing.Reclaim it
+// stops at the first fence that is not yet signaled.
+func (t *BindlessTable) Reclaim() error {
+	n := 0
+	for _, f := range t.pending {
+		ok, err := t.device.FenceSignaled(f.fence)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		for _, s := range f.slots {
+			a := &t.arrays[s.binding]
+			a.free = append(a.free, s.index)
+		}
+		n++
+	}
+	t.pending = append(t.pending[:0], t.pending[n:]...)
+	return nil
+}
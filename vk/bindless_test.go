@@ -0,0 +1,56 @@
+package vk
+
+import "testing"
+
+func TestBindlessRelease(t *testing.T) {
+	var tb BindlessTable
+	tb.arrays[BindlessSamplers] = bindlessArray{t: DescriptorSampler, capacity: 70}
+	a := &tb.arrays[BindlessSamplers]
+	for range 70 {
+		if _, ok := a.alloc(); !ok {
+			t.Fatal("alloc failed below capacity")
+		}
+	}
+	if _, ok := a.alloc(); ok {
+		t.Fatal("alloc beyond capacity")
+	}
+
+	for _, i := range []uint32{3, 65} {
+		if err := tb.Release(BindlessSamplers, i); err != nil {
+			t.Fatalf("release %d: %v", i, err)
+		}
+		if err := tb.Release(BindlessSamplers, i); err == nil {
+			t.Fatalf("second release of %d succeeded", i)
+		}
+	}
+	if err := tb.Release(BindlessSamplers, 70); err == nil {
+		t.Error("release of a never allocated slot succeeded")
+	}
+	if err := tb.Release(BindlessStorageBuffers, 0); err == nil {
+		t.Error("release in an empty array succeeded")
+	}
+	if err := tb.Release(4, 0); err == nil {
+		t.Error("release of an unknown binding succeeded")
+	}
+
+	// Reclaimed slots are handed out once each, and live again.
+	tb.EndFrame(0)
+	for _, s := range tb.pending[0].slots {
+		a.free = append(a.free, s.index)
+	}
+	tb.pending = nil
+	seen := map[uint32]bool{}
+	for range 2 {
+		i, ok := a.alloc()
+		if !ok || seen[i] {
+			t.Fatalf("alloc = %d, %v after reclaim", i, ok)
+		}
+		seen[i] = true
+	}
+	if _, ok := a.alloc(); ok {
+		t.Fatal("alloc beyond the reclaimed slots")
+	}
+	if err := tb.Release(BindlessSamplers, 65); err != nil {
+		t.Errorf("release of a reused slot: %v", err)
+	}
+}
@@ -17,6 +17,18 @@ func (c CommandBuffer) CopyBuffer(src, dst Buffer, size DeviceSize) {
 	runtime.KeepAlive(&region)
 }
 
+// CopyBufferRegion records a copy of size bytes from src at srcOffset to dst at
+// dstOffset.
+func (c CommandBuffer) CopyBufferRegion(src, dst Buffer, srcOffset, dstOffset, size DeviceSize) {
+	region := vulkan.VkBufferCopy{
+		SrcOffset: vulkan.VkDeviceSize(srcOffset),
+		DstOffset: vulkan.VkDeviceSize(dstOffset),
+		Size:      vulkan.VkDeviceSize(size),
+	}
+	vulkan.VkCmdCopyBuffer(vulkan.VkCommandBuffer(c), vulkan.VkBuffer(src), vulkan.VkBuffer(dst), 1, unsafe.Pointer(&region))
+	runtime.KeepAlive(&region)
+}
+
 // BlitImage records a single-region vkCmdBlitImage from one mip level of src to
 // one mip level of dst, both color aspect, layer 0. Source/destination extents
 // are the half-open rectangles [0..w, 0..h] at each level; filter is a VkFilter
@@ -70,6 +82,11 @@ func (d Device) AllocateCommandBuffers(pool CommandPool, count uint32) ([]Comman
 	return buffers, res.asError("vkAllocateCommandBuffers")
 }
 
+// ResetCommandPool resets every command buffer allocated from pool.
+func (d Device) ResetCommandPool(pool CommandPool) error {
+	return Result(vulkan.VkResetCommandPool(vulkan.VkDevice(d), vulkan.VkCommandPool(pool), 0)).asError("vkResetCommandPool")
+}
+
 // Begin starts recording. flags is a VkCommandBufferUsageFlags value.
 func (c CommandBuffer) Begin(flags uint32) error {
 	bi := vulkan.VkCommandBufferBeginInfo{SType: vulkan.VkStructureType(stCommandBufferBeginInfo), Flags: flags}
@@ -110,8 +127,12 @@ func (c CommandBuffer) BeginRenderPass(rp RenderPass, fb Framebuffer, area Rect2
 func (c CommandBuffer) EndRenderPass() { vulkan.VkCmdEndRenderPass(vulkan.VkCommandBuffer(c)) }
 
 // BindPipeline binds a graphics pipeline.
-func (c CommandBuffer) BindPipeline(p Pipeline) {
-	vulkan.VkCmdBindPipeline(vulkan.VkCommandBuffer(c), vulkan.VkPipelineBindPoint(BindPointGraphics), vulkan.VkPipeline(p))
+func (c CommandBuffer) BindPipeline(p Pipeline) { c.BindPipelineAt(BindPointGraphics, p) }
+
+// BindPipelineAt binds a pipeline at the given bind point (BindPointGraphics or
+// BindPointCompute).
+func (c CommandBuffer) BindPipelineAt(bindPoint uint32, p Pipeline) {
+	vulkan.VkCmdBindPipeline(vulkan.VkCommandBuffer(c), vulkan.VkPipelineBindPoint(bindPoint), vulkan.VkPipeline(p))
 }
 
 // SetViewport sets a single viewport.
@@ -150,13 +171,36 @@ func (c CommandBuffer) BindIndexBuffer(b Buffer, offset DeviceSize, indexType ui
 	vulkan.VkCmdBindIndexBuffer(vulkan.VkCommandBuffer(c), vulkan.VkBuffer(b), vulkan.VkDeviceSize(offset), vulkan.VkIndexType(indexType))
 }
 
-// BindDescriptorSet binds a single descriptor set at firstSet.
+// BindDescriptorSet binds a single descriptor set at firstSet for graphics.
 func (c CommandBuffer) BindDescriptorSet(layout PipelineLayout, firstSet uint32, set DescriptorSet) {
+	c.BindDescriptorSetAt(BindPointGraphics, layout, firstSet, set)
+}
+
+// BindDescriptorSetAt binds a single descriptor set at firstSet for the given
+// bind point.
+func (c CommandBuffer) BindDescriptorSetAt(bindPoint uint32, layout PipelineLayout, firstSet uint32, set DescriptorSet) {
 	vs := vulkan.VkDescriptorSet(set)
-	vulkan.VkCmdBindDescriptorSets(vulkan.VkCommandBuffer(c), vulkan.VkPipelineBindPoint(BindPointGraphics), vulkan.VkPipelineLayout(layout), firstSet, 1, unsafe.Pointer(&vs), 0, nil)
+	vulkan.VkCmdBindDescriptorSets(vulkan.VkCommandBuffer(c), vulkan.VkPipelineBindPoint(bindPoint), vulkan.VkPipelineLayout(layout), firstSet, 1, unsafe.Pointer(&vs), 0, nil)
 	runtime.KeepAlive(&vs)
 }
 
+// BindDescriptorSets binds consecutive descriptor sets starting at firstSet for
+// the given bind point. dynamicOffsets holds one offset per dynamic uniform or
+// storage buffer descriptor in the sets, in binding order.
+func (c CommandBuffer) BindDescriptorSets(bindPoint uint32, layout PipelineLayout, firstSet uint32, sets []DescriptorSet, dynamicOffsets []uint32) {
+	if len(sets) == 0 {
+		return
+	}
+	var offs unsafe.Pointer
+	if len(dynamicOffsets) > 0 {
+		offs = unsafe.Pointer(&dynamicOffsets[0])
+	}
+	vulkan.VkCmdBindDescriptorSets(vulkan.VkCommandBuffer(c), vulkan.VkPipelineBindPoint(bindPoint), vulkan.VkPipelineLayout(layout),
+		firstSet, uint32(len(sets)), unsafe.Pointer(&sets[0]), uint32(len(dynamicOffsets)), offs)
+	runtime.KeepAlive(sets)
+	runtime.KeepAlive(dynamicOffsets)
+}
+
 // PushConstants uploads push constant data.
 func (c CommandBuffer) PushConstants(layout PipelineLayout, stage, offset uint32, data unsafe.Pointer, size uint32) {
 	vulkan.VkCmdPushConstants(vulkan.VkCommandBuffer(c), vulkan.VkPipelineLayout(layout), stage, offset, size, data)
@@ -0,0 +1,94 @@
+package vk
+
+import (
+	"runtime"
+	"unsafe"
+
+	vulkan "github.com/christerso/vulkan-go/vulkan"
+)
+
+// Pipeline create flag bits (VkPipelineCreateFlagBits).
+const pipelineCreateDispatchBase uint32 = 0x00000010
+
+// ComputePipelineConfig describes a compute pipeline.
+type ComputePipelineConfig struct {
+	Layout         PipelineLayout
+	Shader         ShaderModule
+	EntryPoint     string // "" selects "main"
+	Specialization *Specialization
+	// DispatchBase allows the pipeline to be used with a non-zero base in
+	// CommandBuffer.DispatchBase.
+	DispatchBase bool
+	// DescriptorBuffer marks a pipeline whose sets come from descriptor
+	// buffers (CreateDescriptorBufferSetLayout) instead of descriptor pools.
+	DescriptorBuffer bool
+	Cache            *PipelineCache // optional; nil compiles without a cache
+}
+
+// CreateComputePipeline builds a compute pipeline from a single compute shader
+// stage.
+func (d Device) CreateComputePipeline(cfg ComputePipelineConfig) (Pipeline, error) {
+	name := cfg.EntryPoint
+	if name == "" {
+		name = "main"
+	}
+	entry := cstr(name)
+	spec, specEntries := specializationInfo(cfg.Specialization)
+	ci := vulkan.VkComputePipelineCreateInfo{
+		SType: vulkan.VkStructureType(stComputePipelineCreateInfo),
+		Stage: vulkan.VkPipelineShaderStageCreateInfo{
+			SType:               vulkan.VkStructureType(stPipelineShaderStageCreateInfo),
+			Stage:               ShaderStageCompute,
+			Module:              vulkan.VkShaderModule(cfg.Shader),
+			PName:               unsafe.Pointer(entry),
+			PSpecializationInfo: unsafe.Pointer(spec),
+		},
+		Layout:            vulkan.VkPipelineLayout(cfg.Layout),
+		BasePipelineIndex: -1,
+	}
+	if cfg.DispatchBase {
+		ci.Flags |= pipelineCreateDispatchBase
+	}
+	if cfg.DescriptorBuffer {
+		ci.Flags |= pipelineCreateDescriptorBuffer
+	}
+	cache, release := cfg.Cache.acquire()
+	defer release()
+	var pipeline vulkan.VkPipeline
+	res := Result(vulkan.VkCreateComputePipelines(vulkan.VkDevice(d), cache, 1, unsafe.Pointer(&ci), nil, unsafe.Pointer(&pipeline)))
+	runtime.KeepAlive(entry)
+	runtime.KeepAlive(spec)
+	runtime.KeepAlive(specEntries)
+	runtime.KeepAlive(cfg.Specialization)
+	runtime.KeepAlive(&ci)
+	return Pipeline(pipeline), res.asError("vkCreateComputePipelines")
+}
+
+// Dispatch records a compute dispatch of x*y*z workgroups.
+func (c CommandBuffer) Dispatch(x, y, z uint32) {
+	vulkan.VkCmdDispatch(vulkan.VkCommandBuffer(c), x, y, z)
+}
+
+// DispatchIndirect records a compute dispatch whose workgroup counts are read
+// from a VkDispatchIndirectCommand (three uint32s) at offset in buf. buf needs
+// BufferUsageIndirectBuffer.
+func (c CommandBuffer) DispatchIndirect(buf Buffer, offset DeviceSize) {
+	vulkan.VkCmdDispatchIndirect(vulkan.VkCommandBuffer(c), vulkan.VkBuffer(buf), vulkan.VkDeviceSize(offset))
+}
+
+// DispatchBase records a dispatch of x*y*z workgroups whose WorkgroupId starts
+// at (baseX, baseY, baseZ) instead of zero, for splitting a grid across several
+// dispatches. A non-zero base requires a pipeline created with
+// ComputePipelineConfig.DispatchBase.
+func (c CommandBuffer) DispatchBase(baseX, baseY, baseZ, x, y, z uint32) {
+	vulkan.VkCmdDispatchBase(vulkan.VkCommandBuffer(c), baseX, baseY, baseZ, x, y, z)
+}
+
+// GroupCount returns the number of workgroups of size local needed to cover n
+// invocations along one axis, rounding up.
+func GroupCount(n, local uint32) uint32 {
+	if local == 0 {
+		return 0
+	}
+	return (n + local - 1) / local
+}
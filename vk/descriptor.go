@@ -0,0 +1,387 @@
+package vk
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"unsafe"
+
+	vulkan "github.com/christerso/vulkan-go/vulkan"
+)
+
+// Descriptor pool create flag bits (VkDescriptorPoolCreateFlagBits).
+const (
+	DescriptorPoolCreateFreeDescriptorSet uint32 = 0x00000001
+	// DescriptorPoolCreateUpdateAfterBind is required to allocate sets whose
+	// layout has DescriptorBindingUpdateAfterBind bindings.
+	DescriptorPoolCreateUpdateAfterBind uint32 = 0x00000002
+)
+
+// Descriptor binding flag bits (VkDescriptorBindingFlagBits), set in
+// DescriptorBinding.Flags. They need DeviceConfig.DescriptorIndexing.
+const (
+	// DescriptorBindingUpdateAfterBind allows writing the binding after the
+	// set is bound, as long as no pending submission uses the descriptors.
+	DescriptorBindingUpdateAfterBind uint32 = 0x00000001
+	// DescriptorBindingUpdateUnusedWhilePending allows writing descriptors
+	// the pending submissions do not use.
+	DescriptorBindingUpdateUnusedWhilePending uint32 = 0x00000002
+	// DescriptorBindingPartiallyBound allows descriptors that shaders do not
+	// use to be left unwritten or stale.
+	DescriptorBindingPartiallyBound uint32 = 0x00000004
+	// DescriptorBindingVariableCount sizes the binding at allocation time;
+	// see AllocateDescriptorSetVariable.
+	DescriptorBindingVariableCount uint32 = 0x00000008
+)
+
+// Descriptor set layout create flag bits (VkDescriptorSetLayoutCreateFlagBits),
+// set by the layout constructors as needed.
+const (
+	descriptorSetLayoutCreatePushDescriptor      uint32 = 0x00000001
+	descriptorSetLayoutCreateUpdateAfterBindPool uint32 = 0x00000002
+	descriptorSetLayoutCreateDescriptorBuffer    uint32 = 0x00000010
+)
+
+// DefaultDescriptorRatios is the per-set descriptor mix DescriptorAllocator
+// sizes its pools for when DescriptorAllocatorConfig.Ratios is nil.
+var DefaultDescriptorRatios = map[DescriptorType]float32{
+	DescriptorSampler:              0.5,
+	DescriptorCombinedImageSampler: 4,
+	DescriptorSampledImage:         4,
+	DescriptorStorageImage:         1,
+	DescriptorUniformTexelBuffer:   1,
+	DescriptorStorageTexelBuffer:   1,
+	DescriptorUniformBuffer:        2,
+	DescriptorStorageBuffer:        2,
+	DescriptorUniformBufferDynamic: 1,
+	DescriptorStorageBufferDynamic: 1,
+	DescriptorInputAttachment:      0.5,
+}
+
+// DescriptorAllocatorConfig describes the pools of a DescriptorAllocator.
+type DescriptorAllocatorConfig struct {
+	// SetsPerPool is the set capacity of the first pool; 0 means 64. Each new
+	// pool holds 1.5 times as many sets as the last, up to MaxSetsPerPool.
+	SetsPerPool uint32
+	// MaxSetsPerPool caps pool growth; 0 means 4096.
+	MaxSetsPerPool uint32
+	// Ratios gives the number of descriptors of each type per set, so a pool
+	// of n sets holds ceil(n*ratio) descriptors of that type. Ratios must be
+	// non-negative, with at least one positive. nil means
+	// DefaultDescriptorRatios.
+	Ratios map[DescriptorType]float32
+	Flags  uint32 // DescriptorPoolCreate* bits
+}
+
+// DescriptorAllocator hands out descriptor sets from a growing list of pools.
+// When a pool is exhausted it moves on to the next one, creating a larger
+// pool if none is left, so Allocate only fails on real errors. Sets are not
+// freed one by one: Reset returns every set to the pools at once. Keeping one
+// allocator per frame in flight and resetting it once that frame's fence has
+// signaled suits sets rebuilt every frame.
+//
+// A DescriptorAllocator is not safe for concurrent use.
+type DescriptorAllocator struct {
+	device Device
+	cfg    DescriptorAllocatorConfig
+	next   uint32           // sets in the next pool created
+	ready  []DescriptorPool // pools that may have room; the last is current
+	full   []DescriptorPool // pools that reported exhaustion
+}
+
+// CreateDescriptorAllocator creates an allocator and its first pool.
+func (d Device) CreateDescriptorAllocator(cfg DescriptorAllocatorConfig) (*DescriptorAllocator, error) {
+	if cfg.SetsPerPool == 0 {
+		cfg.SetsPerPool = 64
+	}
+	if cfg.MaxSetsPerPool == 0 {
+		cfg.MaxSetsPerPool = 4096
+	}
+	if cfg.Ratios == nil {
+		cfg.Ratios = DefaultDescriptorRatios
+	}
+	positive := false
+	for t, r := range cfg.Ratios {
+		if !(r >= 0) || math.IsInf(float64(r), 1) {
+			return nil, fmt.Errorf("vk: descriptor ratio %v for type %d is not a finite non-negative number", r, t)
+		}
+		positive = positive || r > 0
+	}
+	if !positive {
+		return nil, fmt.Errorf("vk: DescriptorAllocatorConfig.Ratios has no positive ratio")
+	}
+	a := &DescriptorAllocator{device: d, cfg: cfg, next: min(cfg.SetsPerPool, cfg.MaxSetsPerPool)}
+	pool, err := a.grow()
+	if err != nil {
+		return nil, err
+	}
+	a.ready = append(a.ready, pool)
+	return a, nil
+}
+
+// grow creates a pool of a.next sets and raises a.next for the one after.
+func (a *DescriptorAllocator) grow() (DescriptorPool, error) {
+	sizes := make(map[DescriptorType]uint32, len(a.cfg.Ratios))
+	for t, r := range a.cfg.Ratios {
+		if n := uint32(math.Ceil(float64(a.next) * float64(r))); n > 0 {
+			sizes[t] = n
+		}
+	}
+	pool, err := a.device.createDescriptorPool(a.next, sizes, a.cfg.Flags)
+	if err != nil {
+		return 0, err
+	}
+	a.next = min(a.next+a.next/2, a.cfg.MaxSetsPerPool)
+	return pool, nil
+}
+
+// Allocate allocates a descriptor set with the given layout.
+func (a *DescriptorAllocator) Allocate(layout DescriptorSetLayout) (DescriptorSet, error) {
+	return a.AllocateVariable(layout, 0)
+}
+
+// AllocateVariable allocates a descriptor set whose
+// DescriptorBindingVariableCount binding holds count descriptors.
+func (a *DescriptorAllocator) AllocateVariable(layout DescriptorSetLayout, count uint32) (DescriptorSet, error) {
+	for {
+		fresh := false
+		if len(a.ready) == 0 {
+			pool, err := a.grow()
+			if err != nil {
+				return 0, err
+			}
+			a.ready = append(a.ready, pool)
+			fresh = true
+		}
+		pool := a.ready[len(a.ready)-1]
+		set, res := a.device.allocateDescriptorSet(pool, layout, count)
+		switch res {
+		case Success:
+			return set, nil
+		case ErrorOutOfPoolMemory, ErrorFragmentedPool:
+			a.ready = a.ready[:len(a.ready)-1]
+			a.full = append(a.full, pool)
+			if fresh {
+				// Growing further would not help: the layout needs more
+				// descriptors of some type than the ratios provide.
+				return 0, fmt.Errorf("vk: descriptor set layout does not fit an empty pool (%s); check DescriptorAllocatorConfig.Ratios", res)
+			}
+		default:
+			return 0, res.asError("vkAllocateDescriptorSets")
+		}
+	}
+}
+
+// Reset returns every set allocated since the last Reset to the pools. The
+// caller must ensure no submitted work still uses those sets.
+func (a *DescriptorAllocator) Reset() error {
+	a.ready = append(a.ready, a.full...)
+	a.full = a.full[:0]
+	for _, pool := range a.ready {
+		res := Result(vulkan.VkResetDescriptorPool(vulkan.VkDevice(a.device), vulkan.VkDescriptorPool(pool), 0))
+		if err := res.asError("vkResetDescriptorPool"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pools returns the number of pools the allocator holds.
+func (a *DescriptorAllocator) Pools() int { return len(a.ready) + len(a.full) }
+
+// Destroy destroys every pool and with them all allocated sets.
+func (a *DescriptorAllocator) Destroy() {
+	for _, pool := range a.ready {
+		a.device.DestroyDescriptorPool(pool)
+	}
+	for _, pool := range a.full {
+		a.device.DestroyDescriptorPool(pool)
+	}
+	*a = DescriptorAllocator{}
+}
+
+// DescriptorBufferInfo mirrors VkDescriptorBufferInfo. Range 0 means
+// WholeSize.
+type DescriptorBufferInfo struct {
+	Buffer Buffer
+	Offset DeviceSize
+	Range  DeviceSize
+}
+
+// DescriptorImageInfo mirrors VkDescriptorImageInfo. Layout 0 means
+// LayoutGeneral for storage images and LayoutShaderReadOnlyOptimal otherwise;
+// Sampler is ignored except for sampler and combined image sampler
+// descriptors, and View for sampler descriptors.
+type DescriptorImageInfo struct {
+	Sampler Sampler
+	View    ImageView
+	Layout  ImageLayout
+}
+
+// descriptorWrite is one queued VkWriteDescriptorSet whose infos are
+// [first, first+count) of the builder's buffer infos, image infos, or texel
+// buffer views.
+type descriptorWrite struct {
+	set          DescriptorSet
+	binding      uint32
+	element      uint32
+	t            DescriptorType
+	first, count int
+	image        bool
+	texel        bool
+}
+
+// DescriptorSetBuilder queues descriptor writes to any number of sets and
+// applies them with a single vkUpdateDescriptorSets call. The zero value is
+// ready to use, and the builder can be reused after Update.
+//
+//	var b vk.DescriptorSetBuilder
+//	b.Buffer(set, 0, vk.DescriptorUniformBuffer, ubo.Buffer, 0, 0).
+//		CombinedImageSampler(set, 1, view, sampler)
+//	b.Update(device)
+type DescriptorSetBuilder struct {
+	writes  []descriptorWrite
+	buffers []vulkan.VkDescriptorBufferInfo
+	images  []vulkan.VkDescriptorImageInfo
+	views   []vulkan.VkBufferView
+}
+
+// Buffer queues a write of one uniform or storage buffer descriptor. size 0
+// means WholeSize.
+func (b *DescriptorSetBuilder) Buffer(set DescriptorSet, binding uint32, t DescriptorType, buf Buffer, offset, size DeviceSize) *DescriptorSetBuilder {
+	return b.Buffers(set, binding, 0, t, []DescriptorBufferInfo{{Buffer: buf, Offset: offset, Range: size}})
+}
+
+// Buffers queues a write of consecutive array elements of a uniform or
+// storage buffer binding, starting at element first. Texel buffer bindings
+// take buffer views through TexelBuffers instead.
+func (b *DescriptorSetBuilder) Buffers(set DescriptorSet, binding, first uint32, t DescriptorType, infos []DescriptorBufferInfo) *DescriptorSetBuilder {
+	if len(infos) == 0 {
+		return b
+	}
+	b.writes = append(b.writes, descriptorWrite{set: set, binding: binding, element: first, t: t, first: len(b.buffers), count: len(infos)})
+	for _, in := range infos {
+		rang := in.Range
+		if rang == 0 {
+			rang = WholeSize
+		}
+		b.buffers = append(b.buffers, vulkan.VkDescriptorBufferInfo{
+			Buffer: vulkan.VkBuffer(in.Buffer),
+			Offset: vulkan.VkDeviceSize(in.Offset),
+			Range:  vulkan.VkDeviceSize(rang),
+		})
+	}
+	return b
+}
+
+// TexelBuffer queues a write of one uniform or storage texel buffer
+// descriptor.
+func (b *DescriptorSetBuilder) TexelBuffer(set DescriptorSet, binding uint32, t DescriptorType, view BufferView) *DescriptorSetBuilder {
+	return b.TexelBuffers(set, binding, 0, t, []BufferView{view})
+}
+
+// TexelBuffers queues a write of consecutive array elements of a texel
+// buffer binding, starting at element first.
+func (b *DescriptorSetBuilder) TexelBuffers(set DescriptorSet, binding, first uint32, t DescriptorType, views []BufferView) *DescriptorSetBuilder {
+	if len(views) == 0 {
+		return b
+	}
+	b.writes = append(b.writes, descriptorWrite{set: set, binding: binding, element: first, t: t, first: len(b.views), count: len(views), texel: true})
+	for _, v := range views {
+		b.views = append(b.views, vulkan.VkBufferView(v))
+	}
+	return b
+}
+
+// Image queues a write of one sampled, storage, or input attachment image
+// descriptor. layout 0 picks the default described at DescriptorImageInfo.
+func (b *DescriptorSetBuilder) Image(set DescriptorSet, binding uint32, t DescriptorType, view ImageView, layout ImageLayout) *DescriptorSetBuilder {
+	return b.Images(set, binding, 0, t, []DescriptorImageInfo{{View: view, Layout: layout}})
+}
+
+// CombinedImageSampler queues a write of one combined image sampler
+// descriptor with the image in LayoutShaderReadOnlyOptimal.
+func (b *DescriptorSetBuilder) CombinedImageSampler(set DescriptorSet, binding uint32, view ImageView, sampler Sampler) *DescriptorSetBuilder {
+	return b.Images(set, binding, 0, DescriptorCombinedImageSampler, []DescriptorImageInfo{{Sampler: sampler, View: view}})
+}
+
+// Sampler queues a write of one sampler descriptor.
+func (b *DescriptorSetBuilder) Sampler(set DescriptorSet, binding uint32, sampler Sampler) *DescriptorSetBuilder {
+	return b.Images(set, binding, 0, DescriptorSampler, []DescriptorImageInfo{{Sampler: sampler}})
+}
+
+// Images queues a write of consecutive array elements of an image or sampler
+// binding, starting at element first.
+func (b *DescriptorSetBuilder) Images(set DescriptorSet, binding, first uint32, t DescriptorType, infos []DescriptorImageInfo) *DescriptorSetBuilder {
+	if len(infos) == 0 {
+		return b
+	}
+	b.writes = append(b.writes, descriptorWrite{set: set, binding: binding, element: first, t: t, first: len(b.images), count: len(infos), image: true})
+	for _, in := range infos {
+		layout := in.Layout
+		if layout == LayoutUndefined && t != DescriptorSampler {
+			layout = LayoutShaderReadOnlyOptimal
+			if t == DescriptorStorageImage {
+				layout = LayoutGeneral
+			}
+		}
+		b.images = append(b.images, vulkan.VkDescriptorImageInfo{
+			Sampler:     vulkan.VkSampler(in.Sampler),
+			ImageView:   vulkan.VkImageView(in.View),
+			ImageLayout: vulkan.VkImageLayout(layout),
+		})
+	}
+	return b
+}
+
+// Len returns the number of queued writes.
+func (b *DescriptorSetBuilder) Len() int { return len(b.writes) }
+
+// Reset drops the queued writes.
+func (b *DescriptorSetBuilder) Reset() {
+	b.writes = b.writes[:0]
+	b.buffers = b.buffers[:0]
+	b.images = b.images[:0]
+	b.views = b.views[:0]
+}
+
+// vk returns the queued writes as VkWriteDescriptorSets pointing into b's
+// info slices, which must not grow while the result is in use.
+func (b *DescriptorSetBuilder) vk() []vulkan.VkWriteDescriptorSet {
+	writes := make([]vulkan.VkWriteDescriptorSet, len(b.writes))
+	for i, w := range b.writes {
+		writes[i] = vulkan.VkWriteDescriptorSet{
+			SType:           vulkan.VkStructureType(stWriteDescriptorSet),
+			DstSet:          vulkan.VkDescriptorSet(w.set),
+			DstBinding:      w.binding,
+			DstArrayElement: w.element,
+			DescriptorCount: uint32(w.count),
+			DescriptorType:  vulkan.VkDescriptorType(w.t),
+		}
+		switch {
+		case w.image:
+			writes[i].PImageInfo = unsafe.Pointer(&b.images[w.first])
+		case w.texel:
+			writes[i].PTexelBufferView = unsafe.Pointer(&b.views[w.first])
+		default:
+			writes[i].PBufferInfo = unsafe.Pointer(&b.buffers[w.first])
+		}
+	}
+	return writes
+}
+
+// Update applies every queued write in one vkUpdateDescriptorSets call and
+// resets the builder. The sets must not be in use by pending command buffers
+// unless their bindings allow update after bind.
+func (b *DescriptorSetBuilder) Update(d Device) {
+	if len(b.writes) == 0 {
+		return
+	}
+	writes := b.vk()
+	vulkan.VkUpdateDescriptorSets(vulkan.VkDevice(d), uint32(len(writes)), unsafe.Pointer(&writes[0]), 0, nil)
+	runtime.KeepAlive(writes)
+	runtime.KeepAlive(b.buffers)
+	runtime.KeepAlive(b.images)
+	runtime.KeepAlive(b.views)
+	b.Reset()
+}
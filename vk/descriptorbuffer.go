@@ -0,0 +1,364 @@
+package vk
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	vulkan "github.com/christerso/vulkan-go/vulkan"
+)
+
+// Buffer usage flag bits for descriptor buffers (VK_EXT_descriptor_buffer).
+// Sampler and combined image sampler descriptors go in a sampler descriptor
+// buffer, everything else in a resource descriptor buffer; a buffer with both
+// bits holds either kind.
+const (
+	BufferUsageSamplerDescriptorBuffer  uint32 = 0x00200000
+	BufferUsageResourceDescriptorBuffer uint32 = 0x00400000
+)
+
+// pipelineCreateDescriptorBuffer (VK_PIPELINE_CREATE_DESCRIPTOR_BUFFER_BIT_EXT)
+// marks a pipeline whose sets come from descriptor buffers.
+const pipelineCreateDescriptorBuffer uint32 = 0x20000000
+
+// CreateDescriptorBufferSetLayout creates a descriptor set layout whose sets
+// live in descriptor buffers instead of descriptor pools. Dynamic buffer
+// descriptors and DescriptorBindingUpdateAfterBind are not allowed; the
+// memory can be written whenever no pending submission reads it. Pipelines
+// using such layouts need the DescriptorBuffer option of their config, and
+// the device needs DeviceConfig.DescriptorBuffer.
+func (d Device) CreateDescriptorBufferSetLayout(bindings []DescriptorBinding) (DescriptorSetLayout, error) {
+	for _, b := range bindings {
+		if b.Type == DescriptorUniformBufferDynamic || b.Type == DescriptorStorageBufferDynamic {
+			return 0, fmt.Errorf("vk: binding %d: dynamic descriptors cannot live in a descriptor buffer", b.Binding)
+		}
+		if b.Flags&DescriptorBindingUpdateAfterBind != 0 {
+			return 0, fmt.Errorf("vk: binding %d: update after bind cannot be used with a descriptor buffer", b.Binding)
+		}
+	}
+	return d.createDescriptorSetLayout(bindings, descriptorSetLayoutCreateDescriptorBuffer)
+}
+
+// DescriptorSetLayoutSize returns the number of bytes a set of layout takes
+// in a descriptor buffer.
+func (d Device) DescriptorSetLayoutSize(layout DescriptorSetLayout) DeviceSize {
+	var size vulkan.VkDeviceSize
+	vulkan.VkGetDescriptorSetLayoutSizeEXT(vulkan.VkDevice(d), vulkan.VkDescriptorSetLayout(layout), unsafe.Pointer(&size))
+	return DeviceSize(size)
+}
+
+// DescriptorSetLayoutBindingOffset returns the byte offset of binding within
+// a set of layout. Array element i of the binding follows at i times the
+// descriptor size of its type.
+func (d Device) DescriptorSetLayoutBindingOffset(layout DescriptorSetLayout, binding uint32) DeviceSize {
+	var off vulkan.VkDeviceSize
+	vulkan.VkGetDescriptorSetLayoutBindingOffsetEXT(vulkan.VkDevice(d), vulkan.VkDescriptorSetLayout(layout), binding, unsafe.Pointer(&off))
+	return DeviceSize(off)
+}
+
+// DescriptorBufferProperties holds the VK_EXT_descriptor_buffer limits and
+// the size in bytes of each descriptor type. The sizes are those without
+// robust buffer access, which the binding never enables.
+type DescriptorBufferProperties struct {
+	// OffsetAlignment is the alignment of set offsets passed to
+	// SetDescriptorBufferOffsets.
+	OffsetAlignment     DeviceSize
+	MaxBindings         uint32 // descriptor buffers bound at once
+	MaxResourceBindings uint32
+	MaxSamplerBindings  uint32
+	MaxSamplerRange     DeviceSize
+	MaxResourceRange    DeviceSize
+	// CombinedImageSamplerSingleArray reports that a combined image sampler
+	// array may be stored as one array of combined descriptors. Without it
+	// the array must be stored as all its images followed by all its
+	// samplers, which DescriptorBuffer does not lay out: it then only writes
+	// combined image sampler bindings of one element.
+	CombinedImageSamplerSingleArray bool
+
+	sizes map[DescriptorType]int
+}
+
+// DescriptorSize returns the size in bytes of one descriptor of type t, or 0
+// for types that cannot live in a descriptor buffer.
+func (p DescriptorBufferProperties) DescriptorSize(t DescriptorType) int {
+	return p.sizes[t]
+}
+
+// DescriptorBufferProperties queries the descriptor buffer properties of the
+// device. The device must support VK_EXT_descriptor_buffer.
+func (pd PhysicalDevice) DescriptorBufferProperties() DescriptorBufferProperties {
+	dbp := vulkan.VkPhysicalDeviceDescriptorBufferPropertiesEXT{SType: vulkan.VkStructureType(stPhysicalDeviceDescriptorBufferPropertiesEXT)}
+	p2 := vulkan.VkPhysicalDeviceProperties2{SType: vulkan.VkStructureType(stPhysicalDeviceProperties2), PNext: unsafe.Pointer(&dbp)}
+	vulkan.VkGetPhysicalDeviceProperties2(vulkan.VkPhysicalDevice(pd), unsafe.Pointer(&p2))
+	runtime.KeepAlive(&p2)
+	runtime.KeepAlive(&dbp)
+	return DescriptorBufferProperties{
+		OffsetAlignment:                 DeviceSize(dbp.DescriptorBufferOffsetAlignment),
+		MaxBindings:                     dbp.MaxDescriptorBufferBindings,
+		MaxResourceBindings:             dbp.MaxResourceDescriptorBufferBindings,
+		MaxSamplerBindings:              dbp.MaxSamplerDescriptorBufferBindings,
+		MaxSamplerRange:                 DeviceSize(dbp.MaxSamplerDescriptorBufferRange),
+		MaxResourceRange:                DeviceSize(dbp.MaxResourceDescriptorBufferRange),
+		CombinedImageSamplerSingleArray: dbp.CombinedImageSamplerDescriptorSingleArray != 0,
+		sizes: map[DescriptorType]int{
+			DescriptorSampler:               int(dbp.SamplerDescriptorSize),
+			DescriptorCombinedImageSampler:  int(dbp.CombinedImageSamplerDescriptorSize),
+			DescriptorSampledImage:          int(dbp.SampledImageDescriptorSize),
+			DescriptorStorageImage:          int(dbp.StorageImageDescriptorSize),
+			DescriptorUniformTexelBuffer:    int(dbp.UniformTexelBufferDescriptorSize),
+			DescriptorStorageTexelBuffer:    int(dbp.StorageTexelBufferDescriptorSize),
+			DescriptorUniformBuffer:         int(dbp.UniformBufferDescriptorSize),
+			DescriptorStorageBuffer:         int(dbp.StorageBufferDescriptorSize),
+			DescriptorInputAttachment:       int(dbp.InputAttachmentDescriptorSize),
+			DescriptorAccelerationStructure: int(dbp.AccelerationStructureDescriptorSize),
+		},
+	}
+}
+
+// DescriptorBufferConfig describes a DescriptorBuffer.
+type DescriptorBufferConfig struct {
+	Size DeviceSize
+	// Usage holds BufferUsage*DescriptorBuffer bits; 0 means both, so the
+	// buffer can hold every descriptor type.
+	Usage uint32
+}
+
+// DescriptorBuffer is a persistently mapped, device-addressable buffer that
+// holds descriptor sets as raw descriptor data. Sets are placed with Alloc and
+// filled with the Write methods, which call vkGetDescriptorEXT straight into
+// the mapping; there is no pool and no vkUpdateDescriptorSets. Bind it with
+// CommandBuffer.BindDescriptorBuffers and select sets with
+// SetDescriptorBufferOffsets.
+//
+// Writes land in memory the device may be reading, so a set must not be
+// rewritten while a pending submission uses it. A DescriptorBuffer is not
+// safe for concurrent use.
+type DescriptorBuffer struct {
+	AllocBuffer
+	device  Device
+	usage   uint32
+	props   DescriptorBufferProperties
+	next    DeviceSize
+	offsets map[descriptorBindingKey]DeviceSize
+}
+
+// descriptorBindingKey caches DescriptorSetLayoutBindingOffset results.
+type descriptorBindingKey struct {
+	layout  DescriptorSetLayout
+	binding uint32
+}
+
+// CreateDescriptorBuffer creates a descriptor buffer in host-visible,
+// coherent memory, device-local where the device offers it. The device needs
+// DeviceConfig.DescriptorBuffer and DeviceConfig.BufferDeviceAddress.
+func (d Device) CreateDescriptorBuffer(pd PhysicalDevice, cfg DescriptorBufferConfig) (*DescriptorBuffer, error) {
+	usage := cfg.Usage
+	if usage == 0 {
+		usage = BufferUsageSamplerDescriptorBuffer | BufferUsageResourceDescriptorBuffer
+	}
+	ab, err := d.CreateBuffer(pd, BufferConfig{
+		Size:          cfg.Size,
+		Usage:         usage,
+		Properties:    MemoryHostVisible | MemoryHostCoherent,
+		Preferred:     MemoryDeviceLocal,
+		Map:           true,
+		DeviceAddress: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &DescriptorBuffer{
+		AllocBuffer: ab,
+		device:      d,
+		usage:       usage,
+		props:       pd.DescriptorBufferProperties(),
+		offsets:     map[descriptorBindingKey]DeviceSize{},
+	}, nil
+}
+
+// Destroy frees the buffer and its memory.
+func (b *DescriptorBuffer) Destroy() {
+	b.device.DestroyBuffer(b.AllocBuffer)
+	*b = DescriptorBuffer{}
+}
+
+// Properties returns the device's descriptor buffer properties.
+func (b *DescriptorBuffer) Properties() DescriptorBufferProperties { return b.props }
+
+// Binding returns the buffer's entry for CommandBuffer.BindDescriptorBuffers.
+func (b *DescriptorBuffer) Binding() DescriptorBufferBinding {
+	return DescriptorBufferBinding{Address: b.Address(), Usage: b.usage}
+}
+
+// Alloc reserves room for one set of layout and returns its offset, aligned
+// for SetDescriptorBufferOffsets. Space is handed out in order and only given
+// back by Reset.
+func (b *DescriptorBuffer) Alloc(layout DescriptorSetLayout) (DeviceSize, error) {
+	align := b.props.OffsetAlignment
+	if align == 0 {
+		align = 1
+	}
+	off := (b.next + align - 1) / align * align
+	size := b.device.DescriptorSetLayoutSize(layout)
+	if off+size > b.Size {
+		return 0, fmt.Errorf("vk: descriptor buffer full: set of %d bytes at %d, buffer is %d", size, off, b.Size)
+	}
+	b.next = off + size
+	return off, nil
+}
+
+// Reset makes the whole buffer available to Alloc again. The caller must
+// ensure no pending submission still reads the sets.
+func (b *DescriptorBuffer) Reset() { b.next = 0 }
+
+// WriteBuffer writes element of a uniform or storage buffer binding of the
+// set of layout at offset set, as size bytes from addr (for instance an
+// AllocBuffer.Address plus an offset). Descriptor buffers have no WholeSize,
+// so size must be non-zero unless addr is 0, which writes a null descriptor.
+func (b *DescriptorBuffer) WriteBuffer(set DeviceSize, layout DescriptorSetLayout, binding, element uint32, t DescriptorType, addr DeviceAddress, size DeviceSize) error {
+	return b.writeAddress(set, layout, binding, element, t, addr, size, FormatUndefined)
+}
+
+// WriteTexelBuffer writes element of a uniform or storage texel buffer
+// binding, viewing size bytes from addr as format. size must be non-zero as
+// for WriteBuffer.
+func (b *DescriptorBuffer) WriteTexelBuffer(set DeviceSize, layout DescriptorSetLayout, binding, element uint32, t DescriptorType, addr DeviceAddress, size DeviceSize, format Format) error {
+	return b.writeAddress(set, layout, binding, element, t, addr, size, format)
+}
+
+func (b *DescriptorBuffer) writeAddress(set DeviceSize, layout DescriptorSetLayout, binding, element uint32, t DescriptorType, addr DeviceAddress, size DeviceSize, format Format) error {
+	if size == 0 && addr != 0 {
+		return fmt.Errorf("vk: descriptor buffer write of binding %d with size 0; the range must be given", binding)
+	}
+	ai := vulkan.VkDescriptorAddressInfoEXT{
+		SType:   vulkan.VkStructureType(stDescriptorAddressInfoEXT),
+		Address: vulkan.VkDeviceAddress(addr),
+		Range:   vulkan.VkDeviceSize(size),
+		Format:  vulkan.VkFormat(format),
+	}
+	// A zero address writes a null descriptor (nullDescriptor feature).
+	var data vulkan.VkDescriptorDataEXT
+	if addr != 0 {
+		*data.AsPUniformBuffer() = unsafe.Pointer(&ai)
+	}
+	err := b.write(set, layout, binding, element, t, data)
+	runtime.KeepAlive(&ai)
+	return err
+}
+
+// WriteImage writes element of a sampled image, storage image, combined image
+// sampler, or input attachment binding. A zero info.Layout picks the default
+// described at DescriptorImageInfo. Combined image sampler elements past the
+// first need DescriptorBufferProperties.CombinedImageSamplerSingleArray.
+func (b *DescriptorBuffer) WriteImage(set DeviceSize, layout DescriptorSetLayout, binding, element uint32, t DescriptorType, info DescriptorImageInfo) error {
+	il := info.Layout
+	if il == LayoutUndefined {
+		il = LayoutShaderReadOnlyOptimal
+		if t == DescriptorStorageImage {
+			il = LayoutGeneral
+		}
+	}
+	ii := vulkan.VkDescriptorImageInfo{
+		Sampler:     vulkan.VkSampler(info.Sampler),
+		ImageView:   vulkan.VkImageView(info.View),
+		ImageLayout: vulkan.VkImageLayout(il),
+	}
+	var data vulkan.VkDescriptorDataEXT
+	*data.AsPSampledImage() = unsafe.Pointer(&ii)
+	err := b.write(set, layout, binding, element, t, data)
+	runtime.KeepAlive(&ii)
+	return err
+}
+
+// WriteSampler writes element of a sampler binding.
+func (b *DescriptorBuffer) WriteSampler(set DeviceSize, layout DescriptorSetLayout, binding, element uint32, s Sampler) error {
+	h := vulkan.VkSampler(s)
+	var data vulkan.VkDescriptorDataEXT
+	*data.AsPSampler() = unsafe.Pointer(&h)
+	err := b.write(set, layout, binding, element, DescriptorSampler, data)
+	runtime.KeepAlive(&h)
+	return err
+}
+
+// WriteAccelerationStructure writes element of an acceleration structure
+// binding from the structure's device address.
+func (b *DescriptorBuffer) WriteAccelerationStructure(set DeviceSize, layout DescriptorSetLayout, binding, element uint32, addr DeviceAddress) error {
+	var data vulkan.VkDescriptorDataEXT
+	*data.AsAccelerationStructure() = vulkan.VkDeviceAddress(addr)
+	return b.write(set, layout, binding, element, DescriptorAccelerationStructure, data)
+}
+
+// write fetches the descriptor of type t described by data into the mapping
+// at element of binding of the set at offset set. Pointers in data must be
+// kept alive by the caller.
+func (b *DescriptorBuffer) write(set DeviceSize, layout DescriptorSetLayout, binding, element uint32, t DescriptorType, data vulkan.VkDescriptorDataEXT) error {
+	size := b.props.DescriptorSize(t)
+	if size == 0 {
+		return fmt.Errorf("vk: descriptor type %d cannot be written to a descriptor buffer", t)
+	}
+	if t == DescriptorCombinedImageSampler && element > 0 && !b.props.CombinedImageSamplerSingleArray {
+		return fmt.Errorf("vk: binding %d: combined image sampler arrays need CombinedImageSamplerSingleArray", binding)
+	}
+	key := descriptorBindingKey{layout: layout, binding: binding}
+	off, ok := b.offsets[key]
+	if !ok {
+		off = b.device.DescriptorSetLayoutBindingOffset(layout, binding)
+		b.offsets[key] = off
+	}
+	off += set + DeviceSize(element)*DeviceSize(size)
+	if off+DeviceSize(size) > b.Size {
+		return fmt.Errorf("vk: descriptor write at %d past the end of a %d byte descriptor buffer", off, b.Size)
+	}
+	gi := vulkan.VkDescriptorGetInfoEXT{
+		SType: vulkan.VkStructureType(stDescriptorGetInfoEXT),
+		Type:  vulkan.VkDescriptorType(t),
+		Data:  data,
+	}
+	vulkan.VkGetDescriptorEXT(vulkan.VkDevice(b.device), unsafe.Pointer(&gi), uintptr(size), unsafe.Add(b.Mapped, off))
+	runtime.KeepAlive(&gi)
+	return nil
+}
+
+// DescriptorBufferBinding is one descriptor buffer to bind: its device
+// address and its BufferUsage*DescriptorBuffer bits.
+type DescriptorBufferBinding struct {
+	Address DeviceAddress
+	Usage   uint32
+}
+
+// BindDescriptorBuffers binds descriptor buffers; SetDescriptorBufferOffsets
+// refers to them by index into bufs. Binding replaces every previously bound
+// descriptor buffer.
+func (c CommandBuffer) BindDescriptorBuffers(bufs []DescriptorBufferBinding) {
+	if len(bufs) == 0 {
+		return
+	}
+	infos := make([]vulkan.VkDescriptorBufferBindingInfoEXT, len(bufs))
+	for i, b := range bufs {
+		infos[i] = vulkan.VkDescriptorBufferBindingInfoEXT{
+			SType:   vulkan.VkStructureType(stDescriptorBufferBindingInfoEXT),
+			Address: vulkan.VkDeviceAddress(b.Address),
+			Usage:   b.Usage,
+		}
+	}
+	vulkan.VkCmdBindDescriptorBuffersEXT(vulkan.VkCommandBuffer(c), uint32(len(infos)), unsafe.Pointer(&infos[0]))
+	runtime.KeepAlive(infos)
+}
+
+// SetDescriptorBufferOffsets binds sets firstSet, firstSet+1, ... of layout
+// at bindPoint to the sets at offsets[i] of the bound descriptor buffer
+// bufferIndices[i], as BindDescriptorSets does for pool-allocated sets. The
+// slices are used up to the length of the shorter one.
+func (c CommandBuffer) SetDescriptorBufferOffsets(bindPoint uint32, layout PipelineLayout, firstSet uint32, bufferIndices []uint32, offsets []DeviceSize) {
+	n := len(bufferIndices)
+	if len(offsets) < n {
+		n = len(offsets)
+	}
+	if n == 0 {
+		return
+	}
+	vulkan.VkCmdSetDescriptorBufferOffsetsEXT(vulkan.VkCommandBuffer(c), vulkan.VkPipelineBindPoint(bindPoint), vulkan.VkPipelineLayout(layout),
+		firstSet, uint32(n), unsafe.Pointer(&bufferIndices[0]), unsafe.Pointer(&offsets[0]))
+	runtime.KeepAlive(bufferIndices)
+	runtime.KeepAlive(offsets)
+}
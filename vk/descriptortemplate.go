@@ -0,0 +1,265 @@
+package vk
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	vulkan "github.com/christerso/vulkan-go/vulkan"
+)
+
+// Descriptor update template types (VkDescriptorUpdateTemplateType).
+const (
+	descriptorUpdateTemplateTypeDescriptorSet   uint32 = 0
+	descriptorUpdateTemplateTypePushDescriptors uint32 = 1
+)
+
+// DescriptorTemplateConfig describes a descriptor update template. The
+// template reads descriptors straight out of a Go struct whose fields are
+// DescriptorImageInfo values for image and sampler bindings, BufferView
+// values for texel buffer bindings, and DescriptorBufferInfo values for
+// uniform and storage buffer bindings, or arrays of them. A field tagged
+// `binding:"N"` writes binding N, and `binding:"N,E"` starts at array
+// element E; untagged fields are skipped. If no field carries a binding tag
+// at all, the exported fields write bindings 0, 1, 2, ... in declaration
+// order:
+//
+//	type material struct {
+//		Params  vk.DescriptorBufferInfo    `binding:"0"`
+//		Albedo  vk.DescriptorImageInfo     `binding:"1"`
+//		Shadows [4]vk.DescriptorImageInfo `binding:"2"`
+//	}
+//
+// The structs are read as is, so unlike with DescriptorSetBuilder a zero
+// Range or Layout is not replaced by a default.
+type DescriptorTemplateConfig struct {
+	// Data is a value of, or pointer to, the struct type the template reads.
+	Data any
+	// Bindings are the bindings of the set layout, which give each field its
+	// descriptor type.
+	Bindings []DescriptorBinding
+	// SetLayout is the layout of the sets UpdateWithTemplate writes.
+	SetLayout DescriptorSetLayout
+	// PipelineLayout, when set, makes a push descriptor template for
+	// PushDescriptorSetWithTemplate, pushing set number Set of the layout at
+	// BindPoint. SetLayout is then ignored.
+	PipelineLayout PipelineLayout
+	BindPoint      uint32
+	Set            uint32
+}
+
+// DescriptorTemplate is a descriptor update template together with the Go
+// struct type it reads.
+type DescriptorTemplate struct {
+	device Device
+	handle vulkan.VkDescriptorUpdateTemplate
+	typ    reflect.Type
+	layout PipelineLayout // push templates only
+	set    uint32
+}
+
+var (
+	bufferInfoType = reflect.TypeOf(DescriptorBufferInfo{})
+	imageInfoType  = reflect.TypeOf(DescriptorImageInfo{})
+	bufferViewType = reflect.TypeOf(BufferView(0))
+)
+
+// templateFieldType returns the Go type a template field for descriptors of
+// type t must have, or nil if templates do not support t.
+func templateFieldType(t DescriptorType) reflect.Type {
+	switch t {
+	case DescriptorSampler, DescriptorCombinedImageSampler, DescriptorSampledImage, DescriptorStorageImage, DescriptorInputAttachment:
+		return imageInfoType
+	case DescriptorUniformTexelBuffer, DescriptorStorageTexelBuffer:
+		return bufferViewType
+	case DescriptorUniformBuffer, DescriptorStorageBuffer, DescriptorUniformBufferDynamic, DescriptorStorageBufferDynamic:
+		return bufferInfoType
+	}
+	return nil
+}
+
+// templateEntries derives one template entry per descriptor field of rt.
+func templateEntries(rt reflect.Type, bindings []DescriptorBinding) ([]vulkan.VkDescriptorUpdateTemplateEntry, error) {
+	types := make(map[uint32]DescriptorType, len(bindings))
+	for _, b := range bindings {
+		types[b.Binding] = b.Type
+	}
+	tagged := false
+	for i := 0; i < rt.NumField(); i++ {
+		if _, ok := rt.Field(i).Tag.Lookup("binding"); ok {
+			tagged = true
+			break
+		}
+	}
+	var entries []vulkan.VkDescriptorUpdateTemplateEntry
+	next := uint32(0)
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		binding, element := next, uint32(0)
+		if tagged {
+			tag, ok := f.Tag.Lookup("binding")
+			if !ok || tag == "-" {
+				continue
+			}
+			b, e, hasElem := strings.Cut(tag, ",")
+			n, err := strconv.ParseUint(b, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("vk: field %s: bad binding tag %q", f.Name, tag)
+			}
+			binding = uint32(n)
+			if hasElem {
+				n, err := strconv.ParseUint(e, 10, 32)
+				if err != nil {
+					return nil, fmt.Errorf("vk: field %s: bad binding tag %q", f.Name, tag)
+				}
+				element = uint32(n)
+			}
+		} else {
+			if !f.IsExported() {
+				continue
+			}
+			next++
+		}
+		t, ok := types[binding]
+		if !ok {
+			return nil, fmt.Errorf("vk: field %s: binding %d is not in the layout", f.Name, binding)
+		}
+		ft, count := f.Type, uint32(1)
+		if ft.Kind() == reflect.Array {
+			ft, count = ft.Elem(), uint32(ft.Len())
+		}
+		want := templateFieldType(t)
+		if want == nil {
+			return nil, fmt.Errorf("vk: field %s: binding %d has descriptor type %d, which templates do not support", f.Name, binding, t)
+		}
+		if ft != want {
+			return nil, fmt.Errorf("vk: field %s: binding %d of descriptor type %d needs %s, have %s", f.Name, binding, t, want, f.Type)
+		}
+		entries = append(entries, vulkan.VkDescriptorUpdateTemplateEntry{
+			DstBinding:      binding,
+			DstArrayElement: element,
+			DescriptorCount: count,
+			DescriptorType:  vulkan.VkDescriptorType(t),
+			Offset:          f.Offset,
+			Stride:          ft.Size(),
+		})
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("vk: %s has no descriptor fields", rt)
+	}
+	return entries, nil
+}
+
+// CreateDescriptorUpdateTemplate creates a template that writes a whole set
+// from one struct in a single call; see DescriptorTemplateConfig for the
+// struct layout.
+func (d Device) CreateDescriptorUpdateTemplate(cfg DescriptorTemplateConfig) (*DescriptorTemplate, error) {
+	rt := reflect.TypeOf(cfg.Data)
+	if rt != nil && rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("vk: descriptor template from %v, want a struct", rt)
+	}
+	entries, err := templateEntries(rt, cfg.Bindings)
+	if err != nil {
+		return nil, err
+	}
+	ci := vulkan.VkDescriptorUpdateTemplateCreateInfo{
+		SType:                      vulkan.VkStructureType(stDescriptorUpdateTemplateCreateInfo),
+		DescriptorUpdateEntryCount: uint32(len(entries)),
+		PDescriptorUpdateEntries:   unsafe.Pointer(&entries[0]),
+		TemplateType:               vulkan.VkDescriptorUpdateTemplateType(descriptorUpdateTemplateTypeDescriptorSet),
+		DescriptorSetLayout:        vulkan.VkDescriptorSetLayout(cfg.SetLayout),
+	}
+	if cfg.PipelineLayout != 0 {
+		ci.TemplateType = vulkan.VkDescriptorUpdateTemplateType(descriptorUpdateTemplateTypePushDescriptors)
+		ci.DescriptorSetLayout = 0
+		ci.PipelineBindPoint = vulkan.VkPipelineBindPoint(cfg.BindPoint)
+		ci.PipelineLayout = vulkan.VkPipelineLayout(cfg.PipelineLayout)
+		ci.Set = cfg.Set
+	}
+	var handle vulkan.VkDescriptorUpdateTemplate
+	res := Result(vulkan.VkCreateDescriptorUpdateTemplate(vulkan.VkDevice(d), unsafe.Pointer(&ci), nil, unsafe.Pointer(&handle)))
+	runtime.KeepAlive(&ci)
+	runtime.KeepAlive(entries)
+	if err := res.asError("vkCreateDescriptorUpdateTemplate"); err != nil {
+		return nil, err
+	}
+	return &DescriptorTemplate{device: d, handle: handle, typ: rt, layout: cfg.PipelineLayout, set: cfg.Set}, nil
+}
+
+// Destroy destroys the template.
+func (t *DescriptorTemplate) Destroy() {
+	if t.handle != 0 {
+		vulkan.VkDestroyDescriptorUpdateTemplate(vulkan.VkDevice(t.device), t.handle, nil)
+	}
+	*t = DescriptorTemplate{}
+}
+
+// data returns a pointer to v's struct, which must be of t's type, and the
+// value keeping it reachable. A struct value is copied; a pointer is not.
+func (t *DescriptorTemplate) data(v any) (unsafe.Pointer, any, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && rv.Type().Elem() == t.typ && !rv.IsNil() {
+		return rv.UnsafePointer(), v, nil
+	}
+	if !rv.IsValid() || rv.Type() != t.typ {
+		return nil, nil, fmt.Errorf("vk: descriptor template for %s given %T", t.typ, v)
+	}
+	p := reflect.New(t.typ)
+	p.Elem().Set(rv)
+	return p.UnsafePointer(), p.Interface(), nil
+}
+
+// UpdateWithTemplate writes set from data, a value of or pointer to the
+// struct type tmpl was created for. Passing a pointer avoids a copy.
+func (d Device) UpdateWithTemplate(set DescriptorSet, tmpl *DescriptorTemplate, data any) error {
+	if tmpl.layout != 0 {
+		return fmt.Errorf("vk: UpdateWithTemplate with a push descriptor template")
+	}
+	p, pin, err := tmpl.data(data)
+	if err != nil {
+		return err
+	}
+	vulkan.VkUpdateDescriptorSetWithTemplate(vulkan.VkDevice(d), vulkan.VkDescriptorSet(set), tmpl.handle, p)
+	runtime.KeepAlive(pin)
+	return nil
+}
+
+// PushDescriptorSet records the writes queued in b as set number set of
+// layout, without allocating a descriptor set, and resets b. The set numbers
+// given to b's methods are ignored. The set must have been created with
+// CreatePushDescriptorSetLayout.
+func (c CommandBuffer) PushDescriptorSet(bindPoint uint32, layout PipelineLayout, set uint32, b *DescriptorSetBuilder) {
+	if len(b.writes) == 0 {
+		return
+	}
+	writes := b.vk()
+	vulkan.VkCmdPushDescriptorSet(vulkan.VkCommandBuffer(c), vulkan.VkPipelineBindPoint(bindPoint), vulkan.VkPipelineLayout(layout),
+		set, uint32(len(writes)), unsafe.Pointer(&writes[0]))
+	runtime.KeepAlive(writes)
+	runtime.KeepAlive(b.buffers)
+	runtime.KeepAlive(b.images)
+	runtime.KeepAlive(b.views)
+	b.Reset()
+}
+
+// PushDescriptorSetWithTemplate records a push of the set tmpl was created
+// for, reading its descriptors from data as UpdateWithTemplate does. tmpl must
+// be a push descriptor template (DescriptorTemplateConfig.PipelineLayout).
+func (c CommandBuffer) PushDescriptorSetWithTemplate(tmpl *DescriptorTemplate, data any) error {
+	if tmpl.layout == 0 {
+		return fmt.Errorf("vk: PushDescriptorSetWithTemplate with a descriptor set template")
+	}
+	p, pin, err := tmpl.data(data)
+	if err != nil {
+		return err
+	}
+	vulkan.VkCmdPushDescriptorSetWithTemplate(vulkan.VkCommandBuffer(c), tmpl.handle, vulkan.VkPipelineLayout(tmpl.layout), tmpl.set, p)
+	runtime.KeepAlive(pin)
+	return nil
+}
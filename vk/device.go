@@ -46,44 +46,249 @@ func (pd PhysicalDevice) GraphicsFamily() (uint32, error) {
 	return 0, fmt.Errorf("vk: no graphics queue family")
 }
 
+// ComputeFamily returns the index of a queue family supporting compute,
+// preferring a dedicated one (compute without graphics) so compute work can run
+// asynchronously to rendering. It returns an error if no family has compute.
+func (pd PhysicalDevice) ComputeFamily() (uint32, error) {
+	families := pd.QueueFamilies()
+	for i, f := range families {
+		if f.QueueFlags&QueueComputeBit != 0 && f.QueueFlags&QueueGraphicsBit == 0 {
+			return uint32(i), nil
+		}
+	}
+	for i, f := range families {
+		if f.QueueFlags&QueueComputeBit != 0 {
+			return uint32(i), nil
+		}
+	}
+	return 0, fmt.Errorf("vk: no compute queue family")
+}
+
 // DeviceConfig describes how to create a logical device.
 type DeviceConfig struct {
 	GraphicsFamily uint32
-	Extensions     []string // e.g. "VK_KHR_swapchain"
+	// QueueFamilies lists additional families to create one queue in, such as
+	// a dedicated compute family from ComputeFamily. Fetch those queues with
+	// Device.Queue. Duplicates of GraphicsFamily are ignored.
+	QueueFamilies []uint32
+	Extensions    []string // e.g. "VK_KHR_swapchain"
+	// BufferDeviceAddress enables the Vulkan 1.2 bufferDeviceAddress feature
+	// needed by BufferConfig.DeviceAddress. On a Vulkan 1.1 device
+	// "VK_KHR_buffer_device_address" must also be named in Extensions.
+	BufferDeviceAddress bool
+	// DynamicRendering enables the Vulkan 1.3 dynamicRendering feature needed
+	// by CommandBuffer.BeginRendering. On an older device
+	// "VK_KHR_dynamic_rendering" must also be named in Extensions.
+	DynamicRendering bool
+	// Synchronization2 enables the Vulkan 1.3 synchronization2 feature
+	// needed by CommandBuffer.PipelineBarrier2 and Queue.Submit2. On an older
+	// device "VK_KHR_synchronization2" must also be named in Extensions.
+	Synchronization2 bool
+	// TimelineSemaphore enables the Vulkan 1.2 timelineSemaphore feature
+	// needed by CreateTimelineSemaphore. On a Vulkan 1.1 device
+	// "VK_KHR_timeline_semaphore" must also be named in Extensions.
+	TimelineSemaphore bool
+	// DescriptorIndexing enables the Vulkan 1.2 descriptor indexing features
+	// the device supports: runtime descriptor arrays, non-uniform indexing,
+	// and the DescriptorBinding* flags used by BindlessTable.
+	DescriptorIndexing bool
+
+	// Core features used by the matching GraphicsPipelineConfig options:
+	// geometry and tessellation stages, SampleShading, DepthClamp,
+	// DepthBias.Clamp, differing ColorBlend states, and non-fill PolygonMode.
+	GeometryShader     bool
+	TessellationShader bool
+	SampleRateShading  bool
+	DepthClamp         bool
+	DepthBiasClamp     bool
+	IndependentBlend   bool
+	FillModeNonSolid   bool
+	// ExtendedDynamicState3 enables every VK_EXT_extended_dynamic_state3
+	// feature the device supports, so any of its dynamic states the driver
+	// offers can be listed in GraphicsPipelineConfig.DynamicStates. The
+	// extension must also be named in Extensions.
+	ExtendedDynamicState3 bool
+	// ShaderObject enables the VK_EXT_shader_object feature needed by
+	// Device.CreateShaders. The extension must also be named in Extensions,
+	// and drawing with shader objects needs DynamicRendering.
+	ShaderObject bool
+	// DescriptorBuffer enables the VK_EXT_descriptor_buffer feature needed by
+	// CreateDescriptorBuffer. The extension must also be named in Extensions,
+	// and descriptor buffers need BufferDeviceAddress.
+	DescriptorBuffer bool
 }
 
 // CreateDevice creates a logical device with a single graphics queue.
 func (pd PhysicalDevice) CreateDevice(cfg DeviceConfig) (Device, Queue, error) {
 	priority := float32(1.0)
-	qci := vulkan.VkDeviceQueueCreateInfo{
+	qcis := []vulkan.VkDeviceQueueCreateInfo{{
 		SType:            vulkan.VkStructureType(stDeviceQueueCreateInfo),
 		QueueFamilyIndex: cfg.GraphicsFamily,
 		QueueCount:       1,
 		PQueuePriorities: unsafe.Pointer(&priority),
+	}}
+	for _, fam := range cfg.QueueFamilies {
+		dup := false
+		for _, q := range qcis {
+			dup = dup || q.QueueFamilyIndex == fam
+		}
+		if !dup {
+			qcis = append(qcis, vulkan.VkDeviceQueueCreateInfo{
+				SType:            vulkan.VkStructureType(stDeviceQueueCreateInfo),
+				QueueFamilyIndex: fam,
+				QueueCount:       1,
+				PQueuePriorities: unsafe.Pointer(&priority),
+			})
+		}
 	}
 	exts, extsPin := cstrArray(cfg.Extensions)
 	dci := vulkan.VkDeviceCreateInfo{
 		SType:                   vulkan.VkStructureType(stDeviceCreateInfo),
-		QueueCreateInfoCount:    1,
-		PQueueCreateInfos:       unsafe.Pointer(&qci),
+		QueueCreateInfoCount:    uint32(len(qcis)),
+		PQueueCreateInfos:       unsafe.Pointer(&qcis[0]),
 		EnabledExtensionCount:   uint32(len(cfg.Extensions)),
 		PpEnabledExtensionNames: unsafe.Pointer(exts),
 	}
+	// Optional features are chained through the per-version feature structs,
+	// which may not be combined with the per-extension ones they subsume. A
+	// device older than the version that promoted a feature rejects the
+	// per-version struct, so the extension's own struct is chained instead.
+	version := pd.Info().APIVersion
+	f12 := vulkan.VkPhysicalDeviceVulkan12Features{SType: vulkan.VkStructureType(stPhysicalDeviceVulkan12Features)}
+	bda := vulkan.VkPhysicalDeviceBufferDeviceAddressFeaturesKHR{SType: vulkan.VkStructureType(stPhysicalDeviceBufferDeviceAddressFeatures)}
+	if cfg.BufferDeviceAddress && version >= APIVersion12 {
+		f12.BufferDeviceAddress = 1
+	} else if cfg.BufferDeviceAddress {
+		bda.BufferDeviceAddress = 1
+		bda.PNext = dci.PNext
+		dci.PNext = unsafe.Pointer(&bda)
+	}
+	ts := vulkan.VkPhysicalDeviceTimelineSemaphoreFeaturesKHR{SType: vulkan.VkStructureType(stPhysicalDeviceTimelineSemaphoreFeatures)}
+	if cfg.TimelineSemaphore && version >= APIVersion12 {
+		f12.TimelineSemaphore = 1
+	} else if cfg.TimelineSemaphore {
+		ts.TimelineSemaphore = 1
+		ts.PNext = dci.PNext
+		dci.PNext = unsafe.Pointer(&ts)
+	}
+	if cfg.DescriptorIndexing {
+		enableDescriptorIndexing(pd, &f12)
+	}
+	f13 := vulkan.VkPhysicalDeviceVulkan13Features{SType: vulkan.VkStructureType(stPhysicalDeviceVulkan13Features)}
+	dr := vulkan.VkPhysicalDeviceDynamicRenderingFeaturesKHR{SType: vulkan.VkStructureType(stPhysicalDeviceDynamicRenderingFeatures)}
+	if cfg.DynamicRendering && version >= APIVersion13 {
+		f13.DynamicRendering = 1
+	} else if cfg.DynamicRendering {
+		dr.DynamicRendering = 1
+		dr.PNext = dci.PNext
+		dci.PNext = unsafe.Pointer(&dr)
+	}
+	sync2 := vulkan.VkPhysicalDeviceSynchronization2FeaturesKHR{SType: vulkan.VkStructureType(stPhysicalDeviceSynchronization2Features)}
+	if cfg.Synchronization2 && version >= APIVersion13 {
+		f13.Synchronization2 = 1
+	} else if cfg.Synchronization2 {
+		sync2.Synchronization2 = 1
+		sync2.PNext = dci.PNext
+		dci.PNext = unsafe.Pointer(&sync2)
+	}
+	if f12 != (vulkan.VkPhysicalDeviceVulkan12Features{SType: f12.SType}) {
+		f12.PNext = dci.PNext
+		dci.PNext = unsafe.Pointer(&f12)
+	}
+	if f13 != (vulkan.VkPhysicalDeviceVulkan13Features{SType: f13.SType}) {
+		f13.PNext = dci.PNext
+		dci.PNext = unsafe.Pointer(&f13)
+	}
+	eds3 := vulkan.VkPhysicalDeviceExtendedDynamicState3FeaturesEXT{SType: vulkan.VkStructureType(stPhysicalDeviceExtendedDynamicState3FeaturesEXT)}
+	if cfg.ExtendedDynamicState3 {
+		// Enabling an unsupported feature fails device creation, so enable
+		// exactly the supported set.
+		f2 := vulkan.VkPhysicalDeviceFeatures2{SType: vulkan.VkStructureType(stPhysicalDeviceFeatures2), PNext: unsafe.Pointer(&eds3)}
+		vulkan.VkGetPhysicalDeviceFeatures2(vulkan.VkPhysicalDevice(pd), unsafe.Pointer(&f2))
+		runtime.KeepAlive(&f2)
+		eds3.PNext = dci.PNext
+		dci.PNext = unsafe.Pointer(&eds3)
+	}
+	so := vulkan.VkPhysicalDeviceShaderObjectFeaturesEXT{SType: vulkan.VkStructureType(stPhysicalDeviceShaderObjectFeaturesEXT)}
+	if cfg.ShaderObject {
+		so.ShaderObject = 1
+		so.PNext = dci.PNext
+		dci.PNext = unsafe.Pointer(&so)
+	}
+	db := vulkan.VkPhysicalDeviceDescriptorBufferFeaturesEXT{SType: vulkan.VkStructureType(stPhysicalDeviceDescriptorBufferFeaturesEXT)}
+	if cfg.DescriptorBuffer {
+		db.DescriptorBuffer = 1
+		db.PNext = dci.PNext
+		dci.PNext = unsafe.Pointer(&db)
+	}
+	features := vulkan.VkPhysicalDeviceFeatures{
+		GeometryShader:     vkBool(cfg.GeometryShader),
+		TessellationShader: vkBool(cfg.TessellationShader),
+		SampleRateShading:  vkBool(cfg.SampleRateShading),
+		DepthClamp:         vkBool(cfg.DepthClamp),
+		DepthBiasClamp:     vkBool(cfg.DepthBiasClamp),
+		IndependentBlend:   vkBool(cfg.IndependentBlend),
+		FillModeNonSolid:   vkBool(cfg.FillModeNonSolid),
+	}
+	if features != (vulkan.VkPhysicalDeviceFeatures{}) {
+		dci.PEnabledFeatures = unsafe.Pointer(&features)
+	}
 	var device vulkan.VkDevice
 	res := Result(vulkan.VkCreateDevice(vulkan.VkPhysicalDevice(pd), unsafe.Pointer(&dci), nil, unsafe.Pointer(&device)))
 	runtime.KeepAlive(&priority)
-	runtime.KeepAlive(&qci)
+	runtime.KeepAlive(qcis)
 	runtime.KeepAlive(&dci)
+	runtime.KeepAlive(&f12)
+	runtime.KeepAlive(&bda)
+	runtime.KeepAlive(&ts)
+	runtime.KeepAlive(&f13)
+	runtime.KeepAlive(&dr)
+	runtime.KeepAlive(&sync2)
+	runtime.KeepAlive(&eds3)
+	runtime.KeepAlive(&so)
+	runtime.KeepAlive(&db)
+	runtime.KeepAlive(&features)
 	runtime.KeepAlive(extsPin)
 	if err := res.asError("vkCreateDevice"); err != nil {
 		return 0, 0, err
 	}
 	vulkan.LoadDevice(device)
+	vulkan.LoadDeviceAliases(device)
 	var queue vulkan.VkQueue
 	vulkan.VkGetDeviceQueue(device, cfg.GraphicsFamily, 0, unsafe.Pointer(&queue))
 	return Device(device), Queue(queue), nil
 }
 
+// enableDescriptorIndexing sets the descriptor indexing features of f12 that
+// pd supports; enabling an unsupported one would fail device creation.
+func enableDescriptorIndexing(pd PhysicalDevice, f12 *vulkan.VkPhysicalDeviceVulkan12Features) {
+	have := vulkan.VkPhysicalDeviceVulkan12Features{SType: vulkan.VkStructureType(stPhysicalDeviceVulkan12Features)}
+	f2 := vulkan.VkPhysicalDeviceFeatures2{SType: vulkan.VkStructureType(stPhysicalDeviceFeatures2), PNext: unsafe.Pointer(&have)}
+	vulkan.VkGetPhysicalDeviceFeatures2(vulkan.VkPhysicalDevice(pd), unsafe.Pointer(&f2))
+	runtime.KeepAlive(&f2)
+	f12.DescriptorIndexing = have.DescriptorIndexing
+	f12.ShaderUniformBufferArrayNonUniformIndexing = have.ShaderUniformBufferArrayNonUniformIndexing
+	f12.ShaderSampledImageArrayNonUniformIndexing = have.ShaderSampledImageArrayNonUniformIndexing
+	f12.ShaderStorageBufferArrayNonUniformIndexing = have.ShaderStorageBufferArrayNonUniformIndexing
+	f12.ShaderStorageImageArrayNonUniformIndexing = have.ShaderStorageImageArrayNonUniformIndexing
+	f12.DescriptorBindingUniformBufferUpdateAfterBind = have.DescriptorBindingUniformBufferUpdateAfterBind
+	f12.DescriptorBindingSampledImageUpdateAfterBind = have.DescriptorBindingSampledImageUpdateAfterBind
+	f12.DescriptorBindingStorageImageUpdateAfterBind = have.DescriptorBindingStorageImageUpdateAfterBind
+	f12.DescriptorBindingStorageBufferUpdateAfterBind = have.DescriptorBindingStorageBufferUpdateAfterBind
+	f12.DescriptorBindingUpdateUnusedWhilePending = have.DescriptorBindingUpdateUnusedWhilePending
+	f12.DescriptorBindingPartiallyBound = have.DescriptorBindingPartiallyBound
+	f12.DescriptorBindingVariableDescriptorCount = have.DescriptorBindingVariableDescriptorCount
+	f12.RuntimeDescriptorArray = have.RuntimeDescriptorArray
+}
+
+// Queue returns queue index of the given family. The family must have been
+// requested through DeviceConfig.GraphicsFamily or DeviceConfig.QueueFamilies.
+func (d Device) Queue(family, index uint32) Queue {
+	var queue vulkan.VkQueue
+	vulkan.VkGetDeviceQueue(vulkan.VkDevice(d), family, index, unsafe.Pointer(&queue))
+	return Queue(queue)
+}
+
 // Destroy destroys the logical device.
 func (d Device) Destroy() {
 	if d != 0 {
@@ -16,15 +16,20 @@ type (
 	DescriptorSet         uint64
 	CommandPool           uint64
 	Buffer                uint64
+	BufferView            uint64
 	DeviceMemory          uint64
 	Semaphore             uint64
 	Fence                 uint64
 	Sampler               uint64
+	ShaderEXT             uint64
 )
 
 // DeviceSize is VkDeviceSize.
 type DeviceSize uint64
 
+// DeviceAddress is VkDeviceAddress, a GPU virtual address.
+type DeviceAddress uint64
+
 // Structure type values used by this binding.
 const (
 	stSubmitInfo                            uint32 = 4
@@ -32,12 +37,15 @@ const (
 	stFenceCreateInfo                       uint32 = 8
 	stSemaphoreCreateInfo                   uint32 = 9
 	stBufferCreateInfo                      uint32 = 12
+	stBufferViewCreateInfo                  uint32 = 13
 	stImageCreateInfo                       uint32 = 14
 	stImageViewCreateInfo                   uint32 = 15
 	stShaderModuleCreateInfo                uint32 = 16
+	stPipelineCacheCreateInfo               uint32 = 17
 	stPipelineShaderStageCreateInfo         uint32 = 18
 	stPipelineVertexInputStateCreateInfo    uint32 = 19
 	stPipelineInputAssemblyStateCreateInfo  uint32 = 20
+	stPipelineTessellationStateCreateInfo   uint32 = 21
 	stPipelineViewportStateCreateInfo       uint32 = 22
 	stPipelineRasterizationStateCreateInfo  uint32 = 23
 	stPipelineMultisampleStateCreateInfo    uint32 = 24
@@ -45,6 +53,7 @@ const (
 	stPipelineColorBlendStateCreateInfo     uint32 = 26
 	stPipelineDynamicStateCreateInfo        uint32 = 27
 	stGraphicsPipelineCreateInfo            uint32 = 28
+	stComputePipelineCreateInfo             uint32 = 29
 	stPipelineLayoutCreateInfo              uint32 = 30
 	stDescriptorSetLayoutCreateInfo         uint32 = 32
 	stDescriptorPoolCreateInfo              uint32 = 33
@@ -57,10 +66,54 @@ const (
 	stCommandBufferBeginInfo                uint32 = 42
 	stRenderPassBeginInfo                   uint32 = 43
 	stImageMemoryBarrier                    uint32 = 45
+	stMemoryBarrier                         uint32 = 46
+	stMappedMemoryRange                     uint32 = 6
+	stPhysicalDeviceVulkan12Features        uint32 = 51
+	stPhysicalDeviceVulkan13Features        uint32 = 53
+	stPhysicalDeviceFeatures2               uint32 = 1000059000
+	stPhysicalDeviceBufferDeviceAddressFeatures uint32 = 1000257000
+	stPhysicalDeviceExtendedDynamicState3FeaturesEXT uint32 = 1000455000
+	stRenderingInfo                         uint32 = 1000044000
+	stRenderingAttachmentInfo               uint32 = 1000044001
+	stPipelineRenderingCreateInfo           uint32 = 1000044002
+	stPhysicalDeviceDynamicRenderingFeatures uint32 = 1000044003
+	stMemoryAllocateFlagsInfo               uint32 = 1000060000
+	stBufferDeviceAddressInfo               uint32 = 1000244001
+	stAttachmentDescription2                uint32 = 1000109000
+	stAttachmentReference2                  uint32 = 1000109001
+	stSubpassDescription2                   uint32 = 1000109002
+	stSubpassDependency2                    uint32 = 1000109003
+	stRenderPassCreateInfo2                 uint32 = 1000109004
+	stSubpassDescriptionDepthStencilResolve uint32 = 1000199001
 	stSamplerCreateInfo                     uint32 = 31
 	stSwapchainCreateInfoKHR                uint32 = 1000001000
 	stPresentInfoKHR                        uint32 = 1000001001
 	stDebugUtilsMessengerCreateInfoEXT      uint32 = 1000128004
+	stShaderCreateInfoEXT                   uint32 = 1000482002
+	stDescriptorSetLayoutBindingFlagsCreateInfo        uint32 = 1000161000
+	stDescriptorUpdateTemplateCreateInfo               uint32 = 1000085000
+	stDescriptorSetVariableDescriptorCountAllocateInfo uint32 = 1000161003
+	stPhysicalDeviceShaderObjectFeaturesEXT uint32 = 1000482000
+	stVertexInputBindingDescription2EXT     uint32 = 1000352001
+	stVertexInputAttributeDescription2EXT   uint32 = 1000352002
+	stPhysicalDeviceProperties2             uint32 = 1000059001
+	stPhysicalDeviceDescriptorBufferPropertiesEXT uint32 = 1000316000
+	stPhysicalDeviceDescriptorBufferFeaturesEXT   uint32 = 1000316002
+	stDescriptorAddressInfoEXT              uint32 = 1000316003
+	stDescriptorGetInfoEXT                  uint32 = 1000316004
+	stDescriptorBufferBindingInfoEXT        uint32 = 1000316011
+	stMemoryBarrier2                        uint32 = 1000314000
+	stBufferMemoryBarrier2                  uint32 = 1000314001
+	stImageMemoryBarrier2                   uint32 = 1000314002
+	stDependencyInfo                        uint32 = 1000314003
+	stPhysicalDeviceSynchronization2Features uint32 = 1000314007
+	stSubmitInfo2                           uint32 = 1000314004
+	stSemaphoreSubmitInfo                   uint32 = 1000314005
+	stCommandBufferSubmitInfo               uint32 = 1000314006
+	stSemaphoreTypeCreateInfo               uint32 = 1000207002
+	stSemaphoreWaitInfo                     uint32 = 1000207004
+	stSemaphoreSignalInfo                   uint32 = 1000207005
+	stPhysicalDeviceTimelineSemaphoreFeatures uint32 = 1000207000
 )
 
 // Format values (VkFormat), the subset the binding uses.
@@ -75,8 +128,29 @@ const (
 	FormatR32G32Sfloat      Format = 103
 	FormatR32G32B32Sfloat   Format = 106
 	FormatR32G32B32A32Sfloat Format = 109
+	FormatR16Sfloat          Format = 76
+	FormatR16G16Sfloat       Format = 83
+	FormatR16G16B16Sfloat    Format = 90
+	FormatR16G16B16A16Sfloat Format = 97
+	FormatR32Uint            Format = 98
+	FormatR32Sint            Format = 99
+	FormatR32G32Uint         Format = 101
+	FormatR32G32Sint         Format = 102
+	FormatR32G32B32Uint      Format = 104
+	FormatR32G32B32Sint      Format = 105
+	FormatR32G32B32A32Uint   Format = 107
+	FormatR32G32B32A32Sint   Format = 108
+	FormatR64Sfloat          Format = 112
+	FormatR64G64Sfloat       Format = 115
+	FormatR64G64B64Sfloat    Format = 118
+	FormatR64G64B64A64Sfloat Format = 121
+	FormatD16Unorm          Format = 124
+	FormatX8D24UnormPack32  Format = 125
 	FormatD32Sfloat         Format = 126
+	FormatS8Uint            Format = 127
+	FormatD16UnormS8Uint    Format = 128
 	FormatD24UnormS8Uint    Format = 129
+	FormatD32SfloatS8Uint   Format = 130
 )
 
 // Image layouts (VkImageLayout).
@@ -87,9 +161,14 @@ const (
 	LayoutGeneral                       ImageLayout = 1
 	LayoutColorAttachmentOptimal        ImageLayout = 2
 	LayoutDepthStencilAttachmentOptimal ImageLayout = 3
+	LayoutDepthStencilReadOnlyOptimal   ImageLayout = 4
 	LayoutShaderReadOnlyOptimal         ImageLayout = 5
 	LayoutTransferSrcOptimal            ImageLayout = 6
 	LayoutTransferDstOptimal            ImageLayout = 7
+	LayoutDepthAttachmentOptimal        ImageLayout = 1000241000
+	LayoutStencilAttachmentOptimal      ImageLayout = 1000241002
+	LayoutReadOnlyOptimal               ImageLayout = 1000314000
+	LayoutAttachmentOptimal             ImageLayout = 1000314001
 	LayoutPresentSrcKHR                 ImageLayout = 1000001002
 )
 
@@ -98,18 +177,30 @@ const (
 	ImageUsageTransferSrc            uint32 = 0x00000001
 	ImageUsageTransferDst            uint32 = 0x00000002
 	ImageUsageSampled                uint32 = 0x00000004
+	ImageUsageStorage                uint32 = 0x00000008
 	ImageUsageColorAttachment        uint32 = 0x00000010
 	ImageUsageDepthStencilAttachment uint32 = 0x00000020
+	ImageUsageTransientAttachment    uint32 = 0x00000040
+	ImageUsageInputAttachment        uint32 = 0x00000080
 )
 
 // Buffer usage flag bits (VkBufferUsageFlagBits).
 const (
-	BufferUsageTransferSrc   uint32 = 0x00000001
-	BufferUsageTransferDst   uint32 = 0x00000002
-	BufferUsageUniformBuffer uint32 = 0x00000010
-	BufferUsageStorageBuffer uint32 = 0x00000020
-	BufferUsageIndexBuffer   uint32 = 0x00000040
-	BufferUsageVertexBuffer  uint32 = 0x00000080
+	BufferUsageTransferSrc         uint32 = 0x00000001
+	BufferUsageTransferDst         uint32 = 0x00000002
+	BufferUsageUniformTexelBuffer  uint32 = 0x00000004
+	BufferUsageStorageTexelBuffer  uint32 = 0x00000008
+	BufferUsageUniformBuffer       uint32 = 0x00000010
+	BufferUsageStorageBuffer       uint32 = 0x00000020
+	BufferUsageIndexBuffer         uint32 = 0x00000040
+	BufferUsageVertexBuffer        uint32 = 0x00000080
+	BufferUsageIndirectBuffer      uint32 = 0x00000100
+	BufferUsageShaderDeviceAddress uint32 = 0x00020000
+)
+
+// Memory allocate flag bits (VkMemoryAllocateFlagBits).
+const (
+	memoryAllocateDeviceAddress uint32 = 0x00000002
 )
 
 // Memory property flag bits (VkMemoryPropertyFlagBits).
@@ -117,52 +208,85 @@ const (
 	MemoryDeviceLocal  uint32 = 0x00000001
 	MemoryHostVisible  uint32 = 0x00000002
 	MemoryHostCoherent uint32 = 0x00000004
+	MemoryHostCached   uint32 = 0x00000008
 )
 
 // Sample count (VkSampleCountFlagBits).
 const (
-	SampleCount1 uint32 = 0x00000001
+	SampleCount1  uint32 = 0x00000001
+	SampleCount2  uint32 = 0x00000002
+	SampleCount4  uint32 = 0x00000004
+	SampleCount8  uint32 = 0x00000008
+	SampleCount16 uint32 = 0x00000010
+	SampleCount32 uint32 = 0x00000020
+	SampleCount64 uint32 = 0x00000040
 )
 
 // Pipeline stage flag bits (VkPipelineStageFlagBits).
 const (
 	StageTopOfPipe            uint32 = 0x00000001
+	StageDrawIndirect         uint32 = 0x00000002
 	StageVertexShader         uint32 = 0x00000008
 	StageEarlyFragmentTests   uint32 = 0x00000100
+	StageLateFragmentTests    uint32 = 0x00000200
 	StageColorAttachmentOutput uint32 = 0x00000400
 	StageFragmentShader       uint32 = 0x00000080
+	StageComputeShader        uint32 = 0x00000800
 	StageTransfer             uint32 = 0x00001000
 	StageBottomOfPipe         uint32 = 0x00002000
+	StageHost                 uint32 = 0x00004000
 )
 
 // Access flag bits (VkAccessFlagBits).
 const (
+	AccessIndirectCommandRead         uint32 = 0x00000001
+	AccessInputAttachmentRead         uint32 = 0x00000010
 	AccessShaderRead                  uint32 = 0x00000020
+	AccessShaderWrite                 uint32 = 0x00000040
+	AccessColorAttachmentRead         uint32 = 0x00000080
 	AccessColorAttachmentWrite        uint32 = 0x00000100
+	AccessDepthStencilAttachmentRead  uint32 = 0x00000200
 	AccessDepthStencilAttachmentWrite uint32 = 0x00000400
 	AccessTransferRead                uint32 = 0x00000800
 	AccessTransferWrite               uint32 = 0x00001000
+	AccessHostRead                    uint32 = 0x00002000
+	AccessHostWrite                   uint32 = 0x00004000
 )
 
 // Image aspect flag bits (VkImageAspectFlagBits).
 const (
-	AspectColor uint32 = 0x00000001
-	AspectDepth uint32 = 0x00000002
+	AspectColor   uint32 = 0x00000001
+	AspectDepth   uint32 = 0x00000002
+	AspectStencil uint32 = 0x00000004
 )
 
 // Shader stage flag bits (VkShaderStageFlagBits).
 const (
-	ShaderStageVertex   uint32 = 0x00000001
-	ShaderStageFragment uint32 = 0x00000010
+	ShaderStageVertex         uint32 = 0x00000001
+	ShaderStageTessControl    uint32 = 0x00000002
+	ShaderStageTessEvaluation uint32 = 0x00000004
+	ShaderStageGeometry       uint32 = 0x00000008
+	ShaderStageFragment       uint32 = 0x00000010
+	ShaderStageCompute        uint32 = 0x00000020
+	ShaderStageAllGraphics    uint32 = 0x0000001F
 )
 
 // Descriptor type (VkDescriptorType).
 type DescriptorType uint32
 
 const (
-	DescriptorUniformBuffer        DescriptorType = 6
-	DescriptorStorageBuffer        DescriptorType = 7
-	DescriptorCombinedImageSampler DescriptorType = 1
+	DescriptorUniformBuffer         DescriptorType = 6
+	DescriptorStorageBuffer         DescriptorType = 7
+	DescriptorCombinedImageSampler  DescriptorType = 1
+	DescriptorStorageImage          DescriptorType = 3
+	DescriptorSampler               DescriptorType = 0
+	DescriptorSampledImage          DescriptorType = 2
+	DescriptorUniformTexelBuffer    DescriptorType = 4
+	DescriptorStorageTexelBuffer    DescriptorType = 5
+	DescriptorUniformBufferDynamic  DescriptorType = 8
+	DescriptorStorageBufferDynamic  DescriptorType = 9
+	DescriptorInputAttachment       DescriptorType = 10
+	DescriptorAccelerationStructure DescriptorType = 1000150000
 )
 
 // Vertex input rate (VkVertexInputRate).
@@ -173,20 +297,28 @@ const (
 
 // Primitive topology (VkPrimitiveTopology).
 const (
-	TopologyTriangleList uint32 = 3
+	TopologyPointList     uint32 = 0
+	TopologyLineList      uint32 = 1
+	TopologyLineStrip     uint32 = 2
+	TopologyTriangleList  uint32 = 3
+	TopologyTriangleStrip uint32 = 4
+	TopologyTriangleFan   uint32 = 5
+	TopologyPatchList     uint32 = 10
 )
 
 // Polygon mode (VkPolygonMode).
 const (
-	PolygonFill uint32 = 0
-	PolygonLine uint32 = 1
+	PolygonFill  uint32 = 0
+	PolygonLine  uint32 = 1
+	PolygonPoint uint32 = 2
 )
 
 // Cull mode flag bits (VkCullModeFlagBits).
 const (
-	CullNone  uint32 = 0
-	CullBack  uint32 = 0x00000002
-	CullFront uint32 = 0x00000001
+	CullNone         uint32 = 0
+	CullBack         uint32 = 0x00000002
+	CullFront        uint32 = 0x00000001
+	CullFrontAndBack uint32 = 0x00000003
 )
 
 // Front face (VkFrontFace).
@@ -197,11 +329,70 @@ const (
 
 // Compare op (VkCompareOp).
 const (
-	CompareNever         uint32 = 0
-	CompareLess          uint32 = 1
-	CompareLessOrEqual   uint32 = 3
+	CompareNever          uint32 = 0
+	CompareLess           uint32 = 1
+	CompareEqual          uint32 = 2
+	CompareLessOrEqual    uint32 = 3
+	CompareGreater        uint32 = 4
+	CompareNotEqual       uint32 = 6
 	CompareGreaterOrEqual uint32 = 5
-	CompareAlways        uint32 = 7
+	CompareAlways         uint32 = 7
+)
+
+// Stencil op (VkStencilOp).
+const (
+	StencilOpKeep              uint32 = 0
+	StencilOpZero              uint32 = 1
+	StencilOpReplace           uint32 = 2
+	StencilOpIncrementAndClamp uint32 = 3
+	StencilOpDecrementAndClamp uint32 = 4
+	StencilOpInvert            uint32 = 5
+	StencilOpIncrementAndWrap  uint32 = 6
+	StencilOpDecrementAndWrap  uint32 = 7
+)
+
+// Stencil face flag bits (VkStencilFaceFlagBits).
+const (
+	StencilFaceFront        uint32 = 0x00000001
+	StencilFaceBack         uint32 = 0x00000002
+	StencilFaceFrontAndBack uint32 = 0x00000003
+)
+
+// Blend factor (VkBlendFactor).
+const (
+	BlendFactorZero                  uint32 = 0
+	BlendFactorOne                   uint32 = 1
+	BlendFactorSrcColor              uint32 = 2
+	BlendFactorOneMinusSrcColor      uint32 = 3
+	BlendFactorDstColor              uint32 = 4
+	BlendFactorOneMinusDstColor      uint32 = 5
+	BlendFactorSrcAlpha              uint32 = 6
+	BlendFactorOneMinusSrcAlpha      uint32 = 7
+	BlendFactorDstAlpha              uint32 = 8
+	BlendFactorOneMinusDstAlpha      uint32 = 9
+	BlendFactorConstantColor         uint32 = 10
+	BlendFactorOneMinusConstantColor uint32 = 11
+	BlendFactorConstantAlpha         uint32 = 12
+	BlendFactorOneMinusConstantAlpha uint32 = 13
+	BlendFactorSrcAlphaSaturate      uint32 = 14
+)
+
+// Blend op (VkBlendOp).
+const (
+	BlendOpAdd             uint32 = 0
+	BlendOpSubtract        uint32 = 1
+	BlendOpReverseSubtract uint32 = 2
+	BlendOpMin             uint32 = 3
+	BlendOpMax             uint32 = 4
+)
+
+// Color component flag bits (VkColorComponentFlagBits).
+const (
+	ColorComponentR    uint32 = 0x00000001
+	ColorComponentG    uint32 = 0x00000002
+	ColorComponentB    uint32 = 0x00000004
+	ColorComponentA    uint32 = 0x00000008
+	ColorComponentRGBA uint32 = 0x0000000F
 )
 
 // Index type (VkIndexType).
@@ -217,11 +408,29 @@ const (
 	AttachmentLoadOpDontCare uint32 = 2
 	AttachmentStoreOpStore   uint32 = 0
 	AttachmentStoreOpDontCare uint32 = 1
+	AttachmentStoreOpNone    uint32 = 1000301000
+)
+
+// Resolve mode flag bits (VkResolveModeFlagBits).
+const (
+	ResolveModeNone       uint32 = 0
+	ResolveModeSampleZero uint32 = 0x00000001
+	ResolveModeAverage    uint32 = 0x00000002
+	ResolveModeMin        uint32 = 0x00000004
+	ResolveModeMax        uint32 = 0x00000008
+)
+
+// Rendering flag bits (VkRenderingFlagBits).
+const (
+	RenderingContentsSecondaryCommandBuffers uint32 = 0x00000001
+	RenderingSuspending                      uint32 = 0x00000002
+	RenderingResuming                        uint32 = 0x00000004
 )
 
 // Pipeline bind point (VkPipelineBindPoint).
 const (
 	BindPointGraphics uint32 = 0
+	BindPointCompute  uint32 = 1
 )
 
 // Subpass contents (VkSubpassContents).
@@ -286,13 +495,74 @@ const (
 
 // Dynamic state (VkDynamicState).
 const (
-	DynamicStateViewport uint32 = 0
-	DynamicStateScissor  uint32 = 1
+	DynamicStateViewport           uint32 = 0
+	DynamicStateScissor            uint32 = 1
+	DynamicStateLineWidth          uint32 = 2
+	DynamicStateDepthBias          uint32 = 3
+	DynamicStateBlendConstants     uint32 = 4
+	DynamicStateDepthBounds        uint32 = 5
+	DynamicStateStencilCompareMask uint32 = 6
+	DynamicStateStencilWriteMask   uint32 = 7
+	DynamicStateStencilReference   uint32 = 8
+
+	// Extended dynamic state (core in Vulkan 1.3).
+	DynamicStateCullMode                 uint32 = 1000267000
+	DynamicStateFrontFace                uint32 = 1000267001
+	DynamicStatePrimitiveTopology        uint32 = 1000267002
+	DynamicStateViewportWithCount        uint32 = 1000267003
+	DynamicStateScissorWithCount         uint32 = 1000267004
+	DynamicStateVertexInputBindingStride uint32 = 1000267005
+	DynamicStateDepthTestEnable          uint32 = 1000267006
+	DynamicStateDepthWriteEnable         uint32 = 1000267007
+	DynamicStateDepthCompareOp           uint32 = 1000267008
+	DynamicStateDepthBoundsTestEnable    uint32 = 1000267009
+	DynamicStateStencilTestEnable        uint32 = 1000267010
+	DynamicStateStencilOp                uint32 = 1000267011
+
+	// Extended dynamic state 2 (core in Vulkan 1.3 except PatchControlPoints
+	// and LogicOp, which need VK_EXT_extended_dynamic_state2 features).
+	DynamicStatePatchControlPoints      uint32 = 1000377000
+	DynamicStateRasterizerDiscardEnable uint32 = 1000377001
+	DynamicStateDepthBiasEnable         uint32 = 1000377002
+	DynamicStateLogicOp                 uint32 = 1000377003
+	DynamicStatePrimitiveRestartEnable  uint32 = 1000377004
+
+	// VK_EXT_vertex_input_dynamic_state and VK_EXT_color_write_enable.
+	DynamicStateVertexInput      uint32 = 1000352000
+	DynamicStateColorWriteEnable uint32 = 1000381000
+
+	// Extended dynamic state 3 (VK_EXT_extended_dynamic_state3).
+	DynamicStateTessellationDomainOrigin         uint32 = 1000455002
+	DynamicStateDepthClampEnable                 uint32 = 1000455003
+	DynamicStatePolygonMode                      uint32 = 1000455004
+	DynamicStateRasterizationSamples             uint32 = 1000455005
+	DynamicStateSampleMask                       uint32 = 1000455006
+	DynamicStateAlphaToCoverageEnable            uint32 = 1000455007
+	DynamicStateAlphaToOneEnable                 uint32 = 1000455008
+	DynamicStateLogicOpEnable                    uint32 = 1000455009
+	DynamicStateColorBlendEnable                 uint32 = 1000455010
+	DynamicStateColorBlendEquation               uint32 = 1000455011
+	DynamicStateColorWriteMask                   uint32 = 1000455012
+	DynamicStateRasterizationStream              uint32 = 1000455013
+	DynamicStateConservativeRasterizationMode    uint32 = 1000455014
+	DynamicStateExtraPrimitiveOverestimationSize uint32 = 1000455015
+	DynamicStateDepthClipEnable                  uint32 = 1000455016
+	DynamicStateSampleLocationsEnable            uint32 = 1000455017
+	DynamicStateColorBlendAdvanced               uint32 = 1000455018
+	DynamicStateProvokingVertexMode              uint32 = 1000455019
+	DynamicStateLineRasterizationMode            uint32 = 1000455020
+	DynamicStateLineStippleEnable                uint32 = 1000455021
+	DynamicStateDepthClipNegativeOneToOne        uint32 = 1000455022
 )
 
 // Subpass external constant.
 const SubpassExternal uint32 = 0xFFFFFFFF
 
+// Dependency flag bits (VkDependencyFlagBits).
+const (
+	DependencyByRegion uint32 = 0x00000001
+)
+
 // WholeSize maps to VK_WHOLE_SIZE.
 const WholeSize DeviceSize = 0xFFFFFFFFFFFFFFFF
 
@@ -0,0 +1,399 @@
+package vk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// FencePool recycles fences. It is safe for concurrent use.
+type FencePool struct {
+	device Device
+	mu     sync.Mutex
+	free   []Fence
+}
+
+// NewFencePool returns an empty pool on d.
+func NewFencePool(d Device) *FencePool { return &FencePool{device: d} }
+
+// Get returns an unsignaled fence, reusing a returned one if there is any.
+func (p *FencePool) Get() (Fence, error) {
+	p.mu.Lock()
+	if n := len(p.free); n > 0 {
+		f := p.free[n-1]
+		p.free = p.free[:n-1]
+		p.mu.Unlock()
+		return f, nil
+	}
+	p.mu.Unlock()
+	return p.device.CreateFence(false)
+}
+
+// Put resets f and returns it to the pool. No submission may still be
+// pending on f.
+func (p *FencePool) Put(f Fence) error {
+	if f == 0 {
+		return nil
+	}
+	if err := p.device.ResetFence(f); err != nil {
+		p.device.DestroyFence(f)
+		return err
+	}
+	p.mu.Lock()
+	p.free = append(p.free, f)
+	p.mu.Unlock()
+	return nil
+}
+
+// Destroy destroys the fences in the pool. Fences handed out and not put
+// back are the caller's to destroy.
+func (p *FencePool) Destroy() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, f := range p.free {
+		p.device.DestroyFence(f)
+	}
+	p.free = nil
+}
+
+// SemaphorePool recycles binary semaphores. It is safe for concurrent use.
+type SemaphorePool struct {
+	device Device
+	mu     sync.Mutex
+	free   []Semaphore
+}
+
+// NewSemaphorePool returns an empty pool on d.
+func NewSemaphorePool(d Device) *SemaphorePool { return &SemaphorePool{device: d} }
+
+// Get returns an unsignaled semaphore, reusing a returned one if there is
+// any.
+func (p *SemaphorePool) Get() (Semaphore, error) {
+	p.mu.Lock()
+	if n := len(p.free); n > 0 {
+		s := p.free[n-1]
+		p.free = p.free[:n-1]
+		p.mu.Unlock()
+		return s, nil
+	}
+	p.mu.Unlock()
+	return p.device.CreateSemaphore()
+}
+
+// Put returns s to the pool. s must be unsignaled, with no signal or wait
+// still pending.
+func (p *SemaphorePool) Put(s Semaphore) {
+	if s == 0 {
+		return
+	}
+	p.mu.Lock()
+	p.free = append(p.free, s)
+	p.mu.Unlock()
+}
+
+// Destroy destroys the semaphores in the pool.
+func (p *SemaphorePool) Destroy() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, s := range p.free {
+		p.device.DestroySemaphore(s)
+	}
+	p.free = nil
+}
+
+// ErrOutOfDate reports that the swapchain no longer matches its surface.
+// Recreate it and pass the new one to FrameRing.SetSwapchain.
+var ErrOutOfDate = errors.New("vk: swapchain out of date")
+
+// FrameRingConfig describes a FrameRing.
+type FrameRingConfig struct {
+	Queue       Queue
+	QueueFamily uint32
+	// Swapchain is presented to; 0 renders offscreen, with no acquire or
+	// present.
+	Swapchain SwapchainKHR
+	// Frames is the number of frames in flight; 0 means 2.
+	Frames int
+	// CommandBuffers is the number of command buffers per frame; 0 means 1.
+	CommandBuffers int
+	// WaitStage is the Stage* mask at which a frame waits for its acquired
+	// image; 0 means StageColorAttachmentOutput.
+	WaitStage uint32
+}
+
+// Frame is one frame in flight, between FrameRing.BeginFrame and EndFrame.
+type Frame struct {
+	// Slot is the frame's index in the ring, 0 to Frames-1, for indexing
+	// per-frame resources such as uniform buffers.
+	Slot int
+	// Number counts the frames begun before this one.
+	Number uint64
+	// Image is the index of the acquired swapchain image.
+	Image uint32
+	// Cmd is Commands[0].
+	Cmd CommandBuffer
+	// Commands are begun by BeginFrame, and ended and submitted in order by
+	// EndFrame.
+	Commands []CommandBuffer
+
+	pool      CommandPool
+	fence     Fence     // signaled when the last submission of the slot completes
+	acquire   Semaphore // signaled by the acquire, waited by the submission
+	deletions []func()
+}
+
+// Defer queues fn to run once the GPU is done with this frame: when its
+// slot is next begun, or when the ring is destroyed. It is how resources
+// the frame's commands use are released.
+func (f *Frame) Defer(fn func()) { f.deletions = append(f.deletions, fn) }
+
+// Fence returns the fence the frame was submitted with, once EndFrame has
+// submitted it, for work retired against a fence such as
+// BindlessTable.EndFrame. The ring resets the fence and returns it to its
+// pool when the slot is next begun, after which it reads as unsignaled until
+// reused, so such work is reclaimed a ring cycle late at worst. A StagingRing
+// retires through Defer instead, which runs right after the wait:
+//
+//	f.Defer(staging.EndFrameFunc())
+func (f *Frame) Fence() Fence { return f.fence }
+
+// FrameRing runs a fixed number of frames in flight. BeginFrame waits until
+// the GPU is done with the oldest frame, runs its deferred deletions, resets
+// its command buffers, and acquires a swapchain image; EndFrame submits the
+// frame and presents it. Fences and semaphores come from pools and are
+// recycled as frames complete. A FrameRing is not safe for concurrent use.
+type FrameRing struct {
+	device     Device
+	queue      Queue
+	swapchain  SwapchainKHR
+	waitStage  uint32
+	fences     *FencePool
+	semaphores *SemaphorePool
+	frames     []*Frame
+	// present holds a semaphore per swapchain image: presentation of an
+	// image may still wait on its semaphore when another frame begins.
+	present []Semaphore
+	number  uint64
+	current *Frame
+}
+
+// NewFrameRing creates a ring of frames with their command pools and
+// buffers.
+func NewFrameRing(d Device, cfg FrameRingConfig) (*FrameRing, error) {
+	n := cfg.Frames
+	if n <= 0 {
+		n = 2
+	}
+	ncmd := cfg.CommandBuffers
+	if ncmd <= 0 {
+		ncmd = 1
+	}
+	r := &FrameRing{
+		device:     d,
+		queue:      cfg.Queue,
+		waitStage:  cfg.WaitStage,
+		fences:     NewFencePool(d),
+		semaphores: NewSemaphorePool(d),
+	}
+	if r.waitStage == 0 {
+		r.waitStage = StageColorAttachmentOutput
+	}
+	for i := 0; i < n; i++ {
+		pool, err := d.CreateCommandPool(cfg.QueueFamily)
+		if err != nil {
+			r.Destroy()
+			return nil, err
+		}
+		f := &Frame{Slot: i, pool: pool}
+		r.frames = append(r.frames, f)
+		if f.Commands, err = d.AllocateCommandBuffers(pool, uint32(ncmd)); err != nil {
+			r.Destroy()
+			return nil, err
+		}
+		f.Cmd = f.Commands[0]
+	}
+	if err := r.SetSwapchain(cfg.Swapchain); err != nil {
+		r.Destroy()
+		return nil, err
+	}
+	return r, nil
+}
+
+// SetSwapchain switches presentation to sc, after a recreation or to render
+// offscreen with 0. It waits for the device to go idle first, so the old
+// swapchain may be destroyed before or after.
+func (r *FrameRing) SetSwapchain(sc SwapchainKHR) error {
+	if err := r.device.WaitIdle(); err != nil {
+		return err
+	}
+	for _, s := range r.present {
+		r.semaphores.Put(s)
+	}
+	r.present, r.swapchain = nil, sc
+	if sc == 0 {
+		return nil
+	}
+	images, err := r.device.SwapchainImages(sc)
+	if err != nil {
+		return err
+	}
+	for range images {
+		s, err := r.semaphores.Get()
+		if err != nil {
+			return err
+		}
+		r.present = append(r.present, s)
+	}
+	return nil
+}
+
+// BeginFrame starts the next frame. It returns ErrOutOfDate when the
+// swapchain must be recreated first; no frame is begun then.
+func (r *FrameRing) BeginFrame() (*Frame, error) {
+	if r.current != nil {
+		return nil, fmt.Errorf("vk: BeginFrame before EndFrame of frame %d", r.current.Number)
+	}
+	f := r.frames[r.number%uint64(len(r.frames))]
+	if err := r.retire(f); err != nil {
+		return nil, err
+	}
+	if r.swapchain != 0 {
+		sem, err := r.semaphores.Get()
+		if err != nil {
+			return nil, err
+		}
+		img, res := r.device.AcquireNextImage(r.swapchain, sem, math.MaxUint64)
+		switch res {
+		case Success, SuboptimalKHR:
+		case ErrorOutOfDateKHR:
+			r.semaphores.Put(sem)
+			return nil, ErrOutOfDate
+		default:
+			r.semaphores.Put(sem)
+			return nil, res.asError("vkAcquireNextImageKHR")
+		}
+		f.Image, f.acquire = img, sem
+	}
+	if err := r.device.ResetCommandPool(f.pool); err != nil {
+		r.abandon(f)
+		return nil, err
+	}
+	for _, c := range f.Commands {
+		if err := c.Begin(CommandBufferOneTimeSubmit); err != nil {
+			r.abandon(f)
+			return nil, err
+		}
+	}
+	f.Number = r.number
+	r.number++
+	r.current = f
+	return f, nil
+}
+
+// retire waits for the last submission of f's slot and recycles what it
+// held.
+func (r *FrameRing) retire(f *Frame) error {
+	if f.fence != 0 {
+		if err := r.device.WaitFence(f.fence, math.MaxUint64); err != nil {
+			return err
+		}
+		if err := r.fences.Put(f.fence); err != nil {
+			return err
+		}
+		f.fence = 0
+		r.semaphores.Put(f.acquire)
+		f.acquire = 0
+	}
+	for _, fn := range f.deletions {
+		fn()
+	}
+	clear(f.deletions)
+	f.deletions = f.deletions[:0]
+	return nil
+}
+
+// EndFrame ends f's command buffers, submits them, and presents the frame's
+// image. It returns ErrOutOfDate when presentation found the swapchain out
+// of date or suboptimal; the frame was still submitted.
+func (r *FrameRing) EndFrame(f *Frame) error {
+	if f == nil || f != r.current {
+		return errors.New("vk: EndFrame of a frame not begun")
+	}
+	r.current = nil
+	for _, c := range f.Commands {
+		if err := c.End(); err != nil {
+			r.abandon(f)
+			return err
+		}
+	}
+	fence, err := r.fences.Get()
+	if err != nil {
+		r.abandon(f)
+		return err
+	}
+	var signal Semaphore
+	if r.swapchain != 0 {
+		signal = r.present[f.Image]
+	}
+	if err := r.queue.submit(f.Commands, f.acquire, r.waitStage, signal, fence); err != nil {
+		_ = r.fences.Put(fence)
+		r.abandon(f)
+		return err
+	}
+	f.fence = fence
+	if r.swapchain == 0 {
+		return nil
+	}
+	switch res := r.queue.Present(r.swapchain, f.Image, signal); res {
+	case Success:
+		return nil
+	case SuboptimalKHR, ErrorOutOfDateKHR:
+		return ErrOutOfDate
+	default:
+		return res.asError("vkQueuePresentKHR")
+	}
+}
+
+// abandon gives the image a frame acquired back to the swapchain when the
+// frame fails before its submission: an empty batch waits on the acquire and
+// signals the image's present semaphore, and the image is presented
+// unchanged. The batch's fence becomes the frame's, so the acquire semaphore
+// returns to the pool when the slot is next begun.
+func (r *FrameRing) abandon(f *Frame) {
+	if f.acquire == 0 {
+		return
+	}
+	fence, err := r.fences.Get()
+	if err != nil {
+		// Nothing tells when the acquire has signaled, so the semaphore
+		// cannot be reused; leave it to the device.
+		f.acquire = 0
+		return
+	}
+	signal := r.present[f.Image]
+	if err := r.queue.submit(nil, f.acquire, r.waitStage, signal, fence); err != nil {
+		_ = r.fences.Put(fence)
+		f.acquire = 0
+		return
+	}
+	f.fence = fence
+	r.queue.Present(r.swapchain, f.Image, signal)
+}
+
+// Destroy waits for the device to go idle, runs every frame's deferred
+// deletions, and destroys the ring's objects.
+func (r *FrameRing) Destroy() {
+	_ = r.device.WaitIdle()
+	for _, f := range r.frames {
+		_ = r.retire(f)
+		r.semaphores.Put(f.acquire)
+		r.device.DestroyCommandPool(f.pool)
+	}
+	for _, s := range r.present {
+		r.semaphores.Put(s)
+	}
+	r.frames, r.present, r.current = nil, nil, nil
+	r.fences.Destroy()
+	r.semaphores.Destroy()
+}
@@ -33,3 +33,11 @@ func cStringAt(p uintptr) string {
 	}
 	return string(unsafe.Slice((*byte)(unsafe.Pointer(p)), n))
 }
+
+// vkBool converts b to a VkBool32.
+func vkBool(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -112,6 +112,9 @@ type DeviceInfo struct {
 	DriverVersion uint32
 	VendorID      uint32
 	DeviceID      uint32
+	// PipelineCacheUUID identifies the driver build whose pipeline cache
+	// blobs this device accepts.
+	PipelineCacheUUID [16]byte
 }
 
 // EnumeratePhysicalDevices returns the physical devices on the instance.
@@ -135,11 +138,12 @@ func (pd PhysicalDevice) Info() DeviceInfo {
 	var props vulkan.VkPhysicalDeviceProperties
 	vulkan.VkGetPhysicalDeviceProperties(vulkan.VkPhysicalDevice(pd), unsafe.Pointer(&props))
 	return DeviceInfo{
-		Name:          goStr(props.DeviceName[:]),
-		Type:          PhysicalDeviceType(props.DeviceType),
-		APIVersion:    props.ApiVersion,
-		DriverVersion: props.DriverVersion,
-		VendorID:      props.VendorID,
-		DeviceID:      props.DeviceID,
+		Name:              goStr(props.DeviceName[:]),
+		Type:              PhysicalDeviceType(props.DeviceType),
+		APIVersion:        props.ApiVersion,
+		DriverVersion:     props.DriverVersion,
+		VendorID:          props.VendorID,
+		DeviceID:          props.DeviceID,
+		PipelineCacheUUID: props.PipelineCacheUUID,
 	}
 }
@@ -31,14 +31,34 @@ type MemoryRequirements struct {
 // memoryTypeIndex finds a memory type supporting typeBits with the given
 // property flags.
 func (pd PhysicalDevice) memoryTypeIndex(typeBits, props uint32) (uint32, error) {
+	idx, _, err := pd.memoryType(typeBits, props, 0)
+	return idx, err
+}
+
+// memoryType finds a memory type supporting typeBits with all of the required
+// property flags, favoring one that also has all of the preferred flags. It
+// returns the type index and that type's full property flags.
+func (pd PhysicalDevice) memoryType(typeBits, required, preferred uint32) (uint32, uint32, error) {
 	var mp vulkan.VkPhysicalDeviceMemoryProperties
 	vulkan.VkGetPhysicalDeviceMemoryProperties(vulkan.VkPhysicalDevice(pd), unsafe.Pointer(&mp))
-	for i := uint32(0); i < mp.MemoryTypeCount; i++ {
-		if typeBits&(1<<i) != 0 && mp.MemoryTypes[i].PropertyFlags&props == props {
-			return i, nil
+	for _, want := range []uint32{required | preferred, required} {
+		for i := uint32(0); i < mp.MemoryTypeCount; i++ {
+			flags := mp.MemoryTypes[i].PropertyFlags
+			if typeBits&(1<<i) != 0 && flags&want == want {
+				return i, flags, nil
+			}
 		}
 	}
-	return 0, fmt.Errorf("vk: no memory type for bits %#x props %#x", typeBits, props)
+	return 0, 0, fmt.Errorf("vk: no memory type for bits %#x props %#x", typeBits, required)
+}
+
+// NonCoherentAtomSize returns the device's nonCoherentAtomSize limit, the
+// granularity that flushed and invalidated ranges of non-coherent memory must
+// be aligned to.
+func (pd PhysicalDevice) NonCoherentAtomSize() DeviceSize {
+	var props vulkan.VkPhysicalDeviceProperties
+	vulkan.VkGetPhysicalDeviceProperties(vulkan.VkPhysicalDevice(pd), unsafe.Pointer(&props))
+	return DeviceSize(props.Limits.NonCoherentAtomSize)
 }
 
 // allocate allocates device memory of the given requirements and properties.
@@ -47,14 +67,30 @@ func (d Device) allocate(pd PhysicalDevice, req MemoryRequirements, props uint32
 	if err != nil {
 		return 0, err
 	}
+	return d.allocateType(idx, req.Size, 0)
+}
+
+// allocateType allocates size bytes from memory type idx. allocFlags is a
+// VkMemoryAllocateFlags value chained through VkMemoryAllocateFlagsInfo when
+// non-zero; a buffer bound anywhere in the block can only be given a device
+// address if the block was allocated with memoryAllocateDeviceAddress.
+func (d Device) allocateType(idx uint32, size DeviceSize, allocFlags uint32) (DeviceMemory, error) {
 	ai := vulkan.VkMemoryAllocateInfo{
 		SType:           vulkan.VkStructureType(stMemoryAllocateInfo),
-		AllocationSize:  vulkan.VkDeviceSize(req.Size),
+		AllocationSize:  vulkan.VkDeviceSize(size),
 		MemoryTypeIndex: idx,
 	}
+	fi := vulkan.VkMemoryAllocateFlagsInfo{
+		SType: vulkan.VkStructureType(stMemoryAllocateFlagsInfo),
+		Flags: allocFlags,
+	}
+	if allocFlags != 0 {
+		ai.PNext = unsafe.Pointer(&fi)
+	}
 	var mem vulkan.VkDeviceMemory
 	res := Result(vulkan.VkAllocateMemory(vulkan.VkDevice(d), unsafe.Pointer(&ai), nil, unsafe.Pointer(&mem)))
 	runtime.KeepAlive(&ai)
+	runtime.KeepAlive(&fi)
 	return DeviceMemory(mem), res.asError("vkAllocateMemory")
 }
 
@@ -75,12 +111,114 @@ func (d Device) Map(mem DeviceMemory, size DeviceSize) (unsafe.Pointer, error) {
 // Unmap unmaps device memory.
 func (d Device) Unmap(mem DeviceMemory) { vulkan.VkUnmapMemory(vulkan.VkDevice(d), vulkan.VkDeviceMemory(mem)) }
 
+// atomRange widens [offset, offset+size) outward to multiples of atom, the
+// alignment vkFlushMappedMemoryRanges and vkInvalidateMappedMemoryRanges
+// require. WholeSize is passed through unchanged.
+func atomRange(offset, size, atom DeviceSize) (DeviceSize, DeviceSize) {
+	if atom <= 1 {
+		return offset, size
+	}
+	start := offset / atom * atom
+	if size == WholeSize {
+		return start, WholeSize
+	}
+	end := (offset + size + atom - 1) / atom * atom
+	return start, end - start
+}
+
+// FlushMappedRange makes host writes to [offset, offset+size) of mapped,
+// non-coherent memory visible to the device. The range is widened to the
+// device's nonCoherentAtomSize, so the mapping must extend to the widened
+// end; memory allocated and mapped by CreateBuffer always does.
+func (d Device) FlushMappedRange(pd PhysicalDevice, mem DeviceMemory, offset, size DeviceSize) error {
+	return d.flushRange(mem, offset, size, pd.NonCoherentAtomSize())
+}
+
+// InvalidateMappedRange makes device writes to [offset, offset+size) of mapped,
+// non-coherent memory visible to the host. The range is widened the same way as
+// FlushMappedRange.
+func (d Device) InvalidateMappedRange(pd PhysicalDevice, mem DeviceMemory, offset, size DeviceSize) error {
+	return d.invalidateRange(mem, offset, size, pd.NonCoherentAtomSize())
+}
+
+func (d Device) flushRange(mem DeviceMemory, offset, size, atom DeviceSize) error {
+	off, n := atomRange(offset, size, atom)
+	r := vulkan.VkMappedMemoryRange{
+		SType:  vulkan.VkStructureType(stMappedMemoryRange),
+		Memory: vulkan.VkDeviceMemory(mem),
+		Offset: vulkan.VkDeviceSize(off),
+		Size:   vulkan.VkDeviceSize(n),
+	}
+	res := Result(vulkan.VkFlushMappedMemoryRanges(vulkan.VkDevice(d), 1, unsafe.Pointer(&r)))
+	runtime.KeepAlive(&r)
+	return res.asError("vkFlushMappedMemoryRanges")
+}
+
+func (d Device) invalidateRange(mem DeviceMemory, offset, size, atom DeviceSize) error {
+	off, n := atomRange(offset, size, atom)
+	r := vulkan.VkMappedMemoryRange{
+		SType:  vulkan.VkStructureType(stMappedMemoryRange),
+		Memory: vulkan.VkDeviceMemory(mem),
+		Offset: vulkan.VkDeviceSize(off),
+		Size:   vulkan.VkDeviceSize(n),
+	}
+	res := Result(vulkan.VkInvalidateMappedMemoryRanges(vulkan.VkDevice(d), 1, unsafe.Pointer(&r)))
+	runtime.KeepAlive(&r)
+	return res.asError("vkInvalidateMappedMemoryRanges")
+}
+
 // AllocBuffer bundles a buffer handle, its memory, and size.
 type AllocBuffer struct {
 	Buffer Buffer
 	Memory DeviceMemory
 	Size   DeviceSize
 	Mapped unsafe.Pointer // non-nil for host-visible buffers created with Map=true
+	// NonCoherentAtom is the device's nonCoherentAtomSize when the buffer
+	// landed in host-visible memory without HOST_COHERENT, and 0 otherwise.
+	// FlushBuffer and InvalidateBuffer are no-ops when it is 0.
+	NonCoherentAtom DeviceSize
+
+	address DeviceAddress
+}
+
+// Address returns the buffer's device address, or 0 if it was not created with
+// BufferConfig.DeviceAddress.
+func (b AllocBuffer) Address() DeviceAddress { return b.address }
+
+// BufferAddress queries the device address of buf with
+// vkGetBufferDeviceAddress. buf must have been created with
+// BufferUsageShaderDeviceAddress and bound to memory allocated with the
+// device-address flag; AllocBuffer.Address caches the result for buffers from
+// CreateBuffer.
+func (d Device) BufferAddress(buf Buffer) DeviceAddress {
+	info := vulkan.VkBufferDeviceAddressInfo{
+		SType:  vulkan.VkStructureType(stBufferDeviceAddressInfo),
+		Buffer: vulkan.VkBuffer(buf),
+	}
+	addr := vulkan.VkGetBufferDeviceAddress(vulkan.VkDevice(d), unsafe.Pointer(&info))
+	runtime.KeepAlive(&info)
+	return DeviceAddress(addr)
+}
+
+// FlushBuffer flushes host writes to [offset, offset+size) of a mapped buffer
+// so the device sees them. Call it after CopyToMapped on non-coherent memory;
+// it does nothing for coherent buffers.
+func (d Device) FlushBuffer(b AllocBuffer, offset, size DeviceSize) error {
+	if b.NonCoherentAtom == 0 {
+		return nil
+	}
+	return d.flushRange(b.Memory, offset, size, b.NonCoherentAtom)
+}
+
+// InvalidateBuffer makes device writes to [offset, offset+size) of a mapped
+// buffer visible to the host. Call it before reading back from non-coherent
+// memory, after the writing submission has completed; it does nothing for
+// coherent buffers.
+func (d Device) InvalidateBuffer(b AllocBuffer, offset, size DeviceSize) error {
+	if b.NonCoherentAtom == 0 {
+		return nil
+	}
+	return d.invalidateRange(b.Memory, offset, size, b.NonCoherentAtom)
 }
 
 // BufferConfig describes a buffer allocation.
@@ -88,15 +226,29 @@ type BufferConfig struct {
 	Size       DeviceSize
 	Usage      uint32
 	Properties uint32 // memory property flags
-	Map        bool   // keep host-visible memory persistently mapped
+	// Preferred memory property flags are used when a memory type has them on
+	// top of Properties and ignored otherwise. Readback buffers typically ask
+	// for MemoryHostVisible and prefer MemoryHostCached.
+	Preferred uint32
+	Map       bool // keep host-visible memory persistently mapped
+	// DeviceAddress adds BufferUsageShaderDeviceAddress, allocates the memory
+	// with the device-address flag, and records the address for
+	// AllocBuffer.Address. The device needs DeviceConfig.BufferDeviceAddress.
+	DeviceAddress bool
 }
 
 // CreateBuffer creates a buffer, allocates memory for it, and binds them.
 func (d Device) CreateBuffer(pd PhysicalDevice, cfg BufferConfig) (AllocBuffer, error) {
+	usage := cfg.Usage
+	var allocFlags uint32
+	if cfg.DeviceAddress {
+		usage |= BufferUsageShaderDeviceAddress
+		allocFlags = memoryAllocateDeviceAddress
+	}
 	ci := vulkan.VkBufferCreateInfo{
 		SType:       vulkan.VkStructureType(stBufferCreateInfo),
 		Size:        vulkan.VkDeviceSize(cfg.Size),
-		Usage:       cfg.Usage,
+		Usage:       usage,
 		SharingMode: vulkan.VkSharingMode(SharingModeExclusive),
 	}
 	var buf vulkan.VkBuffer
@@ -107,7 +259,23 @@ func (d Device) CreateBuffer(pd PhysicalDevice, cfg BufferConfig) (AllocBuffer,
 	}
 	var req MemoryRequirements
 	vulkan.VkGetBufferMemoryRequirements(vulkan.VkDevice(d), buf, unsafe.Pointer(&req))
-	mem, err := d.allocate(pd, req, cfg.Properties)
+	idx, flags, err := pd.memoryType(req.MemoryTypeBits, cfg.Properties, cfg.Preferred)
+	if err != nil {
+		vulkan.VkDestroyBuffer(vulkan.VkDevice(d), buf, nil)
+		return AllocBuffer{}, err
+	}
+	// Host-visible memory without HOST_COHERENT needs explicit flushes in
+	// nonCoherentAtomSize units. Rounding the allocation up to that unit keeps
+	// a flush widened past the end of the buffer inside the allocation.
+	var atom DeviceSize
+	allocSize := req.Size
+	if flags&MemoryHostVisible != 0 && flags&MemoryHostCoherent == 0 {
+		atom = pd.NonCoherentAtomSize()
+		if atom > 1 {
+			allocSize = (allocSize + atom - 1) / atom * atom
+		}
+	}
+	mem, err := d.allocateType(idx, allocSize, allocFlags)
 	if err != nil {
 		vulkan.VkDestroyBuffer(vulkan.VkDevice(d), buf, nil)
 		return AllocBuffer{}, err
@@ -117,9 +285,18 @@ func (d Device) CreateBuffer(pd PhysicalDevice, cfg BufferConfig) (AllocBuffer,
 		vulkan.VkFreeMemory(vulkan.VkDevice(d), vulkan.VkDeviceMemory(mem), nil)
 		return AllocBuffer{}, res.asError("vkBindBufferMemory")
 	}
-	ab := AllocBuffer{Buffer: Buffer(buf), Memory: mem, Size: cfg.Size}
+	ab := AllocBuffer{Buffer: Buffer(buf), Memory: mem, Size: cfg.Size, NonCoherentAtom: atom}
+	if cfg.DeviceAddress {
+		ab.address = d.BufferAddress(ab.Buffer)
+	}
 	if cfg.Map {
-		p, err := d.Map(mem, cfg.Size)
+		// Flushes widened to the atom reach past cfg.Size into the rounded-up
+		// allocation, so non-coherent memory is mapped whole.
+		mapSize := cfg.Size
+		if atom > 1 {
+			mapSize = WholeSize
+		}
+		p, err := d.Map(mem, mapSize)
 		if err != nil {
 			d.DestroyBuffer(ab)
 			return AllocBuffer{}, err
@@ -154,41 +331,79 @@ func (d Device) CreateImage2D(pd PhysicalDevice, format Format, extent Extent2D,
 // device-local memory. mipLevels must be >= 1; CreateImage2D is the mipLevels==1
 // case.
 func (d Device) CreateImage2DMips(pd PhysicalDevice, format Format, extent Extent2D, usage uint32, mipLevels uint32) (AllocImage, error) {
-	if mipLevels < 1 {
-		mipLevels = 1
+	img, err := d.CreateImage(ImageConfig{Format: format, Extent: extent, MipLevels: mipLevels, Usage: usage})
+	if err != nil {
+		return AllocImage{}, err
+	}
+	mem, err := d.AllocateMemory(pd, d.ImageMemoryRequirements(img), MemoryDeviceLocal)
+	if err != nil {
+		d.DestroyImage(AllocImage{Image: img})
+		return AllocImage{}, err
+	}
+	if err := d.BindImageMemory(img, mem, 0); err != nil {
+		d.DestroyImage(AllocImage{Image: img, Memory: mem})
+		return AllocImage{}, err
+	}
+	return AllocImage{Image: img, Memory: mem}, nil
+}
+
+// ImageConfig describes a 2D image for CreateImage.
+type ImageConfig struct {
+	Format    Format
+	Extent    Extent2D
+	MipLevels uint32 // 0 means 1
+	Samples   uint32 // SampleCount*; 0 means 1
+	Usage     uint32
+}
+
+// CreateImage creates a 2D image with optimal tiling and no memory bound, for
+// callers that place images in memory of their own, possibly shared with
+// other images; bind it with BindImageMemory. Destroy it with DestroyImage,
+// leaving AllocImage.Memory zero when the memory is freed separately.
+func (d Device) CreateImage(cfg ImageConfig) (Image, error) {
+	mips, samples := cfg.MipLevels, cfg.Samples
+	if mips < 1 {
+		mips = 1
+	}
+	if samples == 0 {
+		samples = SampleCount1
 	}
 	ci := vulkan.VkImageCreateInfo{
 		SType:         vulkan.VkStructureType(stImageCreateInfo),
 		ImageType:     vulkan.VkImageType(ImageType2D),
-		Format:        vulkan.VkFormat(format),
-		Extent:        vulkan.VkExtent3D{Width: extent.Width, Height: extent.Height, Depth: 1},
-		MipLevels:     mipLevels,
+		Format:        vulkan.VkFormat(cfg.Format),
+		Extent:        vulkan.VkExtent3D{Width: cfg.Extent.Width, Height: cfg.Extent.Height, Depth: 1},
+		MipLevels:     mips,
 		ArrayLayers:   1,
-		Samples:       SampleCount1,
+		Samples:       samples,
 		Tiling:        vulkan.VkImageTiling(ImageTilingOptimal),
-		Usage:         usage,
+		Usage:         cfg.Usage,
 		SharingMode:   vulkan.VkSharingMode(SharingModeExclusive),
 		InitialLayout: vulkan.VkImageLayout(LayoutUndefined),
 	}
 	var img vulkan.VkImage
 	res := Result(vulkan.VkCreateImage(vulkan.VkDevice(d), unsafe.Pointer(&ci), nil, unsafe.Pointer(&img)))
 	runtime.KeepAlive(&ci)
-	if err := res.asError("vkCreateImage"); err != nil {
-		return AllocImage{}, err
-	}
+	return Image(img), res.asError("vkCreateImage")
+}
+
+// ImageMemoryRequirements returns the memory requirements of img.
+func (d Device) ImageMemoryRequirements(img Image) MemoryRequirements {
 	var req MemoryRequirements
-	vulkan.VkGetImageMemoryRequirements(vulkan.VkDevice(d), img, unsafe.Pointer(&req))
-	mem, err := d.allocate(pd, req, MemoryDeviceLocal)
-	if err != nil {
-		vulkan.VkDestroyImage(vulkan.VkDevice(d), img, nil)
-		return AllocImage{}, err
-	}
-	if res := Result(vulkan.VkBindImageMemory(vulkan.VkDevice(d), img, vulkan.VkDeviceMemory(mem), 0)); !res.Ok() {
-		vulkan.VkDestroyImage(vulkan.VkDevice(d), img, nil)
-		vulkan.VkFreeMemory(vulkan.VkDevice(d), vulkan.VkDeviceMemory(mem), nil)
-		return AllocImage{}, res.asError("vkBindImageMemory")
-	}
-	return AllocImage{Image: Image(img), Memory: mem}, nil
+	vulkan.VkGetImageMemoryRequirements(vulkan.VkDevice(d), vulkan.VkImage(img), unsafe.Pointer(&req))
+	return req
+}
+
+// AllocateMemory allocates req.Size bytes from a memory type allowed by
+// req.MemoryTypeBits that has the props memory property flags.
+func (d Device) AllocateMemory(pd PhysicalDevice, req MemoryRequirements, props uint32) (DeviceMemory, error) {
+	return d.allocate(pd, req, props)
+}
+
+// BindImageMemory binds img to mem at offset.
+func (d Device) BindImageMemory(img Image, mem DeviceMemory, offset DeviceSize) error {
+	res := Result(vulkan.VkBindImageMemory(vulkan.VkDevice(d), vulkan.VkImage(img), vulkan.VkDeviceMemory(mem), vulkan.VkDeviceSize(offset)))
+	return res.asError("vkBindImageMemory")
 }
 
 // DestroyImage frees an image and its memory.
@@ -237,7 +452,37 @@ func (d Device) DestroyImageView(v ImageView) {
 	}
 }
 
-// CopyToMapped copies src into a mapped pointer.
+// CreateBufferView creates a view of size bytes of buf from offset, read as
+// texels of format, for uniform and storage texel buffer descriptors. buf
+// needs BufferUsageUniformTexelBuffer or BufferUsageStorageTexelBuffer. size
+// 0 means WholeSize.
+func (d Device) CreateBufferView(buf Buffer, format Format, offset, size DeviceSize) (BufferView, error) {
+	if size == 0 {
+		size = WholeSize
+	}
+	ci := vulkan.VkBufferViewCreateInfo{
+		SType:  vulkan.VkStructureType(stBufferViewCreateInfo),
+		Buffer: vulkan.VkBuffer(buf),
+		Format: vulkan.VkFormat(format),
+		Offset: vulkan.VkDeviceSize(offset),
+		Range:  vulkan.VkDeviceSize(size),
+	}
+	var view vulkan.VkBufferView
+	res := Result(vulkan.VkCreateBufferView(vulkan.VkDevice(d), unsafe.Pointer(&ci), nil, unsafe.Pointer(&view)))
+	runtime.KeepAlive(&ci)
+	return BufferView(view), res.asError("vkCreateBufferView")
+}
+
+// DestroyBufferView destroys a buffer view.
+func (d Device) DestroyBufferView(v BufferView) {
+	if v != 0 {
+		vulkan.VkDestroyBufferView(vulkan.VkDevice(d), vulkan.VkBufferView(v), nil)
+	}
+}
+
+// CopyToMapped copies src into a mapped pointer. For memory that is not
+// host-coherent the write must be followed by FlushBuffer (or
+// FlushMappedRange) before the device reads it.
 func CopyToMapped(dst unsafe.Pointer, src []byte) {
 	copy(unsafe.Slice((*byte)(dst), len(src)), src)
 }
@@ -1,6 +1,7 @@
 package vk
 
 import (
+	"fmt"
 	"runtime"
 	"unsafe"
 
@@ -22,6 +23,42 @@ type VertexInputAttribute struct {
 	Offset   uint32
 }
 
+// SpecializationEntry mirrors VkSpecializationMapEntry: constant ConstantID
+// takes Size bytes starting at Offset in Specialization.Data.
+type SpecializationEntry struct {
+	ConstantID uint32
+	Offset     uint32
+	Size       uint32
+}
+
+// Specialization holds the specialization constants for one shader stage.
+type Specialization struct {
+	Entries []SpecializationEntry
+	Data    []byte
+}
+
+// specializationInfo converts s into the generated VkSpecializationInfo and
+// its map entries. It returns nil for a nil or empty s. The returned values and
+// s.Data must stay reachable for the duration of the create call.
+func specializationInfo(s *Specialization) (*vulkan.VkSpecializationInfo, []vulkan.VkSpecializationMapEntry) {
+	if s == nil || len(s.Entries) == 0 {
+		return nil, nil
+	}
+	entries := make([]vulkan.VkSpecializationMapEntry, len(s.Entries))
+	for i, e := range s.Entries {
+		entries[i] = vulkan.VkSpecializationMapEntry{ConstantID: e.ConstantID, Offset: e.Offset, Size: uintptr(e.Size)}
+	}
+	info := &vulkan.VkSpecializationInfo{
+		MapEntryCount: uint32(len(entries)),
+		PMapEntries:   unsafe.Pointer(&entries[0]),
+		DataSize:      uintptr(len(s.Data)),
+	}
+	if len(s.Data) > 0 {
+		info.PData = unsafe.Pointer(&s.Data[0])
+	}
+	return info, entries
+}
+
 // CreateShaderModule creates a shader module from SPIR-V bytes. The length must
 // be a multiple of four.
 func (d Device) CreateShaderModule(code []byte) (ShaderModule, error) {
@@ -109,6 +146,9 @@ func (d Device) CreateColorDepthRenderPass(colorFormat, depthFormat Format) (Ren
 func (d Device) DestroyRenderPass(rp RenderPass) {
 	if rp != 0 {
 		vulkan.VkDestroyRenderPass(vulkan.VkDevice(d), vulkan.VkRenderPass(rp), nil)
+		renderPassColors.Lock()
+		delete(renderPassColors.m, rp)
+		renderPassColors.Unlock()
 	}
 }
 
@@ -143,11 +183,33 @@ type DescriptorBinding struct {
 	Type    DescriptorType
 	Count   uint32
 	Stages  uint32
+	// Flags holds DescriptorBinding* bits (descriptor indexing). For
+	// DescriptorBindingVariableCount, Count is the upper bound and the
+	// binding must have the highest number in the layout.
+	Flags uint32
 }
 
-// CreateDescriptorSetLayout creates a descriptor set layout.
+// CreateDescriptorSetLayout creates a descriptor set layout. An empty bindings
+// list creates the empty layout used for a set number a pipeline skips. A
+// binding with DescriptorBindingUpdateAfterBind makes the layout allocatable
+// only from pools with DescriptorPoolCreateUpdateAfterBind.
 func (d Device) CreateDescriptorSetLayout(bindings []DescriptorBinding) (DescriptorSetLayout, error) {
+	return d.createDescriptorSetLayout(bindings, 0)
+}
+
+// CreatePushDescriptorSetLayout creates a descriptor set layout whose
+// descriptors are pushed with CommandBuffer.PushDescriptorSet instead of
+// allocated. Needs the VK_KHR_push_descriptor extension.
+func (d Device) CreatePushDescriptorSetLayout(bindings []DescriptorBinding) (DescriptorSetLayout, error) {
+	return d.createDescriptorSetLayout(bindings, descriptorSetLayoutCreatePushDescriptor)
+}
+
+// createDescriptorSetLayout creates a layout with the given
+// VkDescriptorSetLayoutCreateFlags.
+func (d Device) createDescriptorSetLayout(bindings []DescriptorBinding, layoutFlags uint32) (DescriptorSetLayout, error) {
 	vkb := make([]vulkan.VkDescriptorSetLayoutBinding, len(bindings))
+	flags := make([]uint32, len(bindings))
+	var anyFlags uint32
 	for i, b := range bindings {
 		vkb[i] = vulkan.VkDescriptorSetLayoutBinding{
 			Binding:         b.Binding,
@@ -155,16 +217,32 @@ func (d Device) CreateDescriptorSetLayout(bindings []DescriptorBinding) (Descrip
 			DescriptorCount: b.Count,
 			StageFlags:      b.Stages,
 		}
+		flags[i] = b.Flags
+		anyFlags |= b.Flags
 	}
 	ci := vulkan.VkDescriptorSetLayoutCreateInfo{
 		SType:        vulkan.VkStructureType(stDescriptorSetLayoutCreateInfo),
+		Flags:        layoutFlags,
 		BindingCount: uint32(len(vkb)),
-		PBindings:    unsafe.Pointer(&vkb[0]),
+	}
+	if len(vkb) > 0 {
+		ci.PBindings = unsafe.Pointer(&vkb[0])
+	}
+	fi := vulkan.VkDescriptorSetLayoutBindingFlagsCreateInfo{SType: vulkan.VkStructureType(stDescriptorSetLayoutBindingFlagsCreateInfo)}
+	if anyFlags != 0 {
+		fi.BindingCount = uint32(len(flags))
+		fi.PBindingFlags = unsafe.Pointer(&flags[0])
+		ci.PNext = unsafe.Pointer(&fi)
+		if anyFlags&DescriptorBindingUpdateAfterBind != 0 {
+			ci.Flags |= descriptorSetLayoutCreateUpdateAfterBindPool
+		}
 	}
 	var layout vulkan.VkDescriptorSetLayout
 	res := Result(vulkan.VkCreateDescriptorSetLayout(vulkan.VkDevice(d), unsafe.Pointer(&ci), nil, unsafe.Pointer(&layout)))
 	runtime.KeepAlive(&ci)
+	runtime.KeepAlive(&fi)
 	runtime.KeepAlive(vkb)
+	runtime.KeepAlive(flags)
 	return DescriptorSetLayout(layout), res.asError("vkCreateDescriptorSetLayout")
 }
 
@@ -206,34 +284,224 @@ func (d Device) DestroyPipelineLayout(l PipelineLayout) {
 	}
 }
 
+// ShaderStage is one programmable stage of a graphics pipeline.
+type ShaderStage struct {
+	Stage          uint32 // one ShaderStage* bit
+	Module         ShaderModule
+	EntryPoint     string // "" selects "main"
+	Specialization *Specialization
+}
+
+// ColorBlendAttachment mirrors VkPipelineColorBlendAttachmentState for one
+// color attachment. WriteMask 0 writes all four components; the zero value is
+// therefore opaque, unblended output. DisableWrites writes none, leaving the
+// attachment unchanged.
+type ColorBlendAttachment struct {
+	BlendEnable bool
+	SrcColor    uint32 // BlendFactor*
+	DstColor    uint32
+	ColorOp     uint32 // BlendOp*
+	SrcAlpha    uint32
+	DstAlpha    uint32
+	AlphaOp     uint32
+	WriteMask   uint32 // ColorComponent* bits
+	// DisableWrites masks every component, overriding WriteMask.
+	DisableWrites bool
+}
+
+// AlphaBlend is standard non-premultiplied alpha blending: color
+// src=SRC_ALPHA, dst=ONE_MINUS_SRC_ALPHA; alpha src=ONE,
+// dst=ONE_MINUS_SRC_ALPHA; both ops ADD.
+var AlphaBlend = ColorBlendAttachment{
+	BlendEnable: true,
+	SrcColor:    BlendFactorSrcAlpha,
+	DstColor:    BlendFactorOneMinusSrcAlpha,
+	ColorOp:     BlendOpAdd,
+	SrcAlpha:    BlendFactorOne,
+	DstAlpha:    BlendFactorOneMinusSrcAlpha,
+	AlphaOp:     BlendOpAdd,
+}
+
+func (b ColorBlendAttachment) vk() vulkan.VkPipelineColorBlendAttachmentState {
+	mask := b.WriteMask
+	switch {
+	case b.DisableWrites:
+		mask = 0
+	case mask == 0:
+		mask = ColorComponentRGBA
+	}
+	return vulkan.VkPipelineColorBlendAttachmentState{
+		BlendEnable:         vkBool(b.BlendEnable),
+		SrcColorBlendFactor: vulkan.VkBlendFactor(b.SrcColor),
+		DstColorBlendFactor: vulkan.VkBlendFactor(b.DstColor),
+		ColorBlendOp:        vulkan.VkBlendOp(b.ColorOp),
+		SrcAlphaBlendFactor: vulkan.VkBlendFactor(b.SrcAlpha),
+		DstAlphaBlendFactor: vulkan.VkBlendFactor(b.DstAlpha),
+		AlphaBlendOp:        vulkan.VkBlendOp(b.AlphaOp),
+		ColorWriteMask:      mask,
+	}
+}
+
+// StencilOpState mirrors VkStencilOpState for one face.
+type StencilOpState struct {
+	FailOp      uint32 // StencilOp*
+	PassOp      uint32
+	DepthFailOp uint32
+	CompareOp   uint32 // Compare*
+	CompareMask uint32
+	WriteMask   uint32
+	Reference   uint32
+}
+
+func (s StencilOpState) vk() vulkan.VkStencilOpState {
+	return vulkan.VkStencilOpState{
+		FailOp:      vulkan.VkStencilOp(s.FailOp),
+		PassOp:      vulkan.VkStencilOp(s.PassOp),
+		DepthFailOp: vulkan.VkStencilOp(s.DepthFailOp),
+		CompareOp:   vulkan.VkCompareOp(s.CompareOp),
+		CompareMask: s.CompareMask,
+		WriteMask:   s.WriteMask,
+		Reference:   s.Reference,
+	}
+}
+
+// DepthBias enables depth bias (polygon offset), for example for shadow maps.
+// A non-zero Clamp needs DeviceConfig.DepthBiasClamp.
+type DepthBias struct {
+	Constant float32
+	Clamp    float32
+	Slope    float32
+}
+
 // GraphicsPipelineConfig describes a graphics pipeline. Viewport and scissor are
-// dynamic state, so the extent is supplied at draw time.
+// always dynamic state, so the extent is supplied at draw time.
 type GraphicsPipelineConfig struct {
-	Layout       PipelineLayout
-	RenderPass   RenderPass
-	VertexShader ShaderModule
-	FragShader   ShaderModule
-	Bindings     []VertexInputBinding
-	Attributes   []VertexInputAttribute
-	Topology     uint32
-	PolygonMode  uint32
-	CullMode     uint32
-	FrontFace    uint32
-	DepthTest    bool
-	DepthWrite   bool
-	// Blend, when true, enables standard alpha blending on the color
-	// attachment (src=SRC_ALPHA, dst=ONE_MINUS_SRC_ALPHA, op=ADD; alpha
-	// src=ONE, dst=ONE_MINUS_SRC_ALPHA). Default false keeps opaque output.
+	Layout     PipelineLayout
+	RenderPass RenderPass
+	Subpass    uint32 // subpass of RenderPass the pipeline is used in
+	// Rendering targets dynamic rendering (BeginRendering) instead of a render
+	// pass; RenderPass must then be zero. The pipeline gets one color blend
+	// attachment per color format.
+	Rendering    *PipelineRendering
+	VertexShader ShaderModule // shorthand for a vertex stage with entry "main"
+	FragShader   ShaderModule // shorthand for a fragment stage with entry "main"
+	// Stages lists further stages (tessellation, geometry) or stages with a
+	// custom entry point or specialization constants. Leave VertexShader or
+	// FragShader zero when the same stage is given here.
+	Stages     []ShaderStage
+	Bindings   []VertexInputBinding
+	Attributes []VertexInputAttribute
+	Topology   uint32
+	// PrimitiveRestart lets a special index value restart strip and fan
+	// topologies.
+	PrimitiveRestart bool
+	// PatchControlPoints is the patch size for TopologyPatchList, required when
+	// a tessellation stage is present.
+	PatchControlPoints uint32
+	PolygonMode        uint32
+	CullMode           uint32
+	FrontFace          uint32
+	LineWidth          float32 // 0 means 1; other widths need wideLines
+	DepthClamp         bool    // needs DeviceConfig.DepthClamp
+	DepthBias          *DepthBias
+	DepthTest          bool
+	DepthWrite         bool
+	DepthCompare       uint32 // 0 means CompareLess
+	StencilTest        bool
+	StencilFront       StencilOpState
+	StencilBack        StencilOpState
+	// Samples is the rasterization sample count (SampleCount*); 0 means 1.
+	Samples uint32
+	// SampleShading, when above zero, enables sample-rate shading with this
+	// minimum fraction of samples shaded (DeviceConfig.SampleRateShading).
+	SampleShading   float32
+	AlphaToCoverage bool
+	// Blend, when true, enables standard alpha blending (AlphaBlend) on every
+	// color attachment. It is ignored when ColorBlend is set.
 	Blend bool
+	// ColorBlend holds one blend state per color attachment, in attachment
+	// order. Differing states across attachments need
+	// DeviceConfig.IndependentBlend. When it is nil, every color attachment
+	// of Rendering or of the render pass's subpass gets the same state.
+	ColorBlend     []ColorBlendAttachment
+	BlendConstants [4]float32
+	// DynamicStates lists state set on the command buffer instead of baked
+	// into the pipeline, in addition to viewport and scissor (which
+	// DynamicStateViewportWithCount and DynamicStateScissorWithCount replace).
+	DynamicStates []uint32
+	// DescriptorBuffer marks a pipeline whose sets come from descriptor
+	// buffers (CreateDescriptorBufferSetLayout) instead of descriptor pools.
+	DescriptorBuffer bool
+	Cache            *PipelineCache // optional; nil compiles without a cache
+}
+
+// graphicsStages collects the shader stages of cfg in pipeline order.
+func (cfg *GraphicsPipelineConfig) graphicsStages() []ShaderStage {
+	var stages []ShaderStage
+	if cfg.VertexShader != 0 {
+		stages = append(stages, ShaderStage{Stage: ShaderStageVertex, Module: cfg.VertexShader})
+	}
+	stages = append(stages, cfg.Stages...)
+	if cfg.FragShader != 0 {
+		stages = append(stages, ShaderStage{Stage: ShaderStageFragment, Module: cfg.FragShader})
+	}
+	return stages
+}
+
+// dynamicStates returns the pipeline's dynamic states: cfg.DynamicStates plus
+// viewport and scissor unless their with-count forms are listed, without
+// duplicates.
+func (cfg *GraphicsPipelineConfig) dynamicStates() []vulkan.VkDynamicState {
+	seen := map[uint32]bool{}
+	var out []vulkan.VkDynamicState
+	add := func(s uint32) {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, vulkan.VkDynamicState(s))
+		}
+	}
+	withCount := map[uint32]bool{}
+	for _, s := range cfg.DynamicStates {
+		withCount[s] = true
+	}
+	if !withCount[DynamicStateViewportWithCount] {
+		add(DynamicStateViewport)
+	}
+	if !withCount[DynamicStateScissorWithCount] {
+		add(DynamicStateScissor)
+	}
+	for _, s := range cfg.DynamicStates {
+		add(s)
+	}
+	return out
 }
 
 // CreateGraphicsPipeline builds a graphics pipeline with dynamic viewport and
 // scissor.
 func (d Device) CreateGraphicsPipeline(cfg GraphicsPipelineConfig) (Pipeline, error) {
-	entry := cstr("main")
-	stages := []vulkan.VkPipelineShaderStageCreateInfo{
-		{SType: vulkan.VkStructureType(stPipelineShaderStageCreateInfo), Stage: ShaderStageVertex, Module: vulkan.VkShaderModule(cfg.VertexShader), PName: unsafe.Pointer(entry)},
-		{SType: vulkan.VkStructureType(stPipelineShaderStageCreateInfo), Stage: ShaderStageFragment, Module: vulkan.VkShaderModule(cfg.FragShader), PName: unsafe.Pointer(entry)},
+	shaderStages := cfg.graphicsStages()
+	if len(shaderStages) == 0 {
+		return 0, fmt.Errorf("vk: graphics pipeline has no shader stages")
+	}
+	stages := make([]vulkan.VkPipelineShaderStageCreateInfo, len(shaderStages))
+	var pins []any
+	tessellated := false
+	for i, s := range shaderStages {
+		name := s.EntryPoint
+		if name == "" {
+			name = "main"
+		}
+		entry := cstr(name)
+		spec, specEntries := specializationInfo(s.Specialization)
+		pins = append(pins, entry, spec, specEntries, s.Specialization)
+		stages[i] = vulkan.VkPipelineShaderStageCreateInfo{
+			SType:               vulkan.VkStructureType(stPipelineShaderStageCreateInfo),
+			Stage:               s.Stage,
+			Module:              vulkan.VkShaderModule(s.Module),
+			PName:               unsafe.Pointer(entry),
+			PSpecializationInfo: unsafe.Pointer(spec),
+		}
+		tessellated = tessellated || s.Stage&(ShaderStageTessControl|ShaderStageTessEvaluation) != 0
 	}
 
 	// Build the generated vertex input binding/attribute arrays.
@@ -258,43 +526,115 @@ func (d Device) CreateGraphicsPipeline(cfg GraphicsPipelineConfig) (Pipeline, er
 		vi.PVertexAttributeDescriptions = unsafe.Pointer(&vkAttrs[0])
 	}
 
-	ia := vulkan.VkPipelineInputAssemblyStateCreateInfo{SType: vulkan.VkStructureType(stPipelineInputAssemblyStateCreateInfo), Topology: vulkan.VkPrimitiveTopology(cfg.Topology)}
+	ia := vulkan.VkPipelineInputAssemblyStateCreateInfo{
+		SType:                  vulkan.VkStructureType(stPipelineInputAssemblyStateCreateInfo),
+		Topology:               vulkan.VkPrimitiveTopology(cfg.Topology),
+		PrimitiveRestartEnable: vkBool(cfg.PrimitiveRestart),
+	}
+	var ts vulkan.VkPipelineTessellationStateCreateInfo
+	if tessellated {
+		if cfg.PatchControlPoints == 0 {
+			return 0, fmt.Errorf("vk: tessellation pipeline needs PatchControlPoints")
+		}
+		ts = vulkan.VkPipelineTessellationStateCreateInfo{
+			SType:              vulkan.VkStructureType(stPipelineTessellationStateCreateInfo),
+			PatchControlPoints: cfg.PatchControlPoints,
+		}
+	}
+
+	dynStates := cfg.dynamicStates()
 	vp := vulkan.VkPipelineViewportStateCreateInfo{SType: vulkan.VkStructureType(stPipelineViewportStateCreateInfo), ViewportCount: 1, ScissorCount: 1}
+	for _, s := range cfg.DynamicStates {
+		switch s {
+		case DynamicStateViewportWithCount:
+			vp.ViewportCount = 0
+		case DynamicStateScissorWithCount:
+			vp.ScissorCount = 0
+		}
+	}
+
+	lineWidth := cfg.LineWidth
+	if lineWidth == 0 {
+		lineWidth = 1.0
+	}
 	rs := vulkan.VkPipelineRasterizationStateCreateInfo{
-		SType:       vulkan.VkStructureType(stPipelineRasterizationStateCreateInfo),
-		PolygonMode: vulkan.VkPolygonMode(cfg.PolygonMode),
-		CullMode:    cfg.CullMode,
-		FrontFace:   vulkan.VkFrontFace(cfg.FrontFace),
-		LineWidth:   1.0,
+		SType:            vulkan.VkStructureType(stPipelineRasterizationStateCreateInfo),
+		DepthClampEnable: vkBool(cfg.DepthClamp),
+		PolygonMode:      vulkan.VkPolygonMode(cfg.PolygonMode),
+		CullMode:         cfg.CullMode,
+		FrontFace:        vulkan.VkFrontFace(cfg.FrontFace),
+		LineWidth:        lineWidth,
+	}
+	if cfg.DepthBias != nil {
+		rs.DepthBiasEnable = 1
+		rs.DepthBiasConstantFactor = cfg.DepthBias.Constant
+		rs.DepthBiasClamp = cfg.DepthBias.Clamp
+		rs.DepthBiasSlopeFactor = cfg.DepthBias.Slope
+	}
+
+	samples := cfg.Samples
+	if samples == 0 {
+		samples = SampleCount1
+	}
+	ms := vulkan.VkPipelineMultisampleStateCreateInfo{
+		SType:                 vulkan.VkStructureType(stPipelineMultisampleStateCreateInfo),
+		RasterizationSamples:  samples,
+		AlphaToCoverageEnable: vkBool(cfg.AlphaToCoverage),
+	}
+	if cfg.SampleShading > 0 {
+		ms.SampleShadingEnable = 1
+		ms.MinSampleShading = cfg.SampleShading
+	}
+
+	depthCompare := cfg.DepthCompare
+	if depthCompare == 0 {
+		depthCompare = CompareLess
 	}
-	ms := vulkan.VkPipelineMultisampleStateCreateInfo{SType: vulkan.VkStructureType(stPipelineMultisampleStateCreateInfo), RasterizationSamples: SampleCount1}
 	ds := vulkan.VkPipelineDepthStencilStateCreateInfo{
-		SType:          vulkan.VkStructureType(stPipelineDepthStencilStateCreateInfo),
-		DepthCompareOp: vulkan.VkCompareOp(CompareLess),
-		MaxDepthBounds: 1.0,
-	}
-	if cfg.DepthTest {
-		ds.DepthTestEnable = 1
-	}
-	if cfg.DepthWrite {
-		ds.DepthWriteEnable = 1
-	}
-	cb := vulkan.VkPipelineColorBlendAttachmentState{ColorWriteMask: 0xF}
-	if cfg.Blend {
-		cb.BlendEnable = 1
-		cb.SrcColorBlendFactor = vulkan.VK_BLEND_FACTOR_SRC_ALPHA
-		cb.DstColorBlendFactor = vulkan.VK_BLEND_FACTOR_ONE_MINUS_SRC_ALPHA
-		cb.ColorBlendOp = vulkan.VK_BLEND_OP_ADD
-		cb.SrcAlphaBlendFactor = vulkan.VK_BLEND_FACTOR_ONE
-		cb.DstAlphaBlendFactor = vulkan.VK_BLEND_FACTOR_ONE_MINUS_SRC_ALPHA
-		cb.AlphaBlendOp = vulkan.VK_BLEND_OP_ADD
+		SType:             vulkan.VkStructureType(stPipelineDepthStencilStateCreateInfo),
+		DepthTestEnable:   vkBool(cfg.DepthTest),
+		DepthWriteEnable:  vkBool(cfg.DepthWrite),
+		DepthCompareOp:    vulkan.VkCompareOp(depthCompare),
+		StencilTestEnable: vkBool(cfg.StencilTest),
+		Front:             cfg.StencilFront.vk(),
+		Back:              cfg.StencilBack.vk(),
+		MaxDepthBounds:    1.0,
+	}
+
+	var blends []vulkan.VkPipelineColorBlendAttachmentState
+	if cfg.ColorBlend != nil {
+		blends = make([]vulkan.VkPipelineColorBlendAttachmentState, len(cfg.ColorBlend))
+		for i, b := range cfg.ColorBlend {
+			blends[i] = b.vk()
+		}
+	} else {
+		cb := ColorBlendAttachment{}
+		if cfg.Blend {
+			cb = AlphaBlend
+		}
+		var n int
+		if cfg.Rendering != nil {
+			n = len(cfg.Rendering.ColorFormats)
+		} else {
+			n = subpassColors(cfg.RenderPass, cfg.Subpass)
+		}
+		blends = make([]vulkan.VkPipelineColorBlendAttachmentState, n)
+		for i := range blends {
+			blends[i] = cb.vk()
+		}
+	}
+	var rendering *vulkan.VkPipelineRenderingCreateInfo
+	if cfg.Rendering != nil {
+		rendering = pipelineRendering(cfg.Rendering)
 	}
 	cbs := vulkan.VkPipelineColorBlendStateCreateInfo{
 		SType:           vulkan.VkStructureType(stPipelineColorBlendStateCreateInfo),
-		AttachmentCount: 1,
-		PAttachments:    unsafe.Pointer(&cb),
+		AttachmentCount: uint32(len(blends)),
+		BlendConstants:  cfg.BlendConstants,
+	}
+	if len(blends) > 0 {
+		cbs.PAttachments = unsafe.Pointer(&blends[0])
 	}
-	dynStates := []vulkan.VkDynamicState{vulkan.VkDynamicState(DynamicStateViewport), vulkan.VkDynamicState(DynamicStateScissor)}
 	dyn := vulkan.VkPipelineDynamicStateCreateInfo{
 		SType:             vulkan.VkStructureType(stPipelineDynamicStateCreateInfo),
 		DynamicStateCount: uint32(len(dynStates)),
@@ -315,21 +655,36 @@ func (d Device) CreateGraphicsPipeline(cfg GraphicsPipelineConfig) (Pipeline, er
 		PDynamicState:       unsafe.Pointer(&dyn),
 		Layout:              vulkan.VkPipelineLayout(cfg.Layout),
 		RenderPass:          vulkan.VkRenderPass(cfg.RenderPass),
+		Subpass:             cfg.Subpass,
 		BasePipelineIndex:   -1,
 	}
+	if tessellated {
+		gp.PTessellationState = unsafe.Pointer(&ts)
+	}
+	if cfg.DescriptorBuffer {
+		gp.Flags = pipelineCreateDescriptorBuffer
+	}
+	if rendering != nil {
+		gp.PNext = unsafe.Pointer(rendering)
+	}
+	cache, release := cfg.Cache.acquire()
+	defer release()
 	var pipeline vulkan.VkPipeline
-	res := Result(vulkan.VkCreateGraphicsPipelines(vulkan.VkDevice(d), 0, 1, unsafe.Pointer(&gp), nil, unsafe.Pointer(&pipeline)))
-	runtime.KeepAlive(entry)
+	res := Result(vulkan.VkCreateGraphicsPipelines(vulkan.VkDevice(d), cache, 1, unsafe.Pointer(&gp), nil, unsafe.Pointer(&pipeline)))
+	runtime.KeepAlive(pins)
 	runtime.KeepAlive(stages)
 	runtime.KeepAlive(&vi)
 	runtime.KeepAlive(vkBindings)
 	runtime.KeepAlive(vkAttrs)
 	runtime.KeepAlive(&ia)
+	runtime.KeepAlive(&ts)
 	runtime.KeepAlive(&vp)
 	runtime.KeepAlive(&rs)
 	runtime.KeepAlive(&ms)
 	runtime.KeepAlive(&ds)
-	runtime.KeepAlive(&cb)
+	runtime.KeepAlive(blends)
+	runtime.KeepAlive(rendering)
+	runtime.KeepAlive(cfg.Rendering)
 	runtime.KeepAlive(&cbs)
 	runtime.KeepAlive(dynStates)
 	runtime.KeepAlive(&dyn)
@@ -346,15 +701,24 @@ func (d Device) DestroyPipeline(p Pipeline) {
 
 // CreateDescriptorPool creates a descriptor pool sized for the given counts.
 func (d Device) CreateDescriptorPool(maxSets uint32, sizes map[DescriptorType]uint32) (DescriptorPool, error) {
+	return d.createDescriptorPool(maxSets, sizes, 0)
+}
+
+// createDescriptorPool is CreateDescriptorPool with DescriptorPoolCreate*
+// flags.
+func (d Device) createDescriptorPool(maxSets uint32, sizes map[DescriptorType]uint32, flags uint32) (DescriptorPool, error) {
 	poolSizes := make([]vulkan.VkDescriptorPoolSize, 0, len(sizes))
 	for t, c := range sizes {
 		poolSizes = append(poolSizes, vulkan.VkDescriptorPoolSize{Type: vulkan.VkDescriptorType(t), DescriptorCount: c})
 	}
 	ci := vulkan.VkDescriptorPoolCreateInfo{
 		SType:         vulkan.VkStructureType(stDescriptorPoolCreateInfo),
+		Flags:         flags,
 		MaxSets:       maxSets,
 		PoolSizeCount: uint32(len(poolSizes)),
-		PPoolSizes:    unsafe.Pointer(&poolSizes[0]),
+	}
+	if len(poolSizes) > 0 {
+		ci.PPoolSizes = unsafe.Pointer(&poolSizes[0])
 	}
 	var pool vulkan.VkDescriptorPool
 	res := Result(vulkan.VkCreateDescriptorPool(vulkan.VkDevice(d), unsafe.Pointer(&ci), nil, unsafe.Pointer(&pool)))
@@ -372,6 +736,21 @@ func (d Device) DestroyDescriptorPool(p DescriptorPool) {
 
 // AllocateDescriptorSet allocates a single descriptor set with the given layout.
 func (d Device) AllocateDescriptorSet(pool DescriptorPool, layout DescriptorSetLayout) (DescriptorSet, error) {
+	set, res := d.allocateDescriptorSet(pool, layout, 0)
+	return set, res.asError("vkAllocateDescriptorSets")
+}
+
+// AllocateDescriptorSetVariable allocates a descriptor set whose
+// DescriptorBindingVariableCount binding holds count descriptors.
+func (d Device) AllocateDescriptorSetVariable(pool DescriptorPool, layout DescriptorSetLayout, count uint32) (DescriptorSet, error) {
+	set, res := d.allocateDescriptorSet(pool, layout, count)
+	return set, res.asError("vkAllocateDescriptorSets")
+}
+
+// allocateDescriptorSet is AllocateDescriptorSetVariable returning the raw
+// result, so DescriptorAllocator can tell an exhausted pool from other
+// failures. A zero count allocates without a variable count.
+func (d Device) allocateDescriptorSet(pool DescriptorPool, layout DescriptorSetLayout, count uint32) (DescriptorSet, Result) {
 	l := vulkan.VkDescriptorSetLayout(layout)
 	ai := vulkan.VkDescriptorSetAllocateInfo{
 		SType:              vulkan.VkStructureType(stDescriptorSetAllocateInfo),
@@ -379,25 +758,27 @@ func (d Device) AllocateDescriptorSet(pool DescriptorPool, layout DescriptorSetL
 		DescriptorSetCount: 1,
 		PSetLayouts:        unsafe.Pointer(&l),
 	}
+	vi := vulkan.VkDescriptorSetVariableDescriptorCountAllocateInfo{
+		SType:              vulkan.VkStructureType(stDescriptorSetVariableDescriptorCountAllocateInfo),
+		DescriptorSetCount: 1,
+		PDescriptorCounts:  unsafe.Pointer(&count),
+	}
+	if count > 0 {
+		ai.PNext = unsafe.Pointer(&vi)
+	}
 	var set vulkan.VkDescriptorSet
 	res := Result(vulkan.VkAllocateDescriptorSets(vulkan.VkDevice(d), unsafe.Pointer(&ai), unsafe.Pointer(&set)))
 	runtime.KeepAlive(&ai)
+	runtime.KeepAlive(&vi)
 	runtime.KeepAlive(&l)
-	return DescriptorSet(set), res.asError("vkAllocateDescriptorSets")
+	runtime.KeepAlive(&count)
+	return DescriptorSet(set), res
 }
 
 // UpdateBufferDescriptor points a uniform/storage buffer descriptor at a buffer.
+//
+// Deprecated: use DescriptorSetBuilder, which batches writes into one call.
 func (d Device) UpdateBufferDescriptor(set DescriptorSet, binding uint32, t DescriptorType, buf Buffer, offset, rang DeviceSize) {
-	bi := vulkan.VkDescriptorBufferInfo{Buffer: vulkan.VkBuffer(buf), Offset: vulkan.VkDeviceSize(offset), Range: vulkan.VkDeviceSize(rang)}
-	w := vulkan.VkWriteDescriptorSet{
-		SType:           vulkan.VkStructureType(stWriteDescriptorSet),
-		DstSet:          vulkan.VkDescriptorSet(set),
-		DstBinding:      binding,
-		DescriptorCount: 1,
-		DescriptorType:  vulkan.VkDescriptorType(t),
-		PBufferInfo:     unsafe.Pointer(&bi),
-	}
-	vulkan.VkUpdateDescriptorSets(vulkan.VkDevice(d), 1, unsafe.Pointer(&w), 0, nil)
-	runtime.KeepAlive(&bi)
-	runtime.KeepAlive(&w)
+	var b DescriptorSetBuilder
+	b.Buffers(set, binding, 0, t, []DescriptorBufferInfo{{Buffer: buf, Offset: offset, Range: rang}}).Update(d)
 }
@@ -0,0 +1,45 @@
+package vk
+
+import "testing"
+
+func TestColorBlendWriteMask(t *testing.T) {
+	for _, c := range []struct {
+		b    ColorBlendAttachment
+		want uint32
+	}{
+		{ColorBlendAttachment{}, ColorComponentRGBA},
+		{ColorBlendAttachment{WriteMask: ColorComponentR}, ColorComponentR},
+		{ColorBlendAttachment{DisableWrites: true}, 0},
+		{ColorBlendAttachment{WriteMask: ColorComponentR, DisableWrites: true}, 0},
+	} {
+		if got := uint32(c.b.vk().ColorWriteMask); got != c.want {
+			t.Errorf("%+v: mask %#x, want %#x", c.b, got, c.want)
+		}
+	}
+}
+
+func TestSubpassColors(t *testing.T) {
+	rp := RenderPass(0xdead)
+	renderPassColors.Lock()
+	renderPassColors.m[rp] = []uint32{3, 0}
+	renderPassColors.Unlock()
+	defer func() {
+		renderPassColors.Lock()
+		delete(renderPassColors.m, rp)
+		renderPassColors.Unlock()
+	}()
+	for _, c := range []struct {
+		rp      RenderPass
+		subpass uint32
+		want    int
+	}{
+		{rp, 0, 3},
+		{rp, 1, 0},
+		{rp, 2, 1},
+		{rp + 1, 0, 1},
+	} {
+		if got := subpassColors(c.rp, c.subpass); got != c.want {
+			t.Errorf("subpassColors(%#x, %d) = %d, want %d", c.rp, c.subpass, got, c.want)
+		}
+	}
+}
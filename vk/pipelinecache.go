@@ -0,0 +1,176 @@
+package vk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"unsafe"
+
+	vulkan "github.com/christerso/vulkan-go/vulkan"
+)
+
+// pipelineCacheHeaderSize is the size of VkPipelineCacheHeaderVersionOne:
+// headerSize, headerVersion, vendorID, deviceID, then pipelineCacheUUID.
+const pipelineCacheHeaderSize = 32
+
+// pipelineCacheHeaderVersionOne is VK_PIPELINE_CACHE_HEADER_VERSION_ONE.
+const pipelineCacheHeaderVersionOne = 1
+
+// PipelineCache wraps a VkPipelineCache. Pipelines may be created through it
+// from any number of goroutines at once; Merge takes the cache exclusively, as
+// vkMergePipelineCaches requires of its destination.
+type PipelineCache struct {
+	device Device
+	handle uint64
+	mu     sync.RWMutex
+}
+
+// CreatePipelineCache creates a pipeline cache seeded with data, a blob
+// previously returned by PipelineCache.Data. A nil or empty data creates an
+// empty cache. Drivers ignore blobs from another device or driver version, but
+// LoadPipelineCache checks the header first so such blobs are never passed in.
+func (d Device) CreatePipelineCache(data []byte) (*PipelineCache, error) {
+	ci := vulkan.VkPipelineCacheCreateInfo{
+		SType:           vulkan.VkStructureType(stPipelineCacheCreateInfo),
+		InitialDataSize: uintptr(len(data)),
+	}
+	if len(data) > 0 {
+		ci.PInitialData = unsafe.Pointer(&data[0])
+	}
+	var h vulkan.VkPipelineCache
+	res := Result(vulkan.VkCreatePipelineCache(vulkan.VkDevice(d), unsafe.Pointer(&ci), nil, unsafe.Pointer(&h)))
+	runtime.KeepAlive(&ci)
+	runtime.KeepAlive(data)
+	if err := res.asError("vkCreatePipelineCache"); err != nil {
+		return nil, err
+	}
+	return &PipelineCache{device: d, handle: h}, nil
+}
+
+// Destroy destroys the pipeline cache. No pipeline creation may be using it.
+func (c *PipelineCache) Destroy() {
+	if c == nil || c.handle == 0 {
+		return
+	}
+	vulkan.VkDestroyPipelineCache(vulkan.VkDevice(c.device), c.handle, nil)
+	c.handle = 0
+}
+
+// acquire read-locks c for a pipeline creation call and returns its handle. A
+// nil cache yields VK_NULL_HANDLE and a no-op release.
+func (c *PipelineCache) acquire() (vulkan.VkPipelineCache, func()) {
+	if c == nil {
+		return 0, func() {}
+	}
+	c.mu.RLock()
+	return c.handle, c.mu.RUnlock
+}
+
+// Data serializes the cache contents, header included, for a later
+// CreatePipelineCache or Save.
+func (c *PipelineCache) Data() ([]byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for {
+		var size uintptr
+		res := Result(vulkan.VkGetPipelineCacheData(vulkan.VkDevice(c.device), c.handle, unsafe.Pointer(&size), nil))
+		if err := res.asError("vkGetPipelineCacheData(size)"); err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return nil, nil
+		}
+		data := make([]byte, size)
+		res = Result(vulkan.VkGetPipelineCacheData(vulkan.VkDevice(c.device), c.handle, unsafe.Pointer(&size), unsafe.Pointer(&data[0])))
+		if res == Incomplete {
+			// Another goroutine grew the cache between the two calls.
+			continue
+		}
+		return data[:size], res.asError("vkGetPipelineCacheData")
+	}
+}
+
+// Merge folds the contents of srcs into c, for example the per-goroutine
+// caches of a parallel compile.
+func (c *PipelineCache) Merge(srcs ...*PipelineCache) error {
+	handles := make([]vulkan.VkPipelineCache, 0, len(srcs))
+	for _, s := range srcs {
+		if s == c {
+			return fmt.Errorf("vk: pipeline cache merged into itself")
+		}
+		if s != nil && s.handle != 0 {
+			handles = append(handles, s.handle)
+		}
+	}
+	if len(handles) == 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := Result(vulkan.VkMergePipelineCaches(vulkan.VkDevice(c.device), c.handle, uint32(len(handles)), unsafe.Pointer(&handles[0])))
+	runtime.KeepAlive(handles)
+	return res.asError("vkMergePipelineCaches")
+}
+
+// PipelineCacheCompatible reports whether data starts with a
+// VkPipelineCacheHeaderVersionOne matching the vendor ID, device ID, and
+// pipelineCacheUUID of info. The header's fields are little-endian.
+func PipelineCacheCompatible(data []byte, info DeviceInfo) bool {
+	if len(data) < pipelineCacheHeaderSize {
+		return false
+	}
+	le := binary.LittleEndian
+	headerSize := le.Uint32(data[0:])
+	return headerSize >= pipelineCacheHeaderSize && int(headerSize) <= len(data) &&
+		le.Uint32(data[4:]) == pipelineCacheHeaderVersionOne &&
+		le.Uint32(data[8:]) == info.VendorID &&
+		le.Uint32(data[12:]) == info.DeviceID &&
+		bytes.Equal(data[16:32], info.PipelineCacheUUID[:])
+}
+
+// LoadPipelineCache creates a pipeline cache from the blob stored at path.
+// A missing file, or a blob written by a different device or driver (checked
+// against the cache header), yields an empty cache rather than an error.
+func (d Device) LoadPipelineCache(pd PhysicalDevice, path string) (*PipelineCache, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("vk: read pipeline cache: %w", err)
+	}
+	if !PipelineCacheCompatible(data, pd.Info()) {
+		data = nil
+	}
+	return d.CreatePipelineCache(data)
+}
+
+// Save writes the cache contents to path, replacing the file atomically so a
+// crash mid-write never leaves a truncated blob behind.
+func (c *PipelineCache) Save(path string) error {
+	data, err := c.Data()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("vk: save pipeline cache: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("vk: save pipeline cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("vk: save pipeline cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("vk: save pipeline cache: %w", err)
+	}
+	return nil
+}
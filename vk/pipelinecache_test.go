@@ -0,0 +1,40 @@
+package vk
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestPipelineCacheCompatible(t *testing.T) {
+	info := DeviceInfo{VendorID: 0x10de, DeviceID: 0x2684}
+	for i := range info.PipelineCacheUUID {
+		info.PipelineCacheUUID[i] = byte(i + 1)
+	}
+	encode := func(order binary.AppendByteOrder, size, version uint32) []byte {
+		data := order.AppendUint32(nil, size)
+		data = order.AppendUint32(data, version)
+		data = order.AppendUint32(data, info.VendorID)
+		data = order.AppendUint32(data, info.DeviceID)
+		return append(data, info.PipelineCacheUUID[:]...)
+	}
+	header := func(size, version uint32) []byte { return encode(binary.LittleEndian, size, version) }
+	good := append(header(32, 1), 0xAA, 0xBB)
+	if !PipelineCacheCompatible(good, info) {
+		t.Error("matching header rejected")
+	}
+	tests := map[string][]byte{
+		"empty":        nil,
+		"short":        good[:31],
+		"version two":  header(32, 2),
+		"header size":  header(16, 1),
+		"oversize":     header(64, 1),
+		"big-endian":   encode(binary.BigEndian, 32, 1),
+		"other device": func() []byte { d := header(32, 1); d[12]++; return d }(),
+		"other UUID":   func() []byte { d := header(32, 1); d[31]++; return d }(),
+	}
+	for name, data := range tests {
+		if PipelineCacheCompatible(data, info) {
+			t.Errorf("%s: header accepted", name)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package vk
+
+import (
+	"runtime"
+	"unsafe"
+
+	vulkan "github.com/christerso/vulkan-go/vulkan"
+)
+
+// RenderingAttachment mirrors VkRenderingAttachmentInfo: one color, depth, or
+// stencil attachment of a dynamic rendering pass. View must be in Layout when
+// rendering begins. A non-zero ResolveMode resolves the multisampled View into
+// ResolveView (in ResolveLayout) at the end of the pass.
+type RenderingAttachment struct {
+	View          ImageView
+	Layout        ImageLayout
+	ResolveMode   uint32 // ResolveModeNone (default), ResolveModeAverage, ...
+	ResolveView   ImageView
+	ResolveLayout ImageLayout
+	LoadOp        uint32
+	StoreOp       uint32
+	Clear         ClearValue // used when LoadOp is AttachmentLoadOpClear
+}
+
+// RenderingInfo mirrors VkRenderingInfo. Depth and Stencil may be nil; for a
+// combined depth/stencil image pass the same attachment to both.
+type RenderingInfo struct {
+	Flags      uint32 // RenderingFlag* bits
+	Area       Rect2D
+	LayerCount uint32 // 0 means 1
+	ViewMask   uint32 // non-zero enables multiview
+	Color      []RenderingAttachment
+	Depth      *RenderingAttachment
+	Stencil    *RenderingAttachment
+}
+
+// renderingAttachment converts a to the generated struct.
+func renderingAttachment(a RenderingAttachment) vulkan.VkRenderingAttachmentInfo {
+	return vulkan.VkRenderingAttachmentInfo{
+		SType:              vulkan.VkStructureType(stRenderingAttachmentInfo),
+		ImageView:          vulkan.VkImageView(a.View),
+		ImageLayout:        vulkan.VkImageLayout(a.Layout),
+		ResolveMode:        vulkan.VkResolveModeFlagBits(a.ResolveMode),
+		ResolveImageView:   vulkan.VkImageView(a.ResolveView),
+		ResolveImageLayout: vulkan.VkImageLayout(a.ResolveLayout),
+		LoadOp:             vulkan.VkAttachmentLoadOp(a.LoadOp),
+		StoreOp:            vulkan.VkAttachmentStoreOp(a.StoreOp),
+		ClearValue:         vulkan.VkClearValue(a.Clear),
+	}
+}
+
+// BeginRendering begins a dynamic rendering pass (Vulkan 1.3, or
+// VK_KHR_dynamic_rendering) drawing straight into image views, with no render
+// pass or framebuffer objects. The device needs DeviceConfig.DynamicRendering,
+// and pipelines drawn in the pass are created with
+// GraphicsPipelineConfig.Rendering.
+func (c CommandBuffer) BeginRendering(info RenderingInfo) {
+	layers := info.LayerCount
+	if layers == 0 {
+		layers = 1
+	}
+	colors := make([]vulkan.VkRenderingAttachmentInfo, len(info.Color))
+	for i, a := range info.Color {
+		colors[i] = renderingAttachment(a)
+	}
+	ri := vulkan.VkRenderingInfo{
+		SType:                vulkan.VkStructureType(stRenderingInfo),
+		Flags:                info.Flags,
+		RenderArea:           vulkan.VkRect2D{Offset: vulkan.VkOffset2D{X: info.Area.Offset.X, Y: info.Area.Offset.Y}, Extent: vulkan.VkExtent2D{Width: info.Area.Extent.Width, Height: info.Area.Extent.Height}},
+		LayerCount:           layers,
+		ViewMask:             info.ViewMask,
+		ColorAttachmentCount: uint32(len(colors)),
+	}
+	if len(colors) > 0 {
+		ri.PColorAttachments = unsafe.Pointer(&colors[0])
+	}
+	var depth, stencil vulkan.VkRenderingAttachmentInfo
+	if info.Depth != nil {
+		depth = renderingAttachment(*info.Depth)
+		ri.PDepthAttachment = unsafe.Pointer(&depth)
+	}
+	if info.Stencil != nil {
+		stencil = renderingAttachment(*info.Stencil)
+		ri.PStencilAttachment = unsafe.Pointer(&stencil)
+	}
+	vulkan.VkCmdBeginRendering(vulkan.VkCommandBuffer(c), unsafe.Pointer(&ri))
+	runtime.KeepAlive(&ri)
+	runtime.KeepAlive(colors)
+	runtime.KeepAlive(&depth)
+	runtime.KeepAlive(&stencil)
+}
+
+// EndRendering ends the current dynamic rendering pass.
+func (c CommandBuffer) EndRendering() { vulkan.VkCmdEndRendering(vulkan.VkCommandBuffer(c)) }
+
+// PipelineRendering mirrors VkPipelineRenderingCreateInfo: the attachment
+// formats a pipeline renders to inside BeginRendering. Leave DepthFormat or
+// StencilFormat as FormatUndefined when the pass has no such attachment.
+type PipelineRendering struct {
+	ViewMask      uint32
+	ColorFormats  []Format
+	DepthFormat   Format
+	StencilFormat Format
+}
+
+// pipelineRendering converts r to the generated struct. The returned value
+// points into r.ColorFormats, which must stay reachable for the create call.
+func pipelineRendering(r *PipelineRendering) *vulkan.VkPipelineRenderingCreateInfo {
+	ci := &vulkan.VkPipelineRenderingCreateInfo{
+		SType:                   vulkan.VkStructureType(stPipelineRenderingCreateInfo),
+		ViewMask:                r.ViewMask,
+		ColorAttachmentCount:    uint32(len(r.ColorFormats)),
+		DepthAttachmentFormat:   vulkan.VkFormat(r.DepthFormat),
+		StencilAttachmentFormat: vulkan.VkFormat(r.StencilFormat),
+	}
+	if len(r.ColorFormats) > 0 {
+		ci.PColorAttachmentFormats = unsafe.Pointer(&r.ColorFormats[0])
+	}
+	return ci
+}
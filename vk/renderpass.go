@@ -0,0 +1,274 @@
+package vk
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"unsafe"
+
+	vulkan "github.com/christerso/vulkan-go/vulkan"
+)
+
+// AttachmentUnused is VK_ATTACHMENT_UNUSED, for an AttachmentRef slot that is
+// deliberately left empty (for example a color output a subpass skips).
+const AttachmentUnused uint32 = 0xFFFFFFFF
+
+// AttachmentDescription mirrors VkAttachmentDescription2: one image a render
+// pass reads or writes. Samples 0 means SampleCount1.
+type AttachmentDescription struct {
+	Format         Format
+	Samples        uint32
+	LoadOp         uint32
+	StoreOp        uint32
+	StencilLoadOp  uint32
+	StencilStoreOp uint32
+	InitialLayout  ImageLayout
+	FinalLayout    ImageLayout
+}
+
+// AttachmentRef mirrors VkAttachmentReference2: a subpass's use of attachment
+// index Attachment in Layout. Aspect only matters for input attachments; 0
+// derives it from the attachment's format.
+type AttachmentRef struct {
+	Attachment uint32
+	Layout     ImageLayout
+	Aspect     uint32
+}
+
+// SubpassDescription mirrors VkSubpassDescription2 for a graphics subpass.
+// Resolve is either empty or parallel to Color, naming the single-sample
+// attachment each multisampled color attachment resolves into
+// (AttachmentUnused to skip one). DepthResolve, when set, resolves the
+// multisampled depth/stencil attachment with DepthResolveMode and
+// StencilResolveMode (Vulkan 1.2).
+type SubpassDescription struct {
+	Input              []AttachmentRef
+	Color              []AttachmentRef
+	Resolve            []AttachmentRef
+	DepthStencil       *AttachmentRef
+	DepthResolve       *AttachmentRef
+	DepthResolveMode   uint32
+	StencilResolveMode uint32
+	Preserve           []uint32
+	ViewMask           uint32
+}
+
+// SubpassDependency mirrors VkSubpassDependency2. Use SubpassExternal for
+// dependencies on work outside the render pass.
+type SubpassDependency struct {
+	SrcSubpass      uint32
+	DstSubpass      uint32
+	SrcStage        uint32
+	DstStage        uint32
+	SrcAccess       uint32
+	DstAccess       uint32
+	DependencyFlags uint32 // DependencyByRegion, ...
+}
+
+// RenderPassConfig describes a render pass with any number of attachments,
+// subpasses, and dependencies.
+type RenderPassConfig struct {
+	Attachments  []AttachmentDescription
+	Subpasses    []SubpassDescription
+	Dependencies []SubpassDependency
+}
+
+// formatAspect returns the image aspects of a format: depth and/or stencil for
+// depth formats, color otherwise.
+func formatAspect(f Format) uint32 {
+	switch f {
+	case FormatD16Unorm, FormatX8D24UnormPack32, FormatD32Sfloat:
+		return AspectDepth
+	case FormatS8Uint:
+		return AspectStencil
+	case FormatD16UnormS8Uint, FormatD24UnormS8Uint, FormatD32SfloatS8Uint:
+		return AspectDepth | AspectStencil
+	default:
+		return AspectColor
+	}
+}
+
+// CreateRenderPass creates a render pass through vkCreateRenderPass2 (Vulkan
+// 1.2; a Vulkan 1.1 device needs "VK_KHR_create_renderpass2" in
+// DeviceConfig.Extensions). Attachment references are checked against
+// cfg.Attachments before the call so a bad index is reported as an error
+// rather than a driver crash.
+func (d Device) CreateRenderPass(cfg RenderPassConfig) (RenderPass, error) {
+	if len(cfg.Subpasses) == 0 {
+		return 0, fmt.Errorf("vk: render pass needs at least one subpass")
+	}
+	attachments := make([]vulkan.VkAttachmentDescription2, len(cfg.Attachments))
+	for i, a := range cfg.Attachments {
+		samples := a.Samples
+		if samples == 0 {
+			samples = SampleCount1
+		}
+		attachments[i] = vulkan.VkAttachmentDescription2{
+			SType:          vulkan.VkStructureType(stAttachmentDescription2),
+			Format:         vulkan.VkFormat(a.Format),
+			Samples:        samples,
+			LoadOp:         vulkan.VkAttachmentLoadOp(a.LoadOp),
+			StoreOp:        vulkan.VkAttachmentStoreOp(a.StoreOp),
+			StencilLoadOp:  vulkan.VkAttachmentLoadOp(a.StencilLoadOp),
+			StencilStoreOp: vulkan.VkAttachmentStoreOp(a.StencilStoreOp),
+			InitialLayout:  vulkan.VkImageLayout(a.InitialLayout),
+			FinalLayout:    vulkan.VkImageLayout(a.FinalLayout),
+		}
+	}
+
+	// refs converts a list of references, validating indices. Every converted
+	// array is kept in pins so it stays reachable through the create call.
+	var pins []any
+	refs := func(sub int, what string, in []AttachmentRef, input bool) (unsafe.Pointer, error) {
+		if len(in) == 0 {
+			return nil, nil
+		}
+		out := make([]vulkan.VkAttachmentReference2, len(in))
+		for i, r := range in {
+			if r.Attachment != AttachmentUnused && int(r.Attachment) >= len(cfg.Attachments) {
+				return nil, fmt.Errorf("vk: subpass %d %s reference %d names attachment %d of %d", sub, what, i, r.Attachment, len(cfg.Attachments))
+			}
+			aspect := r.Aspect
+			if input && aspect == 0 && r.Attachment != AttachmentUnused {
+				aspect = formatAspect(cfg.Attachments[r.Attachment].Format)
+			}
+			out[i] = vulkan.VkAttachmentReference2{
+				SType:      vulkan.VkStructureType(stAttachmentReference2),
+				Attachment: r.Attachment,
+				Layout:     vulkan.VkImageLayout(r.Layout),
+				AspectMask: aspect,
+			}
+		}
+		pins = append(pins, out)
+		return unsafe.Pointer(&out[0]), nil
+	}
+
+	subpasses := make([]vulkan.VkSubpassDescription2, len(cfg.Subpasses))
+	resolves := make([]vulkan.VkSubpassDescriptionDepthStencilResolve, len(cfg.Subpasses))
+	for i, sp := range cfg.Subpasses {
+		if len(sp.Resolve) != 0 && len(sp.Resolve) != len(sp.Color) {
+			return 0, fmt.Errorf("vk: subpass %d has %d resolve references for %d color attachments", i, len(sp.Resolve), len(sp.Color))
+		}
+		input, err := refs(i, "input", sp.Input, true)
+		if err != nil {
+			return 0, err
+		}
+		color, err := refs(i, "color", sp.Color, false)
+		if err != nil {
+			return 0, err
+		}
+		resolve, err := refs(i, "resolve", sp.Resolve, false)
+		if err != nil {
+			return 0, err
+		}
+		var depth unsafe.Pointer
+		if sp.DepthStencil != nil {
+			if depth, err = refs(i, "depth/stencil", []AttachmentRef{*sp.DepthStencil}, false); err != nil {
+				return 0, err
+			}
+		}
+		subpasses[i] = vulkan.VkSubpassDescription2{
+			SType:                   vulkan.VkStructureType(stSubpassDescription2),
+			PipelineBindPoint:       vulkan.VkPipelineBindPoint(BindPointGraphics),
+			ViewMask:                sp.ViewMask,
+			InputAttachmentCount:    uint32(len(sp.Input)),
+			PInputAttachments:       input,
+			ColorAttachmentCount:    uint32(len(sp.Color)),
+			PColorAttachments:       color,
+			PResolveAttachments:     resolve,
+			PDepthStencilAttachment: depth,
+			PreserveAttachmentCount: uint32(len(sp.Preserve)),
+		}
+		if len(sp.Preserve) > 0 {
+			subpasses[i].PPreserveAttachments = unsafe.Pointer(&sp.Preserve[0])
+		}
+		if sp.DepthResolve != nil {
+			dr, err := refs(i, "depth resolve", []AttachmentRef{*sp.DepthResolve}, false)
+			if err != nil {
+				return 0, err
+			}
+			resolves[i] = vulkan.VkSubpassDescriptionDepthStencilResolve{
+				SType:                          vulkan.VkStructureType(stSubpassDescriptionDepthStencilResolve),
+				DepthResolveMode:               vulkan.VkResolveModeFlagBits(sp.DepthResolveMode),
+				StencilResolveMode:             vulkan.VkResolveModeFlagBits(sp.StencilResolveMode),
+				PDepthStencilResolveAttachment: dr,
+			}
+			subpasses[i].PNext = unsafe.Pointer(&resolves[i])
+		}
+	}
+
+	deps := make([]vulkan.VkSubpassDependency2, len(cfg.Dependencies))
+	for i, dep := range cfg.Dependencies {
+		deps[i] = vulkan.VkSubpassDependency2{
+			SType:           vulkan.VkStructureType(stSubpassDependency2),
+			SrcSubpass:      dep.SrcSubpass,
+			DstSubpass:      dep.DstSubpass,
+			SrcStageMask:    dep.SrcStage,
+			DstStageMask:    dep.DstStage,
+			SrcAccessMask:   dep.SrcAccess,
+			DstAccessMask:   dep.DstAccess,
+			DependencyFlags: dep.DependencyFlags,
+		}
+	}
+
+	ci := vulkan.VkRenderPassCreateInfo2{
+		SType:           vulkan.VkStructureType(stRenderPassCreateInfo2),
+		AttachmentCount: uint32(len(attachments)),
+		SubpassCount:    uint32(len(subpasses)),
+		PSubpasses:      unsafe.Pointer(&subpasses[0]),
+		DependencyCount: uint32(len(deps)),
+	}
+	if len(attachments) > 0 {
+		ci.PAttachments = unsafe.Pointer(&attachments[0])
+	}
+	if len(deps) > 0 {
+		ci.PDependencies = unsafe.Pointer(&deps[0])
+	}
+	var rp vulkan.VkRenderPass
+	res := Result(vulkan.VkCreateRenderPass2(vulkan.VkDevice(d), unsafe.Pointer(&ci), nil, unsafe.Pointer(&rp)))
+	runtime.KeepAlive(&ci)
+	runtime.KeepAlive(attachments)
+	runtime.KeepAlive(subpasses)
+	runtime.KeepAlive(resolves)
+	runtime.KeepAlive(deps)
+	runtime.KeepAlive(pins)
+	runtime.KeepAlive(cfg.Subpasses)
+	if err := res.asError("vkCreateRenderPass2"); err != nil {
+		return 0, err
+	}
+	colors := make([]uint32, len(cfg.Subpasses))
+	for i, sp := range cfg.Subpasses {
+		colors[i] = uint32(len(sp.Color))
+	}
+	renderPassColors.Lock()
+	renderPassColors.m[RenderPass(rp)] = colors
+	renderPassColors.Unlock()
+	return RenderPass(rp), nil
+}
+
+// renderPassColors holds the color attachment count of each subpass of the
+// render passes CreateRenderPass made, for sizing pipeline blend state. It
+// is dropped by DestroyRenderPass.
+var renderPassColors = struct {
+	sync.Mutex
+	m map[RenderPass][]uint32
+}{m: map[RenderPass][]uint32{}}
+
+// subpassColors returns the number of color attachments of subpass of rp, or
+// 1 for render passes CreateRenderPass did not make, such as those of
+// CreateColorDepthRenderPass.
+func subpassColors(rp RenderPass, subpass uint32) int {
+	renderPassColors.Lock()
+	defer renderPassColors.Unlock()
+	colors, ok := renderPassColors.m[rp]
+	if !ok || subpass >= uint32(len(colors)) {
+		return 1
+	}
+	return int(colors[subpass])
+}
+
+// NextSubpass advances to the next subpass of the current render pass with
+// inline contents.
+func (c CommandBuffer) NextSubpass() {
+	vulkan.VkCmdNextSubpass(vulkan.VkCommandBuffer(c), vulkan.VkSubpassContents(SubpassContentsInline))
+}
@@ -0,0 +1,490 @@
+package vk
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	vulkan "github.com/christerso/vulkan-go/vulkan"
+)
+
+// Shader create flag bits (VkShaderCreateFlagBitsEXT).
+const (
+	ShaderCreateLinkStage uint32 = 0x00000001
+)
+
+// Shader code types (VkShaderCodeTypeEXT).
+const (
+	shaderCodeTypeBinary uint32 = 0
+	shaderCodeTypeSPIRV  uint32 = 1
+)
+
+// ErrIncompatibleShaderBinary is returned by CreateShaders when binary code
+// from ShaderBinary was produced by a different driver or device. Recreate
+// the shaders from SPIR-V.
+var ErrIncompatibleShaderBinary = errors.New("vk: incompatible shader binary")
+
+// ShaderConfig describes one shader object (VK_EXT_shader_object). The
+// descriptor set layouts and push constant range must match those of the
+// pipeline layout the shader is used with.
+type ShaderConfig struct {
+	Stage uint32 // one ShaderStage* bit
+	// NextStage holds the ShaderStage* bits of the stages that may follow
+	// this one. For linked shaders it defaults to the stage of the next
+	// config.
+	NextStage uint32
+	Code      []byte // SPIR-V, or a ShaderBinary result when Binary is set
+	Binary    bool
+	// EntryPoint "" selects "main".
+	EntryPoint     string
+	SetLayouts     []DescriptorSetLayout
+	PushStages     uint32 // ShaderStage* bits of the push constant range
+	PushSize       uint32 // 0 means no push constants
+	Specialization *Specialization
+	Flags          uint32 // ShaderCreate* bits
+}
+
+// CreateShader creates a single unlinked shader object.
+func (d Device) CreateShader(cfg ShaderConfig) (ShaderEXT, error) {
+	shaders, err := d.CreateShaders([]ShaderConfig{cfg}, false)
+	if err != nil {
+		return 0, err
+	}
+	return shaders[0], nil
+}
+
+// CreateShaders creates one shader object per config. Linked shaders are
+// compiled together, as a pipeline would be, and must then always be bound
+// together; cfgs must list them in pipeline stage order. Unlinked shaders
+// can be bound in any combination. Needs DeviceConfig.ShaderObject.
+func (d Device) CreateShaders(cfgs []ShaderConfig, linked bool) ([]ShaderEXT, error) {
+	if len(cfgs) == 0 {
+		return nil, nil
+	}
+	cis := make([]vulkan.VkShaderCreateInfoEXT, len(cfgs))
+	pcrs := make([]vulkan.VkPushConstantRange, len(cfgs))
+	var pins []any
+	for i, cfg := range cfgs {
+		if len(cfg.Code) == 0 {
+			return nil, fmt.Errorf("vk: shader %d has no code", i)
+		}
+		codeType, flags, next := shaderCodeTypeSPIRV, cfg.Flags, cfg.NextStage
+		if cfg.Binary {
+			codeType = shaderCodeTypeBinary
+		}
+		if linked {
+			flags |= ShaderCreateLinkStage
+			if next == 0 && i+1 < len(cfgs) {
+				next = cfgs[i+1].Stage
+			}
+		}
+		entry := cfg.EntryPoint
+		if entry == "" {
+			entry = "main"
+		}
+		name := cstr(entry)
+		cis[i] = vulkan.VkShaderCreateInfoEXT{
+			SType:          vulkan.VkStructureType(stShaderCreateInfoEXT),
+			Flags:          flags,
+			Stage:          cfg.Stage,
+			NextStage:      next,
+			CodeType:       vulkan.VkShaderCodeTypeEXT(codeType),
+			CodeSize:       uintptr(len(cfg.Code)),
+			PCode:          unsafe.Pointer(&cfg.Code[0]),
+			PName:          unsafe.Pointer(name),
+			SetLayoutCount: uint32(len(cfg.SetLayouts)),
+		}
+		if len(cfg.SetLayouts) > 0 {
+			cis[i].PSetLayouts = unsafe.Pointer(&cfg.SetLayouts[0])
+		}
+		if cfg.PushSize > 0 {
+			pcrs[i] = vulkan.VkPushConstantRange{StageFlags: cfg.PushStages, Size: cfg.PushSize}
+			cis[i].PushConstantRangeCount = 1
+			cis[i].PPushConstantRanges = unsafe.Pointer(&pcrs[i])
+		}
+		if info, entries := specializationInfo(cfg.Specialization); info != nil {
+			cis[i].PSpecializationInfo = unsafe.Pointer(info)
+			pins = append(pins, info, entries, cfg.Specialization.Data)
+		}
+		pins = append(pins, name, cfg.Code, cfg.SetLayouts)
+	}
+	shaders := make([]ShaderEXT, len(cfgs))
+	res := Result(vulkan.VkCreateShadersEXT(vulkan.VkDevice(d), uint32(len(cis)), unsafe.Pointer(&cis[0]), nil, unsafe.Pointer(&shaders[0])))
+	runtime.KeepAlive(cis)
+	runtime.KeepAlive(pcrs)
+	runtime.KeepAlive(pins)
+	if res != Success {
+		// Creation may fail part way; shaders that failed are left zero.
+		for _, s := range shaders {
+			d.DestroyShader(s)
+		}
+		if res == IncompatibleShaderBinaryEXT {
+			return nil, ErrIncompatibleShaderBinary
+		}
+		return nil, res.asError("vkCreateShadersEXT")
+	}
+	return shaders, nil
+}
+
+// DestroyShader destroys a shader object.
+func (d Device) DestroyShader(s ShaderEXT) {
+	if s != 0 {
+		vulkan.VkDestroyShaderEXT(vulkan.VkDevice(d), vulkan.VkShaderEXT(s), nil)
+	}
+}
+
+// ShaderBinary returns the driver's binary code for s, which
+// ShaderConfig.Binary can recreate it from faster than from SPIR-V on the same
+// driver and device.
+func (d Device) ShaderBinary(s ShaderEXT) ([]byte, error) {
+	var size uintptr
+	res := Result(vulkan.VkGetShaderBinaryDataEXT(vulkan.VkDevice(d), vulkan.VkShaderEXT(s), unsafe.Pointer(&size), nil))
+	if err := res.asError("vkGetShaderBinaryDataEXT"); err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		return nil, nil
+	}
+	data := make([]byte, size)
+	res = Result(vulkan.VkGetShaderBinaryDataEXT(vulkan.VkDevice(d), vulkan.VkShaderEXT(s), unsafe.Pointer(&size), unsafe.Pointer(&data[0])))
+	runtime.KeepAlive(data)
+	return data[:size], res.asError("vkGetShaderBinaryDataEXT")
+}
+
+// BindShaders binds shaders[i] to stages[i]. A zero shader unbinds its stage;
+// every graphics stage the draw does not use must be unbound this way.
+func (c CommandBuffer) BindShaders(stages []uint32, shaders []ShaderEXT) {
+	if len(stages) == 0 {
+		return
+	}
+	vulkan.VkCmdBindShadersEXT(vulkan.VkCommandBuffer(c), uint32(len(stages)), unsafe.Pointer(&stages[0]), unsafe.Pointer(&shaders[0]))
+	runtime.KeepAlive(stages)
+	runtime.KeepAlive(shaders)
+}
+
+// Dynamic state setters. Shader objects have no pipeline to bake state into,
+// so everything a draw depends on is set with these; SetDrawState sets all of
+// it at once. Pipelines use them for the matching DynamicStates.
+
+// SetViewports sets the viewports and their count (DynamicStateViewportWithCount).
+func (c CommandBuffer) SetViewports(vs []Viewport) {
+	if len(vs) == 0 {
+		return
+	}
+	vulkan.VkCmdSetViewportWithCount(vulkan.VkCommandBuffer(c), uint32(len(vs)), unsafe.Pointer(&vs[0]))
+	runtime.KeepAlive(vs)
+}
+
+// SetScissors sets the scissor rectangles and their count
+// (DynamicStateScissorWithCount).
+func (c CommandBuffer) SetScissors(rs []Rect2D) {
+	if len(rs) == 0 {
+		return
+	}
+	vulkan.VkCmdSetScissorWithCount(vulkan.VkCommandBuffer(c), uint32(len(rs)), unsafe.Pointer(&rs[0]))
+	runtime.KeepAlive(rs)
+}
+
+// SetCullMode sets the cull mode (Cull*).
+func (c CommandBuffer) SetCullMode(mode uint32) {
+	vulkan.VkCmdSetCullMode(vulkan.VkCommandBuffer(c), mode)
+}
+
+// SetFrontFace sets the front face winding (FrontFace*).
+func (c CommandBuffer) SetFrontFace(face uint32) {
+	vulkan.VkCmdSetFrontFace(vulkan.VkCommandBuffer(c), vulkan.VkFrontFace(face))
+}
+
+// SetPrimitiveTopology sets the primitive topology (Topology*).
+func (c CommandBuffer) SetPrimitiveTopology(topology uint32) {
+	vulkan.VkCmdSetPrimitiveTopology(vulkan.VkCommandBuffer(c), vulkan.VkPrimitiveTopology(topology))
+}
+
+// SetPrimitiveRestartEnable sets whether a special index restarts strips and
+// fans.
+func (c CommandBuffer) SetPrimitiveRestartEnable(enable bool) {
+	vulkan.VkCmdSetPrimitiveRestartEnable(vulkan.VkCommandBuffer(c), vkBool(enable))
+}
+
+// SetPatchControlPoints sets the patch size for TopologyPatchList.
+func (c CommandBuffer) SetPatchControlPoints(n uint32) {
+	vulkan.VkCmdSetPatchControlPointsEXT(vulkan.VkCommandBuffer(c), n)
+}
+
+// SetVertexInput sets the vertex bindings and attributes
+// (DynamicStateVertexInput).
+func (c CommandBuffer) SetVertexInput(bindings []VertexInputBinding, attrs []VertexInputAttribute) {
+	vb := make([]vulkan.VkVertexInputBindingDescription2EXT, len(bindings))
+	for i, b := range bindings {
+		vb[i] = vulkan.VkVertexInputBindingDescription2EXT{
+			SType:     vulkan.VkStructureType(stVertexInputBindingDescription2EXT),
+			Binding:   b.Binding,
+			Stride:    b.Stride,
+			InputRate: vulkan.VkVertexInputRate(b.InputRate),
+			Divisor:   1,
+		}
+	}
+	va := make([]vulkan.VkVertexInputAttributeDescription2EXT, len(attrs))
+	for i, a := range attrs {
+		va[i] = vulkan.VkVertexInputAttributeDescription2EXT{
+			SType:    vulkan.VkStructureType(stVertexInputAttributeDescription2EXT),
+			Location: a.Location,
+			Binding:  a.Binding,
+			Format:   vulkan.VkFormat(a.Format),
+			Offset:   a.Offset,
+		}
+	}
+	var pb, pa unsafe.Pointer
+	if len(vb) > 0 {
+		pb = unsafe.Pointer(&vb[0])
+	}
+	if len(va) > 0 {
+		pa = unsafe.Pointer(&va[0])
+	}
+	vulkan.VkCmdSetVertexInputEXT(vulkan.VkCommandBuffer(c), uint32(len(vb)), pb, uint32(len(va)), pa)
+	runtime.KeepAlive(vb)
+	runtime.KeepAlive(va)
+}
+
+// SetRasterizerDiscardEnable sets whether primitives are discarded before
+// rasterization.
+func (c CommandBuffer) SetRasterizerDiscardEnable(enable bool) {
+	vulkan.VkCmdSetRasterizerDiscardEnable(vulkan.VkCommandBuffer(c), vkBool(enable))
+}
+
+// SetPolygonMode sets the polygon mode (Polygon*). Non-fill modes need
+// DeviceConfig.FillModeNonSolid.
+func (c CommandBuffer) SetPolygonMode(mode uint32) {
+	vulkan.VkCmdSetPolygonModeEXT(vulkan.VkCommandBuffer(c), vulkan.VkPolygonMode(mode))
+}
+
+// SetLineWidth sets the rasterized line width.
+func (c CommandBuffer) SetLineWidth(width float32) {
+	vulkan.VkCmdSetLineWidth(vulkan.VkCommandBuffer(c), width)
+}
+
+// SetDepthClampEnable sets whether depth is clamped instead of clipped
+// (DeviceConfig.DepthClamp).
+func (c CommandBuffer) SetDepthClampEnable(enable bool) {
+	vulkan.VkCmdSetDepthClampEnableEXT(vulkan.VkCommandBuffer(c), vkBool(enable))
+}
+
+// SetDepthBiasEnable sets whether depth bias is applied.
+func (c CommandBuffer) SetDepthBiasEnable(enable bool) {
+	vulkan.VkCmdSetDepthBiasEnable(vulkan.VkCommandBuffer(c), vkBool(enable))
+}
+
+// SetDepthBias sets the depth bias factors.
+func (c CommandBuffer) SetDepthBias(b DepthBias) {
+	vulkan.VkCmdSetDepthBias(vulkan.VkCommandBuffer(c), b.Constant, b.Clamp, b.Slope)
+}
+
+// SetRasterizationSamples sets the rasterization sample count (SampleCount*).
+func (c CommandBuffer) SetRasterizationSamples(samples uint32) {
+	vulkan.VkCmdSetRasterizationSamplesEXT(vulkan.VkCommandBuffer(c), samples)
+}
+
+// SetSampleMask sets the coverage mask for the given sample count; mask
+// holds one bit per sample, 32 samples per word.
+func (c CommandBuffer) SetSampleMask(samples uint32, mask []uint32) {
+	if len(mask) == 0 {
+		return
+	}
+	vulkan.VkCmdSetSampleMaskEXT(vulkan.VkCommandBuffer(c), samples, unsafe.Pointer(&mask[0]))
+	runtime.KeepAlive(mask)
+}
+
+// SetAlphaToCoverageEnable sets whether fragment alpha drives coverage.
+func (c CommandBuffer) SetAlphaToCoverageEnable(enable bool) {
+	vulkan.VkCmdSetAlphaToCoverageEnableEXT(vulkan.VkCommandBuffer(c), vkBool(enable))
+}
+
+// SetAlphaToOneEnable sets whether fragment alpha is replaced by one. Needs
+// the alphaToOne feature.
+func (c CommandBuffer) SetAlphaToOneEnable(enable bool) {
+	vulkan.VkCmdSetAlphaToOneEnableEXT(vulkan.VkCommandBuffer(c), vkBool(enable))
+}
+
+// SetDepthTestEnable sets whether the depth test runs.
+func (c CommandBuffer) SetDepthTestEnable(enable bool) {
+	vulkan.VkCmdSetDepthTestEnable(vulkan.VkCommandBuffer(c), vkBool(enable))
+}
+
+// SetDepthWriteEnable sets whether passing fragments write depth.
+func (c CommandBuffer) SetDepthWriteEnable(enable bool) {
+	vulkan.VkCmdSetDepthWriteEnable(vulkan.VkCommandBuffer(c), vkBool(enable))
+}
+
+// SetDepthCompareOp sets the depth test comparison (Compare*).
+func (c CommandBuffer) SetDepthCompareOp(op uint32) {
+	vulkan.VkCmdSetDepthCompareOp(vulkan.VkCommandBuffer(c), vulkan.VkCompareOp(op))
+}
+
+// SetDepthBoundsTestEnable sets whether the depth bounds test runs.
+func (c CommandBuffer) SetDepthBoundsTestEnable(enable bool) {
+	vulkan.VkCmdSetDepthBoundsTestEnable(vulkan.VkCommandBuffer(c), vkBool(enable))
+}
+
+// SetDepthBounds sets the depth bounds test range.
+func (c CommandBuffer) SetDepthBounds(minDepth, maxDepth float32) {
+	vulkan.VkCmdSetDepthBounds(vulkan.VkCommandBuffer(c), minDepth, maxDepth)
+}
+
+// SetStencilTestEnable sets whether the stencil test runs.
+func (c CommandBuffer) SetStencilTestEnable(enable bool) {
+	vulkan.VkCmdSetStencilTestEnable(vulkan.VkCommandBuffer(c), vkBool(enable))
+}
+
+// SetStencilOp sets the stencil operations and comparison of the faces in
+// faceMask (StencilFace*). The masks and reference of s are ignored; see
+// SetStencilMasks.
+func (c CommandBuffer) SetStencilOp(faceMask uint32, s StencilOpState) {
+	vulkan.VkCmdSetStencilOp(vulkan.VkCommandBuffer(c), faceMask,
+		vulkan.VkStencilOp(s.FailOp), vulkan.VkStencilOp(s.PassOp), vulkan.VkStencilOp(s.DepthFailOp), vulkan.VkCompareOp(s.CompareOp))
+}
+
+// SetStencilMasks sets the compare mask, write mask, and reference of the
+// faces in faceMask (StencilFace*) from s.
+func (c CommandBuffer) SetStencilMasks(faceMask uint32, s StencilOpState) {
+	cb := vulkan.VkCommandBuffer(c)
+	vulkan.VkCmdSetStencilCompareMask(cb, faceMask, s.CompareMask)
+	vulkan.VkCmdSetStencilWriteMask(cb, faceMask, s.WriteMask)
+	vulkan.VkCmdSetStencilReference(cb, faceMask, s.Reference)
+}
+
+// SetLogicOpEnable sets whether color attachments are combined with a logic
+// op instead of blending. Needs the logicOp feature.
+func (c CommandBuffer) SetLogicOpEnable(enable bool) {
+	vulkan.VkCmdSetLogicOpEnableEXT(vulkan.VkCommandBuffer(c), vkBool(enable))
+}
+
+// SetColorBlend sets the blend enable, equation, and write mask of the color
+// attachments starting at first, one ColorBlendAttachment each. As in
+// GraphicsPipelineConfig, a zero WriteMask writes all components.
+func (c CommandBuffer) SetColorBlend(first uint32, attachments []ColorBlendAttachment) {
+	if len(attachments) == 0 {
+		return
+	}
+	enables := make([]uint32, len(attachments))
+	equations := make([]vulkan.VkColorBlendEquationEXT, len(attachments))
+	masks := make([]uint32, len(attachments))
+	for i, a := range attachments {
+		s := a.vk()
+		enables[i] = s.BlendEnable
+		equations[i] = vulkan.VkColorBlendEquationEXT{
+			SrcColorBlendFactor: s.SrcColorBlendFactor,
+			DstColorBlendFactor: s.DstColorBlendFactor,
+			ColorBlendOp:        s.ColorBlendOp,
+			SrcAlphaBlendFactor: s.SrcAlphaBlendFactor,
+			DstAlphaBlendFactor: s.DstAlphaBlendFactor,
+			AlphaBlendOp:        s.AlphaBlendOp,
+		}
+		masks[i] = s.ColorWriteMask
+	}
+	cb, n := vulkan.VkCommandBuffer(c), uint32(len(attachments))
+	vulkan.VkCmdSetColorBlendEnableEXT(cb, first, n, unsafe.Pointer(&enables[0]))
+	vulkan.VkCmdSetColorBlendEquationEXT(cb, first, n, unsafe.Pointer(&equations[0]))
+	vulkan.VkCmdSetColorWriteMaskEXT(cb, first, n, unsafe.Pointer(&masks[0]))
+	runtime.KeepAlive(enables)
+	runtime.KeepAlive(equations)
+	runtime.KeepAlive(masks)
+}
+
+// SetBlendConstants sets the constant color used by BlendFactorConstant*.
+func (c CommandBuffer) SetBlendConstants(rgba [4]float32) {
+	vulkan.VkCmdSetBlendConstants(vulkan.VkCommandBuffer(c), unsafe.Pointer(&rgba))
+	runtime.KeepAlive(&rgba)
+}
+
+// DrawState is the fixed-function state a draw with shader objects needs,
+// mirroring the matching GraphicsPipelineConfig fields and their defaults.
+type DrawState struct {
+	Viewports  []Viewport
+	Scissors   []Rect2D
+	Bindings   []VertexInputBinding
+	Attributes []VertexInputAttribute
+	Topology   uint32
+	// PrimitiveRestart lets a special index value restart strip and fan
+	// topologies.
+	PrimitiveRestart bool
+	// PatchControlPoints is the patch size for TopologyPatchList; 0 leaves
+	// it unset.
+	PatchControlPoints uint32
+	RasterizerDiscard  bool
+	PolygonMode        uint32
+	CullMode           uint32
+	FrontFace          uint32
+	LineWidth          float32 // 0 means 1
+	DepthClamp         bool    // needs DeviceConfig.DepthClamp
+	DepthBias          *DepthBias
+	DepthTest          bool
+	DepthWrite         bool
+	DepthCompare       uint32 // 0 means CompareLess
+	StencilTest        bool
+	StencilFront       StencilOpState
+	StencilBack        StencilOpState
+	// Samples is the rasterization sample count (SampleCount*); 0 means 1.
+	Samples         uint32
+	AlphaToCoverage bool
+	// ColorBlend holds one blend state per color attachment. The zero value
+	// of each is opaque output.
+	ColorBlend     []ColorBlendAttachment
+	BlendConstants [4]float32
+}
+
+// SetDrawState sets every piece of dynamic state VK_EXT_shader_object
+// requires before a draw. State that is only required when an optional
+// feature is enabled, such as alpha-to-one or logic ops, is left to the
+// individual setters.
+func (c CommandBuffer) SetDrawState(s DrawState) {
+	c.SetViewports(s.Viewports)
+	c.SetScissors(s.Scissors)
+	c.SetVertexInput(s.Bindings, s.Attributes)
+	c.SetPrimitiveTopology(s.Topology)
+	c.SetPrimitiveRestartEnable(s.PrimitiveRestart)
+	if s.PatchControlPoints > 0 {
+		c.SetPatchControlPoints(s.PatchControlPoints)
+	}
+	c.SetRasterizerDiscardEnable(s.RasterizerDiscard)
+	if s.RasterizerDiscard {
+		return
+	}
+	c.SetPolygonMode(s.PolygonMode)
+	c.SetCullMode(s.CullMode)
+	c.SetFrontFace(s.FrontFace)
+	lineWidth := s.LineWidth
+	if lineWidth == 0 {
+		lineWidth = 1
+	}
+	c.SetLineWidth(lineWidth)
+	c.SetDepthClampEnable(s.DepthClamp)
+	c.SetDepthBiasEnable(s.DepthBias != nil)
+	if s.DepthBias != nil {
+		c.SetDepthBias(*s.DepthBias)
+	}
+	samples := s.Samples
+	if samples == 0 {
+		samples = SampleCount1
+	}
+	c.SetRasterizationSamples(samples)
+	c.SetSampleMask(samples, []uint32{0xFFFFFFFF, 0xFFFFFFFF})
+	c.SetAlphaToCoverageEnable(s.AlphaToCoverage)
+	c.SetDepthTestEnable(s.DepthTest)
+	c.SetDepthWriteEnable(s.DepthWrite)
+	compare := s.DepthCompare
+	if compare == 0 {
+		compare = CompareLess
+	}
+	c.SetDepthCompareOp(compare)
+	c.SetDepthBoundsTestEnable(false)
+	c.SetStencilTestEnable(s.StencilTest)
+	if s.StencilTest {
+		c.SetStencilOp(StencilFaceFront, s.StencilFront)
+		c.SetStencilMasks(StencilFaceFront, s.StencilFront)
+		c.SetStencilOp(StencilFaceBack, s.StencilBack)
+		c.SetStencilMasks(StencilFaceBack, s.StencilBack)
+	}
+	c.SetColorBlend(0, s.ColorBlend)
+	c.SetBlendConstants(s.BlendConstants)
+}
@@ -0,0 +1,247 @@
+package vk
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// NewSpecialization builds a Specialization from a struct (or pointer to one)
+// whose fields hold the constant values. A field tagged `spec:"N"` supplies
+// constant_id N and must be exported; untagged fields are skipped, and
+// `spec:"-"` skips a field explicitly. If no field carries a spec tag at all,
+// the exported fields take IDs 0, 1, 2, ... in declaration order:
+//
+//	spec, err := vk.NewSpecialization(struct {
+//		LocalSize  uint32  `spec:"0"`
+//		UseShadows bool    `spec:"1"`
+//		Exposure   float32 `spec:"2"`
+//	}{64, true, 1.5})
+//
+// bool fields become 4-byte VkBool32 values; sized integer and float fields
+// keep their width. int and uint are stored as 32-bit values, matching GLSL
+// int and uint.
+func NewSpecialization(v any) (*Specialization, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("vk: specialization from nil pointer")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("vk: specialization from %s, want a struct", rv.Type())
+	}
+	rt := rv.Type()
+	tagged := false
+	for i := 0; i < rt.NumField(); i++ {
+		if _, ok := rt.Field(i).Tag.Lookup("spec"); ok {
+			tagged = true
+			break
+		}
+	}
+	var s Specialization
+	next := uint32(0)
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		id := next
+		if tagged {
+			tag, ok := f.Tag.Lookup("spec")
+			if !ok || tag == "-" {
+				continue
+			}
+			n, err := strconv.ParseUint(tag, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("vk: field %s: bad spec tag %q", f.Name, tag)
+			}
+			if !f.IsExported() {
+				return nil, fmt.Errorf("vk: field %s: spec tag on unexported field", f.Name)
+			}
+			id = uint32(n)
+		} else {
+			if !f.IsExported() {
+				continue
+			}
+			next++
+		}
+		if err := s.add(id, rv.Field(i).Interface()); err != nil {
+			return nil, fmt.Errorf("vk: field %s: %w", f.Name, err)
+		}
+	}
+	return &s, nil
+}
+
+// SpecializationMap builds a Specialization from constant_id to value pairs,
+// laid out in ascending ID order. Values are encoded as for NewSpecialization.
+func SpecializationMap(m map[uint32]any) (*Specialization, error) {
+	ids := make([]uint32, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	var s Specialization
+	for _, id := range ids {
+		if err := s.add(id, m[id]); err != nil {
+			return nil, fmt.Errorf("vk: constant %d: %w", id, err)
+		}
+	}
+	return &s, nil
+}
+
+// add appends constant id with value v, aligning it to its own size in Data.
+func (s *Specialization) add(id uint32, v any) error {
+	for _, e := range s.Entries {
+		if e.ConstantID == id {
+			return fmt.Errorf("duplicate constant_id %d", id)
+		}
+	}
+	b, err := specValue(v)
+	if err != nil {
+		return err
+	}
+	for len(s.Data)%len(b) != 0 {
+		s.Data = append(s.Data, 0)
+	}
+	s.Entries = append(s.Entries, SpecializationEntry{ConstantID: id, Offset: uint32(len(s.Data)), Size: uint32(len(b))})
+	s.Data = append(s.Data, b...)
+	return nil
+}
+
+// specValue encodes one constant value in host byte order. bool becomes a
+// 4-byte VkBool32; sized integer and float types keep their width. Go's int and
+// uint are stored as 32-bit values, matching GLSL int and uint, and must fit;
+// use int64 or uint64 for 64-bit constants.
+func specValue(v any) ([]byte, error) {
+	ne := binary.NativeEndian
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return ne.AppendUint32(nil, vkBool(rv.Bool())), nil
+	case reflect.Int8:
+		return []byte{byte(rv.Int())}, nil
+	case reflect.Uint8:
+		return []byte{byte(rv.Uint())}, nil
+	case reflect.Int16:
+		return ne.AppendUint16(nil, uint16(rv.Int())), nil
+	case reflect.Uint16:
+		return ne.AppendUint16(nil, uint16(rv.Uint())), nil
+	case reflect.Int32:
+		return ne.AppendUint32(nil, uint32(rv.Int())), nil
+	case reflect.Uint32:
+		return ne.AppendUint32(nil, uint32(rv.Uint())), nil
+	case reflect.Int:
+		n := rv.Int()
+		if n < math.MinInt32 || n > math.MaxInt32 {
+			return nil, fmt.Errorf("int value %d overflows 32 bits", n)
+		}
+		return ne.AppendUint32(nil, uint32(n)), nil
+	case reflect.Uint:
+		n := rv.Uint()
+		if n > math.MaxUint32 {
+			return nil, fmt.Errorf("uint value %d overflows 32 bits", n)
+		}
+		return ne.AppendUint32(nil, uint32(n)), nil
+	case reflect.Int64:
+		return ne.AppendUint64(nil, uint64(rv.Int())), nil
+	case reflect.Uint64:
+		return ne.AppendUint64(nil, rv.Uint()), nil
+	case reflect.Float32:
+		return ne.AppendUint32(nil, math.Float32bits(float32(rv.Float()))), nil
+	case reflect.Float64:
+		return ne.AppendUint64(nil, math.Float64bits(rv.Float())), nil
+	}
+	return nil, fmt.Errorf("unsupported specialization constant type %T", v)
+}
+
+// Validate checks s against the SPIR-V module code: every entry must name a
+// SpecId the module declares, with the size of that constant's type (4 bytes
+// for booleans), and lie within Data. Constants the module declares but s
+// leaves out are fine; they keep their default values.
+func (s *Specialization) Validate(code []byte) error {
+	if s == nil {
+		return nil
+	}
+	sizes, err := specConstantSizes(code)
+	if err != nil {
+		return err
+	}
+	for _, e := range s.Entries {
+		size, ok := sizes[e.ConstantID]
+		if !ok {
+			return fmt.Errorf("vk: specialization constant_id %d is not declared by the shader", e.ConstantID)
+		}
+		if e.Size != size {
+			return fmt.Errorf("vk: specialization constant_id %d has size %d, shader declares %d", e.ConstantID, e.Size, size)
+		}
+		if uint64(e.Offset)+uint64(e.Size) > uint64(len(s.Data)) {
+			return fmt.Errorf("vk: specialization constant_id %d lies outside the %d-byte data", e.ConstantID, len(s.Data))
+		}
+	}
+	return nil
+}
+
+// SPIR-V opcodes and decorations read by specConstantSizes.
+const (
+	spvMagic               = 0x07230203
+	spvOpTypeBool          = 20
+	spvOpTypeInt           = 21
+	spvOpTypeFloat         = 22
+	spvOpSpecConstantTrue  = 48
+	spvOpSpecConstantFalse = 49
+	spvOpSpecConstant      = 50
+	spvOpDecorate          = 71
+	spvDecorationSpecId    = 1
+)
+
+// specConstantSizes scans a SPIR-V module and returns the byte size of each
+// specialization constant, keyed by SpecId.
+func specConstantSizes(code []byte) (map[uint32]uint32, error) {
+	if len(code) < 20 || len(code)%4 != 0 {
+		return nil, fmt.Errorf("vk: SPIR-V length %d is not a multiple of 4 holding a header", len(code))
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if order.Uint32(code) != spvMagic {
+		order = binary.BigEndian
+		if order.Uint32(code) != spvMagic {
+			return nil, fmt.Errorf("vk: not a SPIR-V module")
+		}
+	}
+	words := make([]uint32, len(code)/4)
+	for i := range words {
+		words[i] = order.Uint32(code[4*i:])
+	}
+
+	specIDs := map[uint32]uint32{}   // result id -> SpecId
+	typeSizes := map[uint32]uint32{} // type id -> byte size
+	constTypes := map[uint32]uint32{}
+	for i := 5; i < len(words); {
+		n := int(words[i] >> 16)
+		op := words[i] & 0xFFFF
+		if n == 0 || i+n > len(words) {
+			return nil, fmt.Errorf("vk: malformed SPIR-V instruction at word %d", i)
+		}
+		args := words[i+1 : i+n]
+		switch {
+		case op == spvOpDecorate && len(args) >= 3 && args[1] == spvDecorationSpecId:
+			specIDs[args[0]] = args[2]
+		case op == spvOpTypeBool && len(args) >= 1:
+			typeSizes[args[0]] = 4
+		case (op == spvOpTypeInt || op == spvOpTypeFloat) && len(args) >= 2:
+			typeSizes[args[0]] = args[1] / 8
+		case (op == spvOpSpecConstantTrue || op == spvOpSpecConstantFalse || op == spvOpSpecConstant) && len(args) >= 2:
+			constTypes[args[1]] = args[0]
+		}
+		i += n
+	}
+
+	sizes := make(map[uint32]uint32, len(specIDs))
+	for result, id := range specIDs {
+		if t, ok := constTypes[result]; ok {
+			sizes[id] = typeSizes[t]
+		}
+	}
+	return sizes, nil
+}
@@ -0,0 +1,234 @@
+package vk
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// ErrStagingFull is returned by StagingRing.Alloc when no region of the
+// requested size is free. Space comes back once the frames retired by EndFrame
+// or EndFrameFunc are done, so callers usually defer the upload to a later
+// frame.
+var ErrStagingFull = errors.New("vk: staging ring full")
+
+// stagingCopyAlign is the region alignment used by UploadBuffer, and the
+// 4-byte offset alignment vkCmdCopyBufferToImage requires on top of a
+// multiple of the texel size; see imageCopyAlign.
+const stagingCopyAlign DeviceSize = 4
+
+// imageCopyAlign returns the alignment of a buffer-to-image copy source for
+// texels of the given size: lcm(texel, 4). RGB8 (3 bytes) and RGB32F (12
+// bytes) both need 12; sizes that are multiples of 4 are their own alignment.
+func imageCopyAlign(texel DeviceSize) DeviceSize {
+	if texel == 0 {
+		return stagingCopyAlign
+	}
+	a, b := texel, stagingCopyAlign
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return texel / a * stagingCopyAlign
+}
+
+// StagingRegion is a slice of a StagingRing handed out by Alloc. Data aliases
+// the mapped memory at Offset; write the upload into it, then record a copy
+// from Buffer at Offset.
+type StagingRegion struct {
+	Buffer Buffer
+	Offset DeviceSize
+	Size   DeviceSize
+	Data   []byte
+}
+
+// stagingFrame records the ring position the GPU may still be reading up to
+// until fence signals, or with no fence until the function EndFrameFunc
+// returned for it is called.
+type stagingFrame struct {
+	fence Fence
+	end   DeviceSize
+}
+
+// StagingRing is a persistently mapped, host-visible transfer-source buffer
+// used as a ring of upload regions. Regions are allocated during a frame,
+// copied into a caller-owned command buffer, and retired together by EndFrame
+// with the fence of the submission that reads them, or by EndFrameFunc.
+// Nothing blocks: Alloc reclaims whatever retired frames are done and
+// otherwise reports ErrStagingFull.
+//
+// head and tail are monotonic byte counters; the ring position is the counter
+// modulo the buffer size. A StagingRing is not safe for concurrent use.
+type StagingRing struct {
+	device  Device
+	buf     AllocBuffer
+	size    DeviceSize
+	head    DeviceSize // next free byte
+	tail    DeviceSize // oldest byte still in flight
+	mark    DeviceSize // head at the last EndFrame
+	done    DeviceSize // end of the last frame released by an EndFrameFunc
+	pending []stagingFrame
+}
+
+// CreateStagingRing allocates a size-byte host-visible, host-coherent staging
+// buffer and maps it for the lifetime of the ring.
+func (d Device) CreateStagingRing(pd PhysicalDevice, size DeviceSize) (*StagingRing, error) {
+	buf, err := d.CreateBuffer(pd, BufferConfig{
+		Size:       size,
+		Usage:      BufferUsageTransferSrc,
+		Properties: MemoryHostVisible | MemoryHostCoherent,
+		Map:        true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &StagingRing{device: d, buf: buf, size: size}, nil
+}
+
+// Destroy frees the ring's buffer. The caller must ensure no submitted work
+// still reads from it.
+func (r *StagingRing) Destroy() {
+	r.device.DestroyBuffer(r.buf)
+	*r = StagingRing{}
+}
+
+// Buffer returns the ring's underlying buffer.
+func (r *StagingRing) Buffer() Buffer { return r.buf.Buffer }
+
+// Size returns the capacity of the ring in bytes.
+func (r *StagingRing) Size() DeviceSize { return r.size }
+
+// InUse returns the number of bytes allocated and not yet reclaimed, including
+// padding lost to alignment and wrap-around.
+func (r *StagingRing) InUse() DeviceSize { return r.head - r.tail }
+
+// Alloc hands out a size-byte region whose offset is a multiple of align (0 or
+// 1 means unaligned). A region never wraps around the end of the buffer. If the
+// ring is full it first reclaims signaled frames, then returns ErrStagingFull.
+func (r *StagingRing) Alloc(size, align DeviceSize) (StagingRegion, error) {
+	if size == 0 || size > r.size {
+		return StagingRegion{}, fmt.Errorf("vk: staging alloc of %d bytes in a %d-byte ring", size, r.size)
+	}
+	if align == 0 {
+		align = 1
+	}
+	off, ok := r.tryAlloc(size, align)
+	if !ok {
+		if err := r.Reclaim(); err != nil {
+			return StagingRegion{}, err
+		}
+		if off, ok = r.tryAlloc(size, align); !ok {
+			return StagingRegion{}, ErrStagingFull
+		}
+	}
+	data := unsafe.Slice((*byte)(unsafe.Add(r.buf.Mapped, off)), size)
+	return StagingRegion{Buffer: r.buf.Buffer, Offset: off, Size: size, Data: data}, nil
+}
+
+// tryAlloc reserves size bytes at the next aligned ring position, skipping to
+// the start of the buffer when the region would straddle its end.
+func (r *StagingRing) tryAlloc(size, align DeviceSize) (DeviceSize, bool) {
+	pos := r.head % r.size
+	lap := r.head - pos
+	start := (pos + align - 1) / align * align
+	if start+size > r.size {
+		lap += r.size
+		start = 0
+	}
+	head := lap + start + size
+	if head-r.tail > r.size {
+		return 0, false
+	}
+	r.head = head
+	return start, true
+}
+
+// UploadBuffer copies data into a fresh region and records a copy from it into
+// dst at dstOffset on cmd.
+func (r *StagingRing) UploadBuffer(cmd CommandBuffer, data []byte, dst Buffer, dstOffset DeviceSize) error {
+	reg, err := r.Alloc(DeviceSize(len(data)), stagingCopyAlign)
+	if err != nil {
+		return err
+	}
+	copy(reg.Data, data)
+	cmd.CopyBufferRegion(reg.Buffer, dst, reg.Offset, dstOffset, reg.Size)
+	return nil
+}
+
+// UploadImage copies tightly packed texels into a fresh region and records a
+// copy into mip 0 of img, which must already be in TRANSFER_DST_OPTIMAL. Layout
+// transitions stay with the caller, as they depend on how img is used next.
+// The texel size is len(data) / (width*height).
+func (r *StagingRing) UploadImage(cmd CommandBuffer, data []byte, img Image, width, height uint32) error {
+	texels := DeviceSize(width) * DeviceSize(height)
+	if texels == 0 || DeviceSize(len(data))%texels != 0 {
+		return fmt.Errorf("vk: %d bytes of texels for a %dx%d image", len(data), width, height)
+	}
+	reg, err := r.Alloc(DeviceSize(len(data)), imageCopyAlign(DeviceSize(len(data))/texels))
+	if err != nil {
+		return err
+	}
+	copy(reg.Data, data)
+	cmd.CopyBufferToImageOffset(reg.Buffer, reg.Offset, img, width, height)
+	return nil
+}
+
+// EndFrame retires every region allocated since the previous EndFrame against
+// fence, the fence of the submission whose command buffers read them. Their
+// space is reclaimed once fence is observed signaled.
+func (r *StagingRing) EndFrame(fence Fence) {
+	if r.head == r.mark {
+		return
+	}
+	r.pending = append(r.pending, stagingFrame{fence: fence, end: r.head})
+	r.mark = r.head
+}
+
+// EndFrameFunc retires every region allocated since the previous EndFrame,
+// as EndFrame does, for callers that wait on and recycle their fences
+// themselves: rather than polling a fence, the space is reclaimed once the
+// returned function has been called, which the caller does when it knows the
+// submission reading the regions has completed.
+func (r *StagingRing) EndFrameFunc() func() {
+	if r.head == r.mark {
+		return func() {}
+	}
+	end := r.head
+	r.pending = append(r.pending, stagingFrame{end: end})
+	r.mark = r.head
+	return func() { r.done = max(r.done, end) }
+}
+
+// Reclaim releases the space of retired frames whose fences have signaled,
+// or whose EndFrameFunc functions have been called, oldest first, without
+// blocking. Frames retire in submission order on one queue, so it stops at
+// the first frame that is not yet done.
+//
+// With EndFrame, call it after waiting on a frame fence and before resetting
+// it. A fence that was reset and resubmitted reads as unsignaled until the new
+// submission completes, which delays reclamation but never frees a region
+// early. Callers whose waits reset the fence, such as a frame manager that
+// recycles fences, retire with EndFrameFunc instead.
+func (r *StagingRing) Reclaim() error {
+	n := 0
+	for _, f := range r.pending {
+		if f.fence == 0 {
+			if f.end > r.done {
+				break
+			}
+			r.tail = f.end
+			n++
+			continue
+		}
+		ok, err := r.device.FenceSignaled(f.fence)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		r.tail = f.end
+		n++
+	}
+	r.pending = append(r.pending[:0], r.pending[n:]...)
+	return nil
+}
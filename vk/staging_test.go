@@ -0,0 +1,35 @@
+package vk
+
+import "testing"
+
+func TestStagingEndFrameFunc(t *testing.T) {
+	r := &StagingRing{size: 1024}
+	r.head = 100
+	first := r.EndFrameFunc()
+	r.head = 300
+	second := r.EndFrameFunc()
+	if noop := r.EndFrameFunc(); noop == nil {
+		t.Fatal("EndFrameFunc with nothing allocated returned nil")
+	}
+
+	reclaim := func(tail DeviceSize) {
+		t.Helper()
+		if err := r.Reclaim(); err != nil {
+			t.Fatal(err)
+		}
+		if r.tail != tail {
+			t.Fatalf("tail = %d, want %d", r.tail, tail)
+		}
+	}
+	reclaim(0)
+	first()
+	reclaim(100)
+	if r.InUse() != 200 {
+		t.Errorf("InUse = %d, want 200", r.InUse())
+	}
+	second()
+	reclaim(300)
+	if len(r.pending) != 0 {
+		t.Errorf("%d frames still pending", len(r.pending))
+	}
+}
@@ -43,6 +43,7 @@ func (d Device) DestroyFence(f Fence) {
 }
 
 // WaitFence waits for a single fence with the given timeout in nanoseconds.
+// WaitFenceContext waits on a context instead, and can be cancelled.
 func (d Device) WaitFence(f Fence, timeout uint64) error {
 	vf := vulkan.VkFence(f)
 	res := Result(vulkan.VkWaitForFences(vulkan.VkDevice(d), 1, unsafe.Pointer(&vf), 1, timeout))
@@ -50,6 +51,20 @@ func (d Device) WaitFence(f Fence, timeout uint64) error {
 	return res.asError("vkWaitForFences")
 }
 
+// FenceSignaled reports whether f is signaled without blocking. It maps
+// VK_SUCCESS to true and VK_NOT_READY to false; any other result is an error.
+func (d Device) FenceSignaled(f Fence) (bool, error) {
+	res := Result(vulkan.VkGetFenceStatus(vulkan.VkDevice(d), vulkan.VkFence(f)))
+	switch res {
+	case Success:
+		return true, nil
+	case NotReady:
+		return false, nil
+	default:
+		return false, res.asError("vkGetFenceStatus")
+	}
+}
+
 // ResetFence resets a single fence.
 func (d Device) ResetFence(f Fence) error {
 	vf := vulkan.VkFence(f)
@@ -78,30 +93,37 @@ type SubmitConfig struct {
 }
 
 // Submit submits one command buffer with one optional wait and signal semaphore.
+// Submit2 takes several of each, and timeline semaphore values.
 func (q Queue) Submit(cfg SubmitConfig) error {
-	cmd := vulkan.VkCommandBuffer(cfg.Command)
+	return q.submit([]CommandBuffer{cfg.Command}, cfg.Wait, cfg.WaitStage, cfg.Signal, cfg.Fence)
+}
+
+// submit submits cmds in one batch with vkQueueSubmit.
+func (q Queue) submit(cmds []CommandBuffer, wait Semaphore, waitStage uint32, signal Semaphore, fence Fence) error {
 	si := vulkan.VkSubmitInfo{
 		SType:              vulkan.VkStructureType(stSubmitInfo),
-		CommandBufferCount: 1,
-		PCommandBuffers:    unsafe.Pointer(&cmd),
+		CommandBufferCount: uint32(len(cmds)),
+	}
+	if len(cmds) > 0 {
+		si.PCommandBuffers = unsafe.Pointer(&cmds[0])
 	}
-	wait := vulkan.VkSemaphore(cfg.Wait)
-	stage := cfg.WaitStage
-	if cfg.Wait != 0 {
+	vwait := vulkan.VkSemaphore(wait)
+	stage := waitStage
+	if wait != 0 {
 		si.WaitSemaphoreCount = 1
-		si.PWaitSemaphores = unsafe.Pointer(&wait)
+		si.PWaitSemaphores = unsafe.Pointer(&vwait)
 		si.PWaitDstStageMask = unsafe.Pointer(&stage)
 	}
-	signal := vulkan.VkSemaphore(cfg.Signal)
-	if cfg.Signal != 0 {
+	vsignal := vulkan.VkSemaphore(signal)
+	if signal != 0 {
 		si.SignalSemaphoreCount = 1
-		si.PSignalSemaphores = unsafe.Pointer(&signal)
+		si.PSignalSemaphores = unsafe.Pointer(&vsignal)
 	}
-	res := Result(vulkan.VkQueueSubmit(vulkan.VkQueue(q), 1, unsafe.Pointer(&si), vulkan.VkFence(cfg.Fence)))
+	res := Result(vulkan.VkQueueSubmit(vulkan.VkQueue(q), 1, unsafe.Pointer(&si), vulkan.VkFence(fence)))
 	runtime.KeepAlive(&si)
-	runtime.KeepAlive(&cmd)
-	runtime.KeepAlive(&wait)
+	runtime.KeepAlive(cmds)
+	runtime.KeepAlive(&vwait)
 	runtime.KeepAlive(&stage)
-	runtime.KeepAlive(&signal)
+	runtime.KeepAlive(&vsignal)
 	return res.asError("vkQueueSubmit")
 }
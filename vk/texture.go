@@ -92,6 +92,19 @@ func (d Device) DestroySampler(s Sampler) {
 	}
 }
 
+// MemoryBarrier records a vkCmdPipelineBarrier with a single global memory
+// barrier, covering every buffer and image, for hazards that need no layout
+// transition (for example compute shader writes read by a later transfer).
+func (c CommandBuffer) MemoryBarrier(srcStage, dstStage, srcAccess, dstAccess uint32) {
+	bar := vulkan.VkMemoryBarrier{
+		SType:         vulkan.VkStructureType(stMemoryBarrier),
+		SrcAccessMask: srcAccess,
+		DstAccessMask: dstAccess,
+	}
+	vulkan.VkCmdPipelineBarrier(vulkan.VkCommandBuffer(c), srcStage, dstStage, 0, 1, unsafe.Pointer(&bar), 0, nil, 0, nil)
+	runtime.KeepAlive(&bar)
+}
+
 // ImageBarrier records a vkCmdPipelineBarrier with a single image memory
 // barrier transitioning img from oldLayout to newLayout. The stage and access
 // masks and the image aspect are supplied by the caller, matching the Vulkan
@@ -146,7 +159,15 @@ func (c CommandBuffer) ImageBarrierLevels(img Image, baseMip, levelCount uint32,
 // CopyBufferToImage records a copy of the whole buffer into the image's
 // transfer-dst layout at the given extent (one mip, one layer, color aspect).
 func (c CommandBuffer) CopyBufferToImage(buf Buffer, img Image, width, height uint32) {
+	c.CopyBufferToImageOffset(buf, 0, img, width, height)
+}
+
+// CopyBufferToImageOffset is CopyBufferToImage reading tightly packed texels
+// starting at offset bytes into buf. offset must be a multiple of the texel
+// size and of 4, as vkCmdCopyBufferToImage requires.
+func (c CommandBuffer) CopyBufferToImageOffset(buf Buffer, offset DeviceSize, img Image, width, height uint32) {
 	region := vulkan.VkBufferImageCopy{
+		BufferOffset: vulkan.VkDeviceSize(offset),
 		ImageSubresource: vulkan.VkImageSubresourceLayers{
 			AspectMask: AspectColor,
 			LayerCount: 1,
@@ -327,21 +348,9 @@ func (d Device) CreateTexture2DMipmapped(pd PhysicalDevice, q Queue, pool Comman
 
 // UpdateImageDescriptor points a combined-image-sampler descriptor at the given
 // image view and sampler, assuming the image is in SHADER_READ_ONLY layout.
+//
+// Deprecated: use DescriptorSetBuilder, which batches writes into one call.
 func (d Device) UpdateImageDescriptor(set DescriptorSet, binding uint32, view ImageView, sampler Sampler) {
-	ii := vulkan.VkDescriptorImageInfo{
-		Sampler:     vulkan.VkSampler(sampler),
-		ImageView:   vulkan.VkImageView(view),
-		ImageLayout: vulkan.VkImageLayout(LayoutShaderReadOnlyOptimal),
-	}
-	w := vulkan.VkWriteDescriptorSet{
-		SType:           vulkan.VkStructureType(stWriteDescriptorSet),
-		DstSet:          vulkan.VkDescriptorSet(set),
-		DstBinding:      binding,
-		DescriptorCount: 1,
-		DescriptorType:  vulkan.VkDescriptorType(DescriptorCombinedImageSampler),
-		PImageInfo:      unsafe.Pointer(&ii),
-	}
-	vulkan.VkUpdateDescriptorSets(vulkan.VkDevice(d), 1, unsafe.Pointer(&w), 0, nil)
-	runtime.KeepAlive(&ii)
-	runtime.KeepAlive(&w)
+	var b DescriptorSetBuilder
+	b.CombinedImageSampler(set, binding, view, sampler).Update(d)
 }
@@ -0,0 +1,191 @@
+package vk
+
+import (
+	"runtime"
+	"unsafe"
+
+	vulkan "github.com/christerso/vulkan-go/vulkan"
+)
+
+// semaphoreTypeTimeline is VK_SEMAPHORE_TYPE_TIMELINE.
+const semaphoreTypeTimeline = 1
+
+// TimelineSemaphore is a semaphore carrying a 64-bit counter that only
+// grows. The GPU signals and waits on values through Queue.Submit2; the host
+// reads, signals, and waits with the methods below. One timeline replaces
+// the per-frame binary semaphores and fences of a queue: frame n signals n.
+type TimelineSemaphore struct {
+	Semaphore
+	device Device
+}
+
+// CreateTimelineSemaphore creates a timeline semaphore with counter initial.
+// It needs DeviceConfig.TimelineSemaphore.
+func (d Device) CreateTimelineSemaphore(initial uint64) (TimelineSemaphore, error) {
+	tci := vulkan.VkSemaphoreTypeCreateInfo{
+		SType:         vulkan.VkStructureType(stSemaphoreTypeCreateInfo),
+		SemaphoreType: semaphoreTypeTimeline,
+		InitialValue:  initial,
+	}
+	ci := vulkan.VkSemaphoreCreateInfo{SType: vulkan.VkStructureType(stSemaphoreCreateInfo), PNext: unsafe.Pointer(&tci)}
+	var s vulkan.VkSemaphore
+	res := Result(vulkan.VkCreateSemaphore(vulkan.VkDevice(d), unsafe.Pointer(&ci), nil, unsafe.Pointer(&s)))
+	runtime.KeepAlive(&ci)
+	runtime.KeepAlive(&tci)
+	return TimelineSemaphore{Semaphore: Semaphore(s), device: d}, res.asError("vkCreateSemaphore")
+}
+
+// Destroy destroys the semaphore.
+func (t TimelineSemaphore) Destroy() { t.device.DestroySemaphore(t.Semaphore) }
+
+// Value returns the current counter value.
+func (t TimelineSemaphore) Value() (uint64, error) {
+	var v uint64
+	res := Result(vulkan.VkGetSemaphoreCounterValue(vulkan.VkDevice(t.device), vulkan.VkSemaphore(t.Semaphore), unsafe.Pointer(&v)))
+	return v, res.asError("vkGetSemaphoreCounterValue")
+}
+
+// Signal sets the counter to v from the host. v must be greater than the
+// current value and than any pending signal of the semaphore.
+func (t TimelineSemaphore) Signal(v uint64) error {
+	si := vulkan.VkSemaphoreSignalInfo{
+		SType:     vulkan.VkStructureType(stSemaphoreSignalInfo),
+		Semaphore: vulkan.VkSemaphore(t.Semaphore),
+		Value:     v,
+	}
+	res := Result(vulkan.VkSignalSemaphore(vulkan.VkDevice(t.device), unsafe.Pointer(&si)))
+	runtime.KeepAlive(&si)
+	return res.asError("vkSignalSemaphore")
+}
+
+// Wait waits until the counter reaches at least v, with the given timeout in
+// nanoseconds.
+func (t TimelineSemaphore) Wait(v uint64, timeout uint64) error {
+	return t.device.WaitSemaphores([]SemaphoreValue{{Semaphore: t, Value: v}}, false, timeout)
+}
+
+// At returns a SemaphoreSubmit that waits for or signals value v at the
+// given Stage2* stages.
+func (t TimelineSemaphore) At(v uint64, stage uint64) SemaphoreSubmit {
+	return SemaphoreSubmit{Semaphore: t.Semaphore, Value: v, Stage: stage}
+}
+
+// SemaphoreValue is a timeline semaphore and a counter value to wait for.
+type SemaphoreValue struct {
+	Semaphore TimelineSemaphore
+	Value     uint64
+}
+
+// semaphoreWaitAny is VK_SEMAPHORE_WAIT_ANY_BIT.
+const semaphoreWaitAny = 0x1
+
+// WaitSemaphores waits until every semaphore reaches its value, or with waitAny
+// set until one does, with the given timeout in nanoseconds.
+func (d Device) WaitSemaphores(waits []SemaphoreValue, waitAny bool, timeout uint64) error {
+	if len(waits) == 0 {
+		return nil
+	}
+	return d.waitSemaphores(waits, waitAny, timeout).asError("vkWaitSemaphores")
+}
+
+// waitSemaphores calls vkWaitSemaphores on the non-empty waits.
+func (d Device) waitSemaphores(waits []SemaphoreValue, waitAny bool, timeout uint64) Result {
+	sems := make([]vulkan.VkSemaphore, len(waits))
+	values := make([]uint64, len(waits))
+	for i, w := range waits {
+		sems[i] = vulkan.VkSemaphore(w.Semaphore.Semaphore)
+		values[i] = w.Value
+	}
+	wi := vulkan.VkSemaphoreWaitInfo{
+		SType:          vulkan.VkStructureType(stSemaphoreWaitInfo),
+		SemaphoreCount: uint32(len(waits)),
+		PSemaphores:    unsafe.Pointer(&sems[0]),
+		PValues:        unsafe.Pointer(&values[0]),
+	}
+	if waitAny {
+		wi.Flags = semaphoreWaitAny
+	}
+	res := Result(vulkan.VkWaitSemaphores(vulkan.VkDevice(d), unsafe.Pointer(&wi), timeout))
+	runtime.KeepAlive(&wi)
+	runtime.KeepAlive(sems)
+	runtime.KeepAlive(values)
+	return res
+}
+
+// SemaphoreSubmit is one semaphore a submission waits on or signals. Stage
+// holds the Stage2* stages that wait, or that complete before the signal;
+// 0 means all commands. Value is ignored for binary semaphores.
+type SemaphoreSubmit struct {
+	Semaphore Semaphore
+	Value     uint64
+	Stage     uint64
+}
+
+// Submission is one batch of a Queue.Submit2: its command buffers run after
+// every Wait is satisfied, and every Signal fires when they complete.
+type Submission struct {
+	Wait     []SemaphoreSubmit
+	Commands []CommandBuffer
+	Signal   []SemaphoreSubmit
+}
+
+// Submit2 submits batches with vkQueueSubmit2, signaling fence, if non-zero,
+// when all of them complete. It needs DeviceConfig.Synchronization2, and
+// timeline values need DeviceConfig.TimelineSemaphore.
+func (q Queue) Submit2(submits []Submission, fence Fence) error {
+	var nsem, ncmd int
+	for _, s := range submits {
+		nsem += len(s.Wait) + len(s.Signal)
+		ncmd += len(s.Commands)
+	}
+	// All batches point into these two backing arrays, sized up front so
+	// appends never move them.
+	sems := make([]vulkan.VkSemaphoreSubmitInfo, 0, nsem)
+	cmds := make([]vulkan.VkCommandBufferSubmitInfo, 0, ncmd)
+	semInfos := func(ss []SemaphoreSubmit) (uint32, unsafe.Pointer) {
+		if len(ss) == 0 {
+			return 0, nil
+		}
+		start := len(sems)
+		for _, s := range ss {
+			stage := s.Stage
+			if stage == 0 {
+				stage = Stage2AllCommands
+			}
+			sems = append(sems, vulkan.VkSemaphoreSubmitInfo{
+				SType:     vulkan.VkStructureType(stSemaphoreSubmitInfo),
+				Semaphore: vulkan.VkSemaphore(s.Semaphore),
+				Value:     s.Value,
+				StageMask: vulkan.VkPipelineStageFlags2(stage),
+			})
+		}
+		return uint32(len(ss)), unsafe.Pointer(&sems[start])
+	}
+	infos := make([]vulkan.VkSubmitInfo2, len(submits))
+	for i, s := range submits {
+		si := &infos[i]
+		si.SType = vulkan.VkStructureType(stSubmitInfo2)
+		si.WaitSemaphoreInfoCount, si.PWaitSemaphoreInfos = semInfos(s.Wait)
+		si.SignalSemaphoreInfoCount, si.PSignalSemaphoreInfos = semInfos(s.Signal)
+		if len(s.Commands) > 0 {
+			start := len(cmds)
+			for _, c := range s.Commands {
+				cmds = append(cmds, vulkan.VkCommandBufferSubmitInfo{
+					SType:         vulkan.VkStructureType(stCommandBufferSubmitInfo),
+					CommandBuffer: vulkan.VkCommandBuffer(c),
+				})
+			}
+			si.CommandBufferInfoCount = uint32(len(s.Commands))
+			si.PCommandBufferInfos = unsafe.Pointer(&cmds[start])
+		}
+	}
+	var pInfos unsafe.Pointer
+	if len(infos) > 0 {
+		pInfos = unsafe.Pointer(&infos[0])
+	}
+	res := Result(vulkan.VkQueueSubmit2(vulkan.VkQueue(q), uint32(len(infos)), pInfos, vulkan.VkFence(fence)))
+	runtime.KeepAlive(infos)
+	runtime.KeepAlive(sems)
+	runtime.KeepAlive(cmds)
+	return res.asError("vkQueueSubmit2")
+}
@@ -0,0 +1,374 @@
+package vk
+
+// access2Writes holds the Access2* bits that write memory.
+const access2Writes = Access2ShaderWrite | Access2ColorAttachmentWrite | Access2DepthStencilAttachmentWrite |
+	Access2TransferWrite | Access2HostWrite | Access2MemoryWrite | Access2ShaderStorageWrite
+
+// syncState is what the tracker knows about one image subresource or one
+// buffer: its layout, the last write, and the reads since.
+type syncState struct {
+	layout      ImageLayout
+	writeStage  uint64 // stages of the last write or layout transition
+	writeAccess uint64 // accesses of the last write, to make available
+	readStages  uint64 // stages that read since the last write
+	// visStages and visAccess are reads already ordered after the last
+	// write, which need no further barrier.
+	visStages, visAccess uint64
+}
+
+// barrierMasks is the part of a barrier the tracker computes per
+// subresource; equal masks on neighbouring subresources share a barrier.
+type barrierMasks struct {
+	srcStage, srcAccess  uint64
+	dstStage, dstAccess  uint64
+	oldLayout, newLayout ImageLayout
+}
+
+// use moves s to the state n and returns the barrier that orders it after
+// the earlier accesses, if one is needed. Layouts are ignored for buffers.
+func (s *syncState) use(n ResourceState, image bool) (barrierMasks, bool) {
+	layoutChange := image && n.Layout != LayoutUndefined && n.Layout != s.layout
+	write := n.Access&access2Writes != 0
+	b := barrierMasks{dstStage: n.Stage, dstAccess: n.Access, oldLayout: s.layout, newLayout: s.layout}
+	if layoutChange || write {
+		// A write or layout transition waits for every access since the
+		// last write, and makes that write available.
+		b.srcStage, b.srcAccess = s.writeStage|s.readStages, s.writeAccess
+		if layoutChange {
+			b.newLayout = n.Layout
+			s.layout = n.Layout
+		}
+		s.writeStage, s.writeAccess = n.Stage, n.Access&access2Writes
+		s.readStages, s.visStages, s.visAccess = 0, 0, 0
+		if !write {
+			// The transition itself is the last write, already visible to
+			// the reads of n.
+			s.readStages, s.visStages, s.visAccess = n.Stage, n.Stage, n.Access
+		}
+		return b, layoutChange || b.srcStage != 0
+	}
+	s.readStages |= n.Stage
+	if s.writeStage == 0 && s.writeAccess == 0 {
+		return b, false // never written: nothing to wait for
+	}
+	if n.Stage&^s.visStages == 0 && n.Access&^s.visAccess == 0 {
+		return b, false // already visible to these reads
+	}
+	b.srcStage, b.srcAccess = s.writeStage, s.writeAccess
+	s.visStages |= n.Stage
+	s.visAccess |= n.Access
+	return b, true
+}
+
+// merge combines two uses of a resource by the same commands: the union of
+// their stages and accesses, in the later use's layout if it names one.
+func (s ResourceState) merge(n ResourceState) ResourceState {
+	s.Stage |= n.Stage
+	s.Access |= n.Access
+	if n.Layout != LayoutUndefined {
+		s.Layout = n.Layout
+	}
+	return s
+}
+
+// trackedImage holds the state of each subresource of an image, indexed by
+// layer*levels + mip, and the uses declared since the last flush.
+type trackedImage struct {
+	levels, layers uint32
+	aspect         uint32
+	subs           []syncState
+	want           []ResourceState
+	used           []bool
+}
+
+// trackedBuffer holds the state of a whole buffer and the use declared since
+// the last flush.
+type trackedBuffer struct {
+	state syncState
+	want  ResourceState
+	used  bool
+}
+
+// StateTracker is an opt-in record of the layout, last access, and stage of
+// images (per mip level and array layer) and buffers as commands are
+// recorded, which it turns into the smallest set of barriers each new use
+// needs. Uses are declared before the commands that perform them; uses
+// declared between two flushes are taken to belong to the same commands and
+// combine. Flush (or TrackedCommandBuffer.Flush) then records every needed
+// barrier in a single vkCmdPipelineBarrier2:
+//
+//	cmd := tracker.Wrap(cb)
+//	cmd.Use(tex, vk.StateTransferDst)
+//	cmd.Flush()
+//	cmd.CopyBufferToImage(staging.Buffer, tex.Image, w, h)
+//	cmd.Use(tex, vk.StateSampledFragment)
+//	cmd.Flush()
+//
+// Barriers computes the same barriers without recording them, so sequences
+// of uses can be checked without a device. The tracker follows a single
+// queue's submission order: record the command buffers it was used with in
+// that order. Aspects of an image are tracked together. The zero value is
+// ready to use; a StateTracker is not safe for concurrent use.
+type StateTracker struct {
+	images     map[Image]*trackedImage
+	buffers    map[Buffer]*trackedBuffer
+	imageOrder []Image // images used since the last flush, in first-use order
+	bufOrder   []Buffer
+}
+
+// TrackImage starts tracking img, which has levels mip levels and layers
+// array layers of the given aspects (0 means AspectColor), with every
+// subresource in state s. Tracking an image again resets it, for instance
+// after it was used outside the tracker. Images used without TrackImage are
+// tracked as one level and one layer of color, starting in StateUndefined.
+func (t *StateTracker) TrackImage(img Image, levels, layers, aspect uint32, s ResourceState) {
+	if levels == 0 {
+		levels = 1
+	}
+	if layers == 0 {
+		layers = 1
+	}
+	if aspect == 0 {
+		aspect = AspectColor
+	}
+	n := int(levels * layers)
+	ti := &trackedImage{levels: levels, layers: layers, aspect: aspect,
+		subs: make([]syncState, n), want: make([]ResourceState, n), used: make([]bool, n)}
+	for i := range ti.subs {
+		ti.subs[i].layout = s.Layout
+		ti.subs[i].writeStage = s.Stage
+		ti.subs[i].writeAccess = s.Access & access2Writes
+	}
+	if t.images == nil {
+		t.images = map[Image]*trackedImage{}
+	}
+	t.forgetPending(img)
+	t.images[img] = ti
+}
+
+// TrackBuffer starts tracking buf in state s, as TrackImage does for images.
+func (t *StateTracker) TrackBuffer(buf Buffer, s ResourceState) {
+	if t.buffers == nil {
+		t.buffers = map[Buffer]*trackedBuffer{}
+	}
+	tb := &trackedBuffer{}
+	tb.state.writeStage, tb.state.writeAccess = s.Stage, s.Access&access2Writes
+	if old, ok := t.buffers[buf]; ok && old.used {
+		t.bufOrder = removeKey(t.bufOrder, buf)
+	}
+	t.buffers[buf] = tb
+}
+
+// Forget stops tracking img, for instance before it is destroyed.
+func (t *StateTracker) Forget(img Image) {
+	t.forgetPending(img)
+	delete(t.images, img)
+}
+
+// ForgetBuffer stops tracking buf.
+func (t *StateTracker) ForgetBuffer(buf Buffer) {
+	if tb, ok := t.buffers[buf]; ok && tb.used {
+		t.bufOrder = removeKey(t.bufOrder, buf)
+	}
+	delete(t.buffers, buf)
+}
+
+func (t *StateTracker) forgetPending(img Image) {
+	if ti, ok := t.images[img]; ok {
+		for _, u := range ti.used {
+			if u {
+				t.imageOrder = removeKey(t.imageOrder, img)
+				return
+			}
+		}
+	}
+}
+
+func removeKey[K comparable](s []K, k K) []K {
+	for i, v := range s {
+		if v == k {
+			return append(s[:i], s[i+1:]...)
+		}
+	}
+	return s
+}
+
+// Layout returns the layout the tracker last recorded for mip level mip of
+// layer layer of img, or LayoutUndefined if img is not tracked.
+func (t *StateTracker) Layout(img Image, mip, layer uint32) ImageLayout {
+	ti, ok := t.images[img]
+	if !ok || mip >= ti.levels || layer >= ti.layers {
+		return LayoutUndefined
+	}
+	return ti.subs[layer*ti.levels+mip].layout
+}
+
+// UseImage declares that the next commands use the rng subresources of img
+// as s. Levels and Layers of 0 in rng mean the rest of the tracked image.
+func (t *StateTracker) UseImage(img Image, rng ImageRange, s ResourceState) {
+	ti, ok := t.images[img]
+	if !ok {
+		t.TrackImage(img, 1, 1, AspectColor, StateUndefined)
+		ti = t.images[img]
+	}
+	levels, layers := rng.Levels, rng.Layers
+	if levels == 0 || rng.BaseMip+levels > ti.levels {
+		levels = ti.levels - min(rng.BaseMip, ti.levels)
+	}
+	if layers == 0 || rng.BaseLayer+layers > ti.layers {
+		layers = ti.layers - min(rng.BaseLayer, ti.layers)
+	}
+	first := true
+	for _, u := range ti.used {
+		first = first && !u
+	}
+	for l := rng.BaseLayer; l < rng.BaseLayer+layers; l++ {
+		for m := rng.BaseMip; m < rng.BaseMip+levels; m++ {
+			i := l*ti.levels + m
+			if ti.used[i] {
+				ti.want[i] = ti.want[i].merge(s)
+			} else {
+				ti.want[i], ti.used[i] = s, true
+			}
+		}
+	}
+	if first && levels > 0 && layers > 0 {
+		t.imageOrder = append(t.imageOrder, img)
+	}
+}
+
+// UseBuffer declares that the next commands use buf as s.
+func (t *StateTracker) UseBuffer(buf Buffer, s ResourceState) {
+	tb, ok := t.buffers[buf]
+	if !ok {
+		t.TrackBuffer(buf, ResourceState{})
+		tb = t.buffers[buf]
+	}
+	if tb.used {
+		tb.want = tb.want.merge(s)
+		return
+	}
+	tb.want, tb.used = s, true
+	t.bufOrder = append(t.bufOrder, buf)
+}
+
+// Barriers returns the barriers the uses declared since the last flush need,
+// in the order the resources were first used, and advances the tracked state
+// past them as Flush does. Neighbouring subresources needing the same barrier
+// share one.
+func (t *StateTracker) Barriers() ([]ImageBarrier2, []BufferBarrier2) {
+	var images []ImageBarrier2
+	for _, img := range t.imageOrder {
+		images = t.images[img].barriers(img, images)
+	}
+	var buffers []BufferBarrier2
+	for _, buf := range t.bufOrder {
+		tb := t.buffers[buf]
+		if b, ok := tb.state.use(tb.want, false); ok {
+			buffers = append(buffers, BufferBarrier2{
+				SrcStage: b.srcStage, SrcAccess: b.srcAccess,
+				DstStage: b.dstStage, DstAccess: b.dstAccess,
+				Buffer: buf,
+			})
+		}
+		tb.used, tb.want = false, ResourceState{}
+	}
+	t.imageOrder = t.imageOrder[:0]
+	t.bufOrder = t.bufOrder[:0]
+	return images, buffers
+}
+
+// barriers applies the pending uses of ti and appends the barriers they need,
+// joining runs of mip levels, then runs of layers, with equal barriers.
+func (ti *trackedImage) barriers(img Image, out []ImageBarrier2) []ImageBarrier2 {
+	type run struct {
+		masks      barrierMasks
+		mip, count uint32
+	}
+	open := map[run]int{} // run of the previous layer -> index in out
+	for l := uint32(0); l < ti.layers; l++ {
+		var runs []run
+		for m := uint32(0); m < ti.levels; m++ {
+			i := l*ti.levels + m
+			if !ti.used[i] {
+				continue
+			}
+			b, ok := ti.subs[i].use(ti.want[i], true)
+			ti.used[i], ti.want[i] = false, ResourceState{}
+			if !ok {
+				continue
+			}
+			if n := len(runs); n > 0 && runs[n-1].masks == b && runs[n-1].mip+runs[n-1].count == m {
+				runs[n-1].count++
+			} else {
+				runs = append(runs, run{masks: b, mip: m, count: 1})
+			}
+		}
+		next := map[run]int{}
+		for _, r := range runs {
+			if j, ok := open[r]; ok {
+				out[j].Range.Layers++
+				next[r] = j
+				continue
+			}
+			out = append(out, ImageBarrier2{
+				SrcStage: r.masks.srcStage, SrcAccess: r.masks.srcAccess,
+				DstStage: r.masks.dstStage, DstAccess: r.masks.dstAccess,
+				OldLayout: r.masks.oldLayout, NewLayout: r.masks.newLayout,
+				Image: img,
+				Range: ImageRange{Aspect: ti.aspect, BaseMip: r.mip, Levels: r.count, BaseLayer: l, Layers: 1},
+			})
+			next[r] = len(out) - 1
+		}
+		open = next
+	}
+	return out
+}
+
+// Flush records the barriers of the uses declared since the last flush in
+// one vkCmdPipelineBarrier2 on cmd, or nothing if none are needed. Needs
+// DeviceConfig.Synchronization2.
+func (t *StateTracker) Flush(cmd CommandBuffer) {
+	images, buffers := t.Barriers()
+	var dep DependencyInfo
+	for _, b := range images {
+		dep.Image(b)
+	}
+	for _, b := range buffers {
+		dep.Buffer(b)
+	}
+	cmd.PipelineBarrier2(&dep)
+}
+
+// Wrap returns cmd bound to the tracker, for recording with Use calls.
+func (t *StateTracker) Wrap(cmd CommandBuffer) TrackedCommandBuffer {
+	return TrackedCommandBuffer{CommandBuffer: cmd, Tracker: t}
+}
+
+// TrackedCommandBuffer is a command buffer whose resource uses are declared
+// to a StateTracker. All CommandBuffer methods remain available.
+type TrackedCommandBuffer struct {
+	CommandBuffer
+	Tracker *StateTracker
+}
+
+// Use declares that the next commands use every subresource of img as s.
+func (c TrackedCommandBuffer) Use(img AllocImage, s ResourceState) {
+	c.Tracker.UseImage(img.Image, ImageRange{}, s)
+}
+
+// UseRange declares that the next commands use the rng subresources of img
+// as s.
+func (c TrackedCommandBuffer) UseRange(img AllocImage, rng ImageRange, s ResourceState) {
+	c.Tracker.UseImage(img.Image, rng, s)
+}
+
+// UseBuffer declares that the next commands use buf as s.
+func (c TrackedCommandBuffer) UseBuffer(buf AllocBuffer, s ResourceState) {
+	c.Tracker.UseBuffer(buf.Buffer, s)
+}
+
+// Flush records the barriers the declared uses need; see StateTracker.Flush.
+func (c TrackedCommandBuffer) Flush() {
+	c.Tracker.Flush(c.CommandBuffer)
+}
@@ -0,0 +1,291 @@
+package vk
+
+import (
+	"reflect"
+	"testing"
+)
+
+// trackerUse is one declared use: of img over rng when img is set, else of
+// buf.
+type trackerUse struct {
+	img   Image
+	rng   ImageRange
+	buf   Buffer
+	state ResourceState
+}
+
+// trackerStep is the uses declared between two flushes and the barriers
+// they need.
+type trackerStep struct {
+	uses    []trackerUse
+	images  []ImageBarrier2
+	buffers []BufferBarrier2
+}
+
+func TestStateTrackerSequences(t *testing.T) {
+	const (
+		img Image  = 1
+		buf Buffer = 2
+	)
+	whole := ImageRange{Aspect: AspectColor, Levels: 1, Layers: 1}
+	transferWritten := ResourceState{Stage: Stage2AllTransfer, Access: Access2TransferWrite}
+
+	tests := []struct {
+		name  string
+		setup func(*StateTracker)
+		steps []trackerStep
+	}{
+		{
+			name: "write then read",
+			steps: []trackerStep{
+				{
+					uses: []trackerUse{{img: img, state: StateTransferDst}},
+					images: []ImageBarrier2{{
+						DstStage: Stage2AllTransfer, DstAccess: Access2TransferWrite,
+						OldLayout: LayoutUndefined, NewLayout: LayoutTransferDstOptimal,
+						Image: img, Range: whole,
+					}},
+				},
+				{
+					uses: []trackerUse{{img: img, state: StateSampledFragment}},
+					images: []ImageBarrier2{{
+						SrcStage: Stage2AllTransfer, SrcAccess: Access2TransferWrite,
+						DstStage: Stage2FragmentShader, DstAccess: Access2ShaderSampledRead,
+						OldLayout: LayoutTransferDstOptimal, NewLayout: LayoutShaderReadOnlyOptimal,
+						Image: img, Range: whole,
+					}},
+				},
+			},
+		},
+		{
+			name:  "read then read",
+			setup: func(tr *StateTracker) { tr.TrackBuffer(buf, transferWritten) },
+			steps: []trackerStep{
+				{
+					uses: []trackerUse{{buf: buf, state: StateUniformVertex}},
+					buffers: []BufferBarrier2{{
+						SrcStage: Stage2AllTransfer, SrcAccess: Access2TransferWrite,
+						DstStage: Stage2VertexShader, DstAccess: Access2UniformRead,
+						Buffer: buf,
+					}},
+				},
+				// The write is already visible to vertex shader reads.
+				{uses: []trackerUse{{buf: buf, state: StateUniformVertex}}},
+				// A new stage still needs it made visible.
+				{
+					uses: []trackerUse{{buf: buf, state: ResourceState{Stage: Stage2FragmentShader, Access: Access2UniformRead}}},
+					buffers: []BufferBarrier2{{
+						SrcStage: Stage2AllTransfer, SrcAccess: Access2TransferWrite,
+						DstStage: Stage2FragmentShader, DstAccess: Access2UniformRead,
+						Buffer: buf,
+					}},
+				},
+			},
+		},
+		{
+			name: "read of a never written buffer",
+			steps: []trackerStep{
+				{uses: []trackerUse{{buf: buf, state: StateVertexInput}}},
+			},
+		},
+		{
+			name:  "write after read",
+			setup: func(tr *StateTracker) { tr.TrackBuffer(buf, transferWritten) },
+			steps: []trackerStep{
+				{
+					uses: []trackerUse{{buf: buf, state: StateVertexInput}},
+					buffers: []BufferBarrier2{{
+						SrcStage: Stage2AllTransfer, SrcAccess: Access2TransferWrite,
+						DstStage: Stage2VertexInput, DstAccess: Access2VertexAttributeRead | Access2IndexRead,
+						Buffer: buf,
+					}},
+				},
+				{
+					uses: []trackerUse{{buf: buf, state: StateStorageCompute}},
+					buffers: []BufferBarrier2{{
+						SrcStage: Stage2AllTransfer | Stage2VertexInput, SrcAccess: Access2TransferWrite,
+						DstStage: Stage2ComputeShader, DstAccess: Access2ShaderStorageRead | Access2ShaderStorageWrite,
+						Buffer: buf,
+					}},
+				},
+				// Write after write waits on the last write only.
+				{
+					uses: []trackerUse{{buf: buf, state: StateStorageCompute}},
+					buffers: []BufferBarrier2{{
+						SrcStage: Stage2ComputeShader, SrcAccess: Access2ShaderStorageWrite,
+						DstStage: Stage2ComputeShader, DstAccess: Access2ShaderStorageRead | Access2ShaderStorageWrite,
+						Buffer: buf,
+					}},
+				},
+			},
+		},
+		{
+			name:  "layout transitions",
+			setup: func(tr *StateTracker) { tr.TrackImage(img, 1, 1, 0, StateUndefined) },
+			steps: []trackerStep{
+				{
+					uses: []trackerUse{{img: img, state: StateColorAttachment}},
+					images: []ImageBarrier2{{
+						DstStage: Stage2ColorAttachmentOutput, DstAccess: Access2ColorAttachmentRead | Access2ColorAttachmentWrite,
+						OldLayout: LayoutUndefined, NewLayout: LayoutColorAttachmentOptimal,
+						Image: img, Range: whole,
+					}},
+				},
+				{
+					uses: []trackerUse{{img: img, state: StatePresent}},
+					images: []ImageBarrier2{{
+						SrcStage: Stage2ColorAttachmentOutput, SrcAccess: Access2ColorAttachmentWrite,
+						OldLayout: LayoutColorAttachmentOptimal, NewLayout: LayoutPresentSrcKHR,
+						Image: img, Range: whole,
+					}},
+				},
+				{
+					uses: []trackerUse{{img: img, state: StateSampledFragment}},
+					images: []ImageBarrier2{{
+						DstStage: Stage2FragmentShader, DstAccess: Access2ShaderSampledRead,
+						OldLayout: LayoutPresentSrcKHR, NewLayout: LayoutShaderReadOnlyOptimal,
+						Image: img, Range: whole,
+					}},
+				},
+				// Reads after a transition without a write see it already.
+				{uses: []trackerUse{{img: img, state: StateSampledFragment}}},
+			},
+		},
+		{
+			name:  "uses between flushes combine",
+			setup: func(tr *StateTracker) { tr.TrackImage(img, 1, 1, 0, StateTransferDst) },
+			steps: []trackerStep{
+				{
+					uses: []trackerUse{
+						{img: img, state: StateSampledFragment},
+						{img: img, state: StateSampledCompute},
+					},
+					images: []ImageBarrier2{{
+						SrcStage: Stage2AllTransfer, SrcAccess: Access2TransferWrite,
+						DstStage: Stage2FragmentShader | Stage2ComputeShader, DstAccess: Access2ShaderSampledRead,
+						OldLayout: LayoutTransferDstOptimal, NewLayout: LayoutShaderReadOnlyOptimal,
+						Image: img, Range: whole,
+					}},
+				},
+			},
+		},
+		{
+			name:  "mip ranges merge",
+			setup: func(tr *StateTracker) { tr.TrackImage(img, 4, 2, 0, StateUndefined) },
+			steps: []trackerStep{
+				{
+					uses: []trackerUse{{img: img, state: StateTransferDst}},
+					images: []ImageBarrier2{{
+						DstStage: Stage2AllTransfer, DstAccess: Access2TransferWrite,
+						OldLayout: LayoutUndefined, NewLayout: LayoutTransferDstOptimal,
+						Image: img, Range: ImageRange{Aspect: AspectColor, Levels: 4, Layers: 2},
+					}},
+				},
+				{
+					uses: []trackerUse{
+						{img: img, rng: ImageRange{Levels: 1}, state: StateTransferSrc},
+						{img: img, rng: ImageRange{BaseMip: 1}, state: StateSampledFragment},
+					},
+					images: []ImageBarrier2{
+						{
+							SrcStage: Stage2AllTransfer, SrcAccess: Access2TransferWrite,
+							DstStage: Stage2AllTransfer, DstAccess: Access2TransferRead,
+							OldLayout: LayoutTransferDstOptimal, NewLayout: LayoutTransferSrcOptimal,
+							Image: img, Range: ImageRange{Aspect: AspectColor, Levels: 1, Layers: 2},
+						},
+						{
+							SrcStage: Stage2AllTransfer, SrcAccess: Access2TransferWrite,
+							DstStage: Stage2FragmentShader, DstAccess: Access2ShaderSampledRead,
+							OldLayout: LayoutTransferDstOptimal, NewLayout: LayoutShaderReadOnlyOptimal,
+							Image: img, Range: ImageRange{Aspect: AspectColor, BaseMip: 1, Levels: 3, Layers: 2},
+						},
+					},
+				},
+				// Only layer 1 of mip 0: the other subresources are untouched.
+				{
+					uses: []trackerUse{{img: img, rng: ImageRange{Levels: 1, BaseLayer: 1}, state: StateSampledFragment}},
+					images: []ImageBarrier2{{
+						SrcStage: Stage2AllTransfer,
+						DstStage: Stage2FragmentShader, DstAccess: Access2ShaderSampledRead,
+						OldLayout: LayoutTransferSrcOptimal, NewLayout: LayoutShaderReadOnlyOptimal,
+						Image: img, Range: ImageRange{Aspect: AspectColor, Levels: 1, BaseLayer: 1, Layers: 1},
+					}},
+				},
+			},
+		},
+		{
+			name: "images and buffers together",
+			setup: func(tr *StateTracker) {
+				tr.TrackImage(img, 1, 1, 0, StateTransferDst)
+				tr.TrackBuffer(buf, transferWritten)
+			},
+			steps: []trackerStep{
+				{
+					uses: []trackerUse{
+						{buf: buf, state: StateStorageCompute},
+						{img: img, state: StateSampledCompute},
+					},
+					images: []ImageBarrier2{{
+						SrcStage: Stage2AllTransfer, SrcAccess: Access2TransferWrite,
+						DstStage: Stage2ComputeShader, DstAccess: Access2ShaderSampledRead,
+						OldLayout: LayoutTransferDstOptimal, NewLayout: LayoutShaderReadOnlyOptimal,
+						Image: img, Range: whole,
+					}},
+					buffers: []BufferBarrier2{{
+						SrcStage: Stage2AllTransfer, SrcAccess: Access2TransferWrite,
+						DstStage: Stage2ComputeShader, DstAccess: Access2ShaderStorageRead | Access2ShaderStorageWrite,
+						Buffer: buf,
+					}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tr StateTracker
+			if tt.setup != nil {
+				tt.setup(&tr)
+			}
+			for i, step := range tt.steps {
+				for _, u := range step.uses {
+					if u.img != 0 {
+						tr.UseImage(u.img, u.rng, u.state)
+					} else {
+						tr.UseBuffer(u.buf, u.state)
+					}
+				}
+				images, buffers := tr.Barriers()
+				if !reflect.DeepEqual(images, step.images) {
+					t.Errorf("step %d: image barriers\n got %+v\nwant %+v", i, images, step.images)
+				}
+				if !reflect.DeepEqual(buffers, step.buffers) {
+					t.Errorf("step %d: buffer barriers\n got %+v\nwant %+v", i, buffers, step.buffers)
+				}
+			}
+		})
+	}
+}
+
+func TestStateTrackerLayoutAndForget(t *testing.T) {
+	const img Image = 1
+	var tr StateTracker
+	tr.TrackImage(img, 2, 1, 0, StateUndefined)
+	tr.UseImage(img, ImageRange{BaseMip: 1}, StateTransferDst)
+	tr.Barriers()
+	if got := tr.Layout(img, 0, 0); got != LayoutUndefined {
+		t.Errorf("mip 0 layout = %d, want undefined", got)
+	}
+	if got := tr.Layout(img, 1, 0); got != LayoutTransferDstOptimal {
+		t.Errorf("mip 1 layout = %d, want transfer dst", got)
+	}
+
+	// A use pending when the image is forgotten produces no barrier.
+	tr.UseImage(img, ImageRange{}, StateSampledFragment)
+	tr.Forget(img)
+	if images, _ := tr.Barriers(); images != nil {
+		t.Errorf("barriers after Forget = %+v, want none", images)
+	}
+	if got := tr.Layout(img, 1, 0); got != LayoutUndefined {
+		t.Errorf("layout after Forget = %d, want undefined", got)
+	}
+}
@@ -38,8 +38,13 @@ const (
 	ErrorExtNotPresent Result = -7
 	ErrorFeatureNotPresent Result = -8
 	ErrorIncompatibleDriver Result = -9
+	ErrorFragmentedPool Result = -12
+	ErrorOutOfPoolMemory Result = -1000069000
 	SuboptimalKHR      Result = 1000001003
 	ErrorOutOfDateKHR  Result = -1000001004
+	// IncompatibleShaderBinaryEXT reports shader binary code from another
+	// driver or device; recreate the shaders from SPIR-V.
+	IncompatibleShaderBinaryEXT Result = 1000482000
 )
 
 // Ok reports whether r is VK_SUCCESS.
@@ -69,10 +74,16 @@ func (r Result) String() string {
 		return "VK_ERROR_FEATURE_NOT_PRESENT"
 	case ErrorIncompatibleDriver:
 		return "VK_ERROR_INCOMPATIBLE_DRIVER"
+	case ErrorFragmentedPool:
+		return "VK_ERROR_FRAGMENTED_POOL"
+	case ErrorOutOfPoolMemory:
+		return "VK_ERROR_OUT_OF_POOL_MEMORY"
 	case SuboptimalKHR:
 		return "VK_SUBOPTIMAL_KHR"
 	case ErrorOutOfDateKHR:
 		return "VK_ERROR_OUT_OF_DATE_KHR"
+	case IncompatibleShaderBinaryEXT:
+		return "VK_INCOMPATIBLE_SHADER_BINARY_EXT"
 	default:
 		return fmt.Sprintf("VkResult(%d)", int32(r))
 	}
@@ -0,0 +1,243 @@
+package vk
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+	"unsafe"
+
+	vulkan "github.com/christerso/vulkan-go/vulkan"
+)
+
+// waitSlice bounds each blocking wait of the context-aware waits: the driver
+// returns as soon as the wait is satisfied, and a cancelled context is
+// noticed within one slice.
+const waitSlice = time.Millisecond
+
+// waitSliced calls wait with timeouts of at most waitSlice, and less near
+// ctx's deadline, until it returns something other than VK_TIMEOUT or ctx
+// is done.
+func waitSliced(ctx context.Context, op string, wait func(timeout uint64) Result) error {
+	for {
+		slice := waitSlice
+		if deadline, ok := ctx.Deadline(); ok {
+			slice = max(min(slice, time.Until(deadline)), 0)
+		}
+		if res := wait(uint64(slice)); res != Timeout {
+			return res.asError(op)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// waitFences calls vkWaitForFences on fences.
+func (d Device) waitFences(fences []vulkan.VkFence, all bool, timeout uint64) Result {
+	var waitAll vulkan.VkBool32
+	if all {
+		waitAll = 1
+	}
+	res := Result(vulkan.VkWaitForFences(vulkan.VkDevice(d), uint32(len(fences)), unsafe.Pointer(&fences[0]), waitAll, timeout))
+	runtime.KeepAlive(fences)
+	return res
+}
+
+func rawFences(fences []Fence) []vulkan.VkFence {
+	vfs := make([]vulkan.VkFence, len(fences))
+	for i, f := range fences {
+		vfs[i] = vulkan.VkFence(f)
+	}
+	return vfs
+}
+
+// WaitFenceContext waits until every fence is signaled or ctx is done, in
+// which case it returns ctx.Err(). Unlike WaitFence it can be cancelled, so
+// shutdown does not hang on a lost device.
+func (d Device) WaitFenceContext(ctx context.Context, fences ...Fence) error {
+	if len(fences) == 0 {
+		return nil
+	}
+	vfs := rawFences(fences)
+	return waitSliced(ctx, "vkWaitForFences", func(timeout uint64) Result {
+		return d.waitFences(vfs, true, timeout)
+	})
+}
+
+// WaitAny waits until at least one fence is signaled and returns the index
+// of a signaled one, or until ctx is done.
+func (d Device) WaitAny(ctx context.Context, fences ...Fence) (int, error) {
+	if len(fences) == 0 {
+		return -1, errors.New("vk: WaitAny with no fences")
+	}
+	vfs := rawFences(fences)
+	err := waitSliced(ctx, "vkWaitForFences", func(timeout uint64) Result {
+		return d.waitFences(vfs, false, timeout)
+	})
+	if err != nil {
+		return -1, err
+	}
+	for i, f := range fences {
+		ok, err := d.FenceSignaled(f)
+		if err != nil {
+			return -1, err
+		}
+		if ok {
+			return i, nil
+		}
+	}
+	return -1, errors.New("vk: WaitAny: no fence signaled") // reset concurrently
+}
+
+// WaitContext waits until the counter reaches at least v or ctx is done.
+func (t TimelineSemaphore) WaitContext(ctx context.Context, v uint64) error {
+	waits := []SemaphoreValue{{Semaphore: t, Value: v}}
+	return waitSliced(ctx, "vkWaitSemaphores", func(timeout uint64) Result {
+		return t.device.waitSemaphores(waits, false, timeout)
+	})
+}
+
+// ErrWatcherClosed is delivered on the channels of fences still pending when
+// a FenceWatcher is closed.
+var ErrWatcherClosed = errors.New("vk: fence watcher closed")
+
+// FenceWatcher turns fence completion into channel notifications, so
+// goroutines can select on GPU work alongside other events. One goroutine
+// waits on all watched fences at once.
+type FenceWatcher struct {
+	device Device
+
+	mu      sync.Mutex
+	pending []watchedFence
+	closed  bool  // Close called
+	err     error // why run stopped
+	wake    chan struct{}
+	done    chan struct{}
+	exited  chan struct{}
+}
+
+type watchedFence struct {
+	fence Fence
+	ch    chan error
+}
+
+// NewFenceWatcher starts a watcher on d. Close stops it.
+func NewFenceWatcher(d Device) *FenceWatcher {
+	w := &FenceWatcher{
+		device: d,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Watch returns a channel that receives nil once f is signaled, or the
+// error that stopped the watcher, such as a lost device or ErrWatcherClosed.
+// It receives exactly one value. f must not be reset or destroyed before
+// then.
+func (w *FenceWatcher) Watch(f Fence) <-chan error {
+	ch := make(chan error, 1)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		ch <- w.err
+		return ch
+	}
+	w.pending = append(w.pending, watchedFence{fence: f, ch: ch})
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+	return ch
+}
+
+// Close stops the watcher and waits for its goroutine to exit. Fences still
+// pending receive ErrWatcherClosed.
+func (w *FenceWatcher) Close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.done)
+	}
+	w.mu.Unlock()
+	<-w.exited
+}
+
+func (w *FenceWatcher) run() {
+	defer close(w.exited)
+	var vfs []vulkan.VkFence
+	for {
+		w.mu.Lock()
+		vfs = vfs[:0]
+		for _, p := range w.pending {
+			vfs = append(vfs, vulkan.VkFence(p.fence))
+		}
+		w.mu.Unlock()
+
+		var err error
+		if len(vfs) == 0 {
+			select {
+			case <-w.wake:
+				continue
+			case <-w.done:
+				err = ErrWatcherClosed
+			}
+		} else {
+			select {
+			case <-w.done:
+				err = ErrWatcherClosed
+			default:
+				// Only a successful wait means some fence is signaled; a
+				// timeout just starts the next slice, which also picks up
+				// fences watched meanwhile.
+				switch res := w.device.waitFences(vfs, false, uint64(waitSlice)); res {
+				case Success:
+					err = w.deliver()
+				case Timeout:
+				default:
+					err = res.asError("vkWaitForFences")
+				}
+			}
+		}
+		if err != nil {
+			w.stop(err)
+			return
+		}
+	}
+}
+
+// deliver notifies and drops the pending fences that are signaled.
+func (w *FenceWatcher) deliver() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var kept []watchedFence
+	for i, p := range w.pending {
+		ok, err := w.device.FenceSignaled(p.fence)
+		if err != nil {
+			w.pending = append(kept, w.pending[i:]...)
+			return err
+		}
+		if ok {
+			p.ch <- nil
+		} else {
+			kept = append(kept, p)
+		}
+	}
+	w.pending = kept
+	return nil
+}
+
+// stop delivers err to every pending fence and to later Watch calls.
+func (w *FenceWatcher) stop(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.err = err
+	for _, p := range w.pending {
+		p.ch <- err
+	}
+	w.pending = nil
+}
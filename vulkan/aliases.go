@@ -0,0 +1,40 @@
+package vulkan
+
+// This file is written by hand; vkgen does not generate it. Add a command's
+// extension name here along with the vk code that first calls the command.
+
+import "reflect"
+
+// LoadDeviceAliases binds commands that were promoted to core from an
+// extension under their extension names, for devices that expose only the
+// extension form (an older core version with the extension enabled).
+// LoadDevice binds the core names only. Commands already bound are left
+// alone.
+func LoadDeviceAliases(device uintptr) {
+	for _, a := range []struct {
+		fptr any
+		name string
+	}{
+		{&VkCmdBeginRenderPass2, "vkCmdBeginRenderPass2KHR"},
+		{&VkCmdBeginRendering, "vkCmdBeginRenderingKHR"},
+		{&VkCmdEndRenderPass2, "vkCmdEndRenderPass2KHR"},
+		{&VkCmdEndRendering, "vkCmdEndRenderingKHR"},
+		{&VkCmdNextSubpass2, "vkCmdNextSubpass2KHR"},
+		{&VkCmdPipelineBarrier2, "vkCmdPipelineBarrier2KHR"},
+		{&VkCmdPushDescriptorSet, "vkCmdPushDescriptorSetKHR"},
+		{&VkCmdPushDescriptorSetWithTemplate, "vkCmdPushDescriptorSetWithTemplateKHR"},
+		{&VkCreateDescriptorUpdateTemplate, "vkCreateDescriptorUpdateTemplateKHR"},
+		{&VkCreateRenderPass2, "vkCreateRenderPass2KHR"},
+		{&VkDestroyDescriptorUpdateTemplate, "vkDestroyDescriptorUpdateTemplateKHR"},
+		{&VkGetBufferDeviceAddress, "vkGetBufferDeviceAddressKHR"},
+		{&VkGetSemaphoreCounterValue, "vkGetSemaphoreCounterValueKHR"},
+		{&VkQueueSubmit2, "vkQueueSubmit2KHR"},
+		{&VkSignalSemaphore, "vkSignalSemaphoreKHR"},
+		{&VkUpdateDescriptorSetWithTemplate, "vkUpdateDescriptorSetWithTemplateKHR"},
+		{&VkWaitSemaphores, "vkWaitSemaphoresKHR"},
+	} {
+		if reflect.ValueOf(a.fptr).Elem().IsNil() {
+			bindDevice(a.fptr, device, a.name)
+		}
+	}
+}